@@ -31,6 +31,9 @@ import (
 // value and are a no-op if set.
 // TODO: add a fast path for *TypeMeta and *ObjectMeta for internal objects
 func Accessor(obj interface{}) (Interface, error) {
+	if unstructured, ok := obj.(*runtime.Unstructured); ok {
+		return unstructuredAccessor{unstructured}, nil
+	}
 	v, err := conversion.EnforcePtr(obj)
 	if err != nil {
 		return nil, err
@@ -80,6 +83,9 @@ func Accessor(obj interface{}) (Interface, error) {
 // in round tripping (objects which can use apiVersion/kind, but do not fit the Kube
 // api conventions).
 func TypeAccessor(obj interface{}) (TypeInterface, error) {
+	if unstructured, ok := obj.(*runtime.Unstructured); ok {
+		return unstructuredAccessor{unstructured}, nil
+	}
 	v, err := conversion.EnforcePtr(obj)
 	if err != nil {
 		return nil, err
@@ -603,3 +603,61 @@ func TestTypeMetaSelfLinker(t *testing.T) {
 		}
 	}
 }
+
+func TestUnstructuredAccessor(t *testing.T) {
+	obj := &runtime.Unstructured{
+		TypeMeta: runtime.TypeMeta{APIVersion: "example.com/v1", Kind: "Widget"},
+		Object: map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"metadata": map[string]interface{}{
+				"name":            "foo",
+				"namespace":       "bar",
+				"resourceVersion": "1",
+				"labels":          map[string]interface{}{"a": "b"},
+			},
+		},
+	}
+	accessor, err := Accessor(obj)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := "foo", accessor.Name(); e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if e, a := "bar", accessor.Namespace(); e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if e, a := "1", accessor.ResourceVersion(); e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if e, a := map[string]string{"a": "b"}, accessor.Labels(); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if e, a := "Widget", accessor.Kind(); e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+
+	accessor.SetResourceVersion("2")
+	accessor.SetUID("uid")
+	accessor.SetAnnotations(map[string]string{"c": "d"})
+	accessor.SetKind("Gadget")
+	metadata := obj.Object["metadata"].(map[string]interface{})
+	if metadata["resourceVersion"] != "2" || metadata["uid"] != "uid" {
+		t.Errorf("unexpected metadata: %#v", metadata)
+	}
+	if e, a := map[string]interface{}{"c": "d"}, metadata["annotations"]; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if obj.Kind != "Gadget" || obj.Object["kind"] != "Gadget" {
+		t.Errorf("unexpected kind: %#v", obj)
+	}
+	accessor.SetAnnotations(nil)
+	if _, found := metadata["annotations"]; found {
+		t.Errorf("expected the annotations to be removed: %#v", metadata)
+	}
+
+	if err := NewAccessor().SetName(&runtime.Unstructured{}, "baz"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
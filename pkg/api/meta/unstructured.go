@@ -0,0 +1,166 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/types"
+)
+
+// unstructuredAccessor implements Interface for objects whose type is not known to
+// the client, reading and writing the standard metadata fields in the JSON map of a
+// runtime.Unstructured.
+type unstructuredAccessor struct {
+	obj *runtime.Unstructured
+}
+
+func (a unstructuredAccessor) metadata() map[string]interface{} {
+	m, _ := a.obj.Object["metadata"].(map[string]interface{})
+	return m
+}
+
+func (a unstructuredAccessor) field(key string) string {
+	s, _ := a.metadata()[key].(string)
+	return s
+}
+
+func (a unstructuredAccessor) setField(key string, value interface{}) {
+	if a.obj.Object == nil {
+		a.obj.Object = make(map[string]interface{})
+	}
+	m := a.metadata()
+	if m == nil {
+		m = make(map[string]interface{})
+		a.obj.Object["metadata"] = m
+	}
+	m[key] = value
+}
+
+func (a unstructuredAccessor) stringMap(key string) map[string]string {
+	m, ok := a.metadata()[key].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		if s, ok := v.(string); ok {
+			out[k] = s
+		}
+	}
+	return out
+}
+
+func (a unstructuredAccessor) setStringMap(key string, values map[string]string) {
+	if values == nil {
+		delete(a.metadata(), key)
+		return
+	}
+	m := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		m[k] = v
+	}
+	a.setField(key, m)
+}
+
+func (a unstructuredAccessor) setTypeField(key string, value string) {
+	if a.obj.Object == nil {
+		a.obj.Object = make(map[string]interface{})
+	}
+	a.obj.Object[key] = value
+}
+
+func (a unstructuredAccessor) APIVersion() string {
+	return a.obj.APIVersion
+}
+
+func (a unstructuredAccessor) SetAPIVersion(version string) {
+	a.obj.APIVersion = version
+	a.setTypeField("apiVersion", version)
+}
+
+func (a unstructuredAccessor) Kind() string {
+	return a.obj.Kind
+}
+
+func (a unstructuredAccessor) SetKind(kind string) {
+	a.obj.Kind = kind
+	a.setTypeField("kind", kind)
+}
+
+func (a unstructuredAccessor) Namespace() string {
+	return a.field("namespace")
+}
+
+func (a unstructuredAccessor) SetNamespace(namespace string) {
+	a.setField("namespace", namespace)
+}
+
+func (a unstructuredAccessor) Name() string {
+	return a.field("name")
+}
+
+func (a unstructuredAccessor) SetName(name string) {
+	a.setField("name", name)
+}
+
+func (a unstructuredAccessor) GenerateName() string {
+	return a.field("generateName")
+}
+
+func (a unstructuredAccessor) SetGenerateName(name string) {
+	a.setField("generateName", name)
+}
+
+func (a unstructuredAccessor) UID() types.UID {
+	return types.UID(a.field("uid"))
+}
+
+func (a unstructuredAccessor) SetUID(uid types.UID) {
+	a.setField("uid", string(uid))
+}
+
+func (a unstructuredAccessor) ResourceVersion() string {
+	return a.field("resourceVersion")
+}
+
+func (a unstructuredAccessor) SetResourceVersion(version string) {
+	a.setField("resourceVersion", version)
+}
+
+func (a unstructuredAccessor) SelfLink() string {
+	return a.field("selfLink")
+}
+
+func (a unstructuredAccessor) SetSelfLink(selfLink string) {
+	a.setField("selfLink", selfLink)
+}
+
+func (a unstructuredAccessor) Labels() map[string]string {
+	return a.stringMap("labels")
+}
+
+func (a unstructuredAccessor) SetLabels(labels map[string]string) {
+	a.setStringMap("labels", labels)
+}
+
+func (a unstructuredAccessor) Annotations() map[string]string {
+	return a.stringMap("annotations")
+}
+
+func (a unstructuredAccessor) SetAnnotations(annotations map[string]string) {
+	a.setStringMap("annotations", annotations)
+}
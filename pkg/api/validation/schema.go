@@ -123,10 +123,7 @@ func (s *SwaggerSchema) ValidateObject(obj interface{}, apiVersion, fieldName, t
 	for key, value := range fields {
 		details, ok := properties.At(key)
 		if !ok {
-			glog.Infof("unknown field: %s", key)
-			// Some properties can be missing because of
-			// https://github.com/GoogleCloudPlatform/kubernetes/issues/6842.
-			glog.Info("this may be a false alarm, see https://github.com/GoogleCloudPlatform/kubernetes/issues/6842")
+			allErrs = append(allErrs, fmt.Errorf("field %s%s: unknown field", fieldName, key))
 			continue
 		}
 		if details.Type == nil && details.Ref == nil {
@@ -93,6 +93,7 @@ func TestInvalid(t *testing.T) {
 		"invalidPod2.json", // hostPort if of type string, instead of int.
 		"invalidPod3.json", // volumes is not an array of objects.
 		"invalidPod.yaml",  // command is a string, instead of []string.
+		"invalidPod4.yaml", // imagePullPolcy is not a known field.
 	}
 	for _, test := range tests {
 		pod, err := readPod(test)
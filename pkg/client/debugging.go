@@ -91,6 +91,12 @@ func NewDebuggingRoundTripper(rt http.RoundTripper, levels ...string) *Debugging
 	return &DebuggingRoundTripper{rt, util.NewStringSet(levels...)}
 }
 
+func (rt *DebuggingRoundTripper) CancelRequest(req *http.Request) {
+	if canceler, ok := rt.delegatedRoundTripper.(requestCanceler); ok {
+		canceler.CancelRequest(req)
+	}
+}
+
 func (rt *DebuggingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	reqInfo := NewRequestInfo(req)
 
@@ -88,6 +88,11 @@ type Config struct {
 
 	// Maximum burst for throttle
 	Burst int
+
+	// RateLimiter, if set, throttles the requests of every client created from this
+	// config instead of a limiter created from QPS and Burst for each client. Copies
+	// of the config share it, so that together their clients stay within one budget.
+	RateLimiter util.RateLimiter
 }
 
 type KubeletConfig struct {
@@ -181,11 +186,22 @@ func NegotiateVersion(client *Client, c *Config, version string, clientRegistere
 			return "", err
 		}
 	}
+	return NegotiateVersionWith(client, c, version, clientRegisteredVersions)
+}
+
+// APIVersionsInterface has a method to retrieve the API versions the server supports.
+type APIVersionsInterface interface {
+	ServerAPIVersions() (*api.APIVersions, error)
+}
+
+// NegotiateVersionWith is like NegotiateVersion, but reads the server's supported api
+// versions from versions, for example a client that caches them.
+func NegotiateVersionWith(versions APIVersionsInterface, c *Config, version string, clientRegisteredVersions []string) (string, error) {
 	clientVersions := util.StringSet{}
 	for _, v := range clientRegisteredVersions {
 		clientVersions.Insert(v)
 	}
-	apiVersions, err := client.ServerAPIVersions()
+	apiVersions, err := versions.ServerAPIVersions()
 	if err != nil {
 		return "", fmt.Errorf("couldn't read version from server: %v\n", err)
 	}
@@ -316,7 +332,15 @@ func RESTClientFor(config *Config) (*RESTClient, error) {
 		return nil, err
 	}
 
-	client := NewRESTClient(baseURL, config.Version, config.Codec, config.QPS, config.Burst)
+	qps := config.QPS
+	if config.RateLimiter != nil {
+		qps = 0
+	}
+	client := NewRESTClient(baseURL, config.Version, config.Codec, qps, config.Burst)
+	if config.RateLimiter != nil {
+		client.Throttle = config.RateLimiter
+	}
+	client.UserAgent = config.UserAgent
 
 	transport, err := TransportFor(config)
 	if err != nil {
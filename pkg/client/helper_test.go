@@ -321,6 +321,30 @@ func TestSetKubernetesDefaultsUserAgent(t *testing.T) {
 	}
 }
 
+type countingRateLimiter struct {
+	accepted int
+}
+
+func (r *countingRateLimiter) CanAccept() bool { return true }
+func (r *countingRateLimiter) Accept()         { r.accepted++ }
+func (r *countingRateLimiter) Stop()           {}
+
+func TestRESTClientForSharedRateLimiter(t *testing.T) {
+	limiter := &countingRateLimiter{}
+	config := &Config{Host: "localhost", Version: testapi.Version(), Codec: testapi.Codec(), QPS: 5, Burst: 10, RateLimiter: limiter}
+	for i := 0; i < 2; i++ {
+		client, err := RESTClientFor(config)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		client.Get()
+		client.Post()
+	}
+	if limiter.accepted != 4 {
+		t.Errorf("expected every request of every client to be throttled by the shared limiter, got %d", limiter.accepted)
+	}
+}
+
 func objBody(object interface{}) io.ReadCloser {
 	output, err := json.MarshalIndent(object, "", "")
 	if err != nil {
@@ -28,6 +28,7 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
@@ -41,6 +42,7 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 	watchjson "github.com/GoogleCloudPlatform/kubernetes/pkg/watch/json"
 	"github.com/golang/glog"
+	"golang.org/x/net/context"
 )
 
 // specialParams lists parameters that are handled specially and which users of Request
@@ -89,6 +91,13 @@ type Request struct {
 
 	apiVersion string
 
+	// ctx, if set, cancels the request when it is done
+	ctx context.Context
+
+	// retry policy for throttled requests and server errors, disabled if maxRetries is zero
+	maxRetries int
+	retryDelay time.Duration
+
 	// output
 	err  error
 	body io.Reader
@@ -450,7 +459,9 @@ func (r *Request) SetHeader(key, value string) *Request {
 }
 
 // Timeout makes the request use the given duration as a timeout. Sets the "timeout"
-// parameter.
+// parameter, and requests that read the whole response (Do and DoRaw) are also
+// abandoned by the client once the duration has passed, so that an unresponsive
+// server cannot block them indefinitely.
 func (r *Request) Timeout(d time.Duration) *Request {
 	if r.err != nil {
 		return r
@@ -459,6 +470,144 @@ func (r *Request) Timeout(d time.Duration) *Request {
 	return r
 }
 
+// Context makes the request abort when ctx is cancelled or its deadline passes.
+// A request that has not been sent yet fails with the context's error.
+func (r *Request) Context(ctx context.Context) *Request {
+	if r.err != nil {
+		return r
+	}
+	r.ctx = ctx
+	return r
+}
+
+// Retry makes the request retry up to maxRetries times when the server responds
+// with 429 Too Many Requests or 503 Service Unavailable, or, for idempotent verbs
+// (GET, HEAD, PUT, and DELETE), with any other 5xx error except 501 Not
+// Implemented. POST and PATCH requests are not retried after other server errors,
+// which may have been returned after the change was made. The first retry waits
+// for delay, and the wait doubles with every attempt. A longer wait requested by
+// the server with a Retry-After header is honored. The response of the last
+// attempt is returned.
+func (r *Request) Retry(maxRetries int, delay time.Duration) *Request {
+	if r.err != nil {
+		return r
+	}
+	r.maxRetries = maxRetries
+	r.retryDelay = delay
+	return r
+}
+
+// WrapClient replaces the HTTPClient that sends the request with the one returned
+// by wrap, which is passed the current client. It allows logging, headers, or
+// measurements to be added around every attempt to send the request. A request
+// without a client wraps http.DefaultClient, which it would otherwise be sent with.
+func (r *Request) WrapClient(wrap func(HTTPClient) HTTPClient) *Request {
+	if r.err != nil {
+		return r
+	}
+	client := r.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	r.client = wrap(client)
+	return r
+}
+
+// newHTTPRequest creates the http.Request for url, carrying the headers set with
+// SetHeader. It fails if the request's context is already done.
+func (r *Request) newHTTPRequest(url string, body io.Reader) (*http.Request, error) {
+	if r.ctx != nil {
+		if err := r.ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequest(r.verb, url, body)
+	if err != nil {
+		return nil, err
+	}
+	// each attempt gets its own headers, which middleware may modify
+	for key, values := range r.headers {
+		req.Header[key] = values
+	}
+	return req, nil
+}
+
+// do sends req with client. If the request has a context, req is cancelled through
+// the transport of client once the context is done, if the transport supports it,
+// and the context's error is returned without waiting for the response. A response
+// received before then has its body closed once the context is done, so that reading
+// it fails.
+func (r *Request) do(client HTTPClient, req *http.Request) (*http.Response, error) {
+	if r.ctx == nil {
+		return client.Do(req)
+	}
+	type result struct {
+		resp *http.Response
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		resp, err := client.Do(req)
+		results <- result{resp, err}
+	}()
+	select {
+	case result := <-results:
+		if result.err != nil {
+			return nil, result.err
+		}
+		result.resp.Body = newContextBody(r.ctx, result.resp.Body)
+		return result.resp, nil
+	case <-r.ctx.Done():
+		cancelRequest(client, req)
+		go func() {
+			if result := <-results; result.resp != nil {
+				result.resp.Body.Close()
+			}
+		}()
+		return nil, r.ctx.Err()
+	}
+}
+
+// cancelRequest aborts req if client is an *http.Client whose transport can cancel
+// requests.
+func cancelRequest(client HTTPClient, req *http.Request) {
+	httpClient, ok := client.(*http.Client)
+	if !ok {
+		return
+	}
+	transport := httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if canceler, ok := transport.(requestCanceler); ok {
+		canceler.CancelRequest(req)
+	}
+}
+
+// contextBody is a response body that is closed once a context is done.
+type contextBody struct {
+	io.ReadCloser
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newContextBody(ctx context.Context, body io.ReadCloser) io.ReadCloser {
+	b := &contextBody{ReadCloser: body, closed: make(chan struct{})}
+	go func() {
+		select {
+		case <-ctx.Done():
+			body.Close()
+		case <-b.closed:
+		}
+	}()
+	return b
+}
+
+func (b *contextBody) Close() error {
+	b.once.Do(func() { close(b.closed) })
+	return b.ReadCloser.Close()
+}
+
 // Body makes the request use obj as the body. Optional.
 // If obj is a string, try to read a file of that name.
 // If obj is a []byte, send it directly.
@@ -561,7 +710,7 @@ func (r *Request) Watch() (watch.Interface, error) {
 		return nil, r.err
 	}
 	url := r.URL().String()
-	req, err := http.NewRequest(r.verb, url, r.body)
+	req, err := r.newHTTPRequest(url, r.body)
 	if err != nil {
 		return nil, err
 	}
@@ -569,7 +718,7 @@ func (r *Request) Watch() (watch.Interface, error) {
 	if client == nil {
 		client = http.DefaultClient
 	}
-	resp, err := client.Do(req)
+	resp, err := r.do(client, req)
 	if err != nil {
 		// The watch stream mechanism handles many common partial data errors, so closed
 		// connections can be retried in many cases.
@@ -596,7 +745,7 @@ func (r *Request) Stream() (io.ReadCloser, error) {
 		return nil, r.err
 	}
 	url := r.URL().String()
-	req, err := http.NewRequest(r.verb, url, nil)
+	req, err := r.newHTTPRequest(url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -604,7 +753,7 @@ func (r *Request) Stream() (io.ReadCloser, error) {
 	if client == nil {
 		client = http.DefaultClient
 	}
-	resp, err := client.Do(req)
+	resp, err := r.do(client, req)
 	if err != nil {
 		return nil, err
 	}
@@ -692,19 +841,43 @@ func (r *Request) request(fn func(*http.Request, *http.Response)) error {
 		client = http.DefaultClient
 	}
 
+	// The timeout covers every attempt, including the time spent reading the response.
+	if r.timeout > 0 {
+		parent := r.ctx
+		if parent == nil {
+			parent = context.Background()
+		}
+		ctx, cancel := context.WithTimeout(parent, r.timeout)
+		defer cancel()
+		defer func(ctx context.Context) { r.ctx = ctx }(r.ctx)
+		r.ctx = ctx
+	}
+
+	// The body is sent again on every retry, so it has to be buffered.
+	var body []byte
+	if r.maxRetries > 0 && r.body != nil {
+		data, err := ioutil.ReadAll(r.body)
+		if err != nil {
+			return err
+		}
+		body = data
+	}
+
 	// Right now we make about ten retry attempts if we get a Retry-After response.
 	// TODO: Change to a timeout based approach.
 	maxRetries := 10
 	retries := 0
 	for {
+		if body != nil {
+			r.body = bytes.NewReader(body)
+		}
 		url := r.URL().String()
-		req, err := http.NewRequest(r.verb, url, r.body)
+		req, err := r.newHTTPRequest(url, r.body)
 		if err != nil {
 			return err
 		}
-		req.Header = r.headers
 
-		resp, err := client.Do(req)
+		resp, err := r.do(client, req)
 		if err != nil {
 			return err
 		}
@@ -715,9 +888,15 @@ func (r *Request) request(fn func(*http.Request, *http.Response)) error {
 			defer resp.Body.Close()
 
 			retries++
-			if seconds, wait := checkWait(resp); wait && retries < maxRetries {
+			if r.maxRetries > 0 {
+				if delay, retry := r.retryBackoff(resp, retries); retry {
+					glog.V(4).Infof("Got a %d response for attempt %d to %v, retrying in %v", resp.StatusCode, retries, url, delay)
+					r.sleep(delay)
+					return false
+				}
+			} else if seconds, wait := checkWait(resp); wait && retries < maxRetries {
 				glog.V(4).Infof("Got a Retry-After %s response for attempt %d to %v", seconds, retries, url)
-				time.Sleep(time.Duration(seconds) * time.Second)
+				r.sleep(time.Duration(seconds) * time.Second)
 				return false
 			}
 			fn(req, resp)
@@ -729,6 +908,18 @@ func (r *Request) request(fn func(*http.Request, *http.Response)) error {
 	}
 }
 
+// sleep waits for d, returning early if the request's context is done.
+func (r *Request) sleep(d time.Duration) {
+	if r.ctx == nil {
+		time.Sleep(d)
+		return
+	}
+	select {
+	case <-r.ctx.Done():
+	case <-time.After(d):
+	}
+}
+
 // Do formats and executes the request. Returns a Result object for easy response
 // processing.
 //
@@ -805,6 +996,7 @@ func (r *Request) transformResponse(resp *http.Response, req *http.Request) Resu
 	return Result{
 		body:       body,
 		statusCode: resp.StatusCode,
+		warnings:   resp.Header[http.CanonicalHeaderKey("Warning")],
 		codec:      r.codec,
 	}
 }
@@ -856,6 +1048,36 @@ func isTextResponse(resp *http.Response) bool {
 	return strings.HasPrefix(media, "text/")
 }
 
+// retryBackoff returns true along with the time to wait if the response to the given
+// attempt should be retried according to the request's retry policy.
+func (r *Request) retryBackoff(resp *http.Response, attempt int) (time.Duration, bool) {
+	if attempt > r.maxRetries {
+		return 0, false
+	}
+	switch {
+	case resp.StatusCode == errors.StatusTooManyRequests, resp.StatusCode == http.StatusServiceUnavailable:
+		// The server did not act on the request.
+	case resp.StatusCode >= http.StatusInternalServerError && resp.StatusCode != http.StatusNotImplemented && isIdempotent(r.verb):
+	default:
+		return 0, false
+	}
+	delay := r.retryDelay << uint(attempt-1)
+	if seconds, ok := retryAfterSeconds(resp); ok && time.Duration(seconds)*time.Second > delay {
+		delay = time.Duration(seconds) * time.Second
+	}
+	return delay, true
+}
+
+// isIdempotent returns true if sending a request with verb more than once has the same
+// effect as sending it once.
+func isIdempotent(verb string) bool {
+	switch verb {
+	case "GET", "HEAD", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
 // checkWait returns true along with a number of seconds if the server instructed us to wait
 // before retrying.
 func checkWait(resp *http.Response) (int, bool) {
@@ -882,6 +1104,7 @@ type Result struct {
 	body       []byte
 	err        error
 	statusCode int
+	warnings   []string
 
 	codec runtime.Codec
 }
@@ -906,6 +1129,12 @@ func (r Result) StatusCode(statusCode *int) Result {
 	return r
 }
 
+// Warnings returns the values of any Warning headers the server attached to the
+// response. (Only valid if no error was returned.)
+func (r Result) Warnings() []string {
+	return r.warnings
+}
+
 // Into stores the result into obj, if possible.
 func (r Result) Into(obj runtime.Object) error {
 	if r.err != nil {
@@ -42,6 +42,7 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/httpstream"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 	watchjson "github.com/GoogleCloudPlatform/kubernetes/pkg/watch/json"
+	"golang.org/x/net/context"
 )
 
 func TestRequestWithErrorWontChange(t *testing.T) {
@@ -998,6 +999,181 @@ func TestWasCreated(t *testing.T) {
 	}
 }
 
+func TestRequestContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewReader([]byte{}))}, nil
+	})
+	if err := NewRequest(client, "GET", &url.URL{}, testapi.Version(), testapi.Codec()).Context(ctx).Do().Error(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a request in flight is cancelled through the transport
+	transport := &cancelTransport{sent: make(chan struct{}), abort: make(chan struct{})}
+	go func() {
+		<-transport.sent
+		cancel()
+	}()
+	httpClient := &http.Client{Transport: NewBasicAuthRoundTripper("user", "pass", transport)}
+	if err := NewRequest(httpClient, "GET", &url.URL{}, testapi.Version(), testapi.Codec()).Context(ctx).Do().Error(); err != context.Canceled {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if req := transport.cancelled; req == nil || len(req.Header.Get("Authorization")) == 0 {
+		t.Errorf("expected the request sent to be cancelled: %#v", req)
+	}
+
+	called := false
+	client = clientFunc(func(req *http.Request) (*http.Response, error) {
+		called = true
+		return nil, errors.New("unexpected call")
+	})
+	if err := NewRequest(client, "GET", &url.URL{}, testapi.Version(), testapi.Codec()).Context(ctx).Do().Error(); err != context.Canceled {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := NewRequest(client, "GET", &url.URL{}, testapi.Version(), testapi.Codec()).Context(ctx).Watch(); err != context.Canceled {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if called {
+		t.Errorf("a cancelled request should not be sent")
+	}
+}
+
+// cancelTransport blocks requests until they are cancelled.
+type cancelTransport struct {
+	sent      chan struct{}
+	abort     chan struct{}
+	cancelled *http.Request
+}
+
+func (t *cancelTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	close(t.sent)
+	<-t.abort
+	return nil, errors.New("request cancelled")
+}
+
+func (t *cancelTransport) CancelRequest(req *http.Request) {
+	t.cancelled = req
+	close(t.abort)
+}
+
+func TestRequestTimeoutDeadline(t *testing.T) {
+	hang := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("timeout") != "50ms" {
+			t.Errorf("unexpected query: %v", req.URL.RawQuery)
+		}
+		<-hang
+	}))
+	defer server.Close()
+	defer close(hang)
+	u, _ := url.Parse(server.URL)
+
+	done := make(chan error)
+	go func() {
+		done <- NewRequest(http.DefaultClient, "GET", u, testapi.Version(), testapi.Codec()).Timeout(50 * time.Millisecond).Do().Error()
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Errorf("expected the request to time out")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the request did not respect its timeout")
+	}
+}
+
+func TestRequestRetry(t *testing.T) {
+	tests := []struct {
+		Verb       string
+		Statuses   []int
+		MaxRetries int
+		Status     int
+		Attempts   int
+	}{
+		{Verb: "PUT", Statuses: []int{http.StatusServiceUnavailable, http.StatusInternalServerError, http.StatusOK}, MaxRetries: 3, Status: http.StatusOK, Attempts: 3},
+		{Verb: "POST", Statuses: []int{apierrors.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusOK}, MaxRetries: 2, Status: http.StatusOK, Attempts: 3},
+		{Verb: "POST", Statuses: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK}, MaxRetries: 1, Status: http.StatusServiceUnavailable, Attempts: 2},
+		{Verb: "POST", Statuses: []int{http.StatusNotFound, http.StatusOK}, MaxRetries: 3, Status: http.StatusNotFound, Attempts: 1},
+		{Verb: "PUT", Statuses: []int{http.StatusInternalServerError, http.StatusOK}, MaxRetries: 0, Status: http.StatusInternalServerError, Attempts: 1},
+		{Verb: "POST", Statuses: []int{http.StatusInternalServerError, http.StatusOK}, MaxRetries: 3, Status: http.StatusInternalServerError, Attempts: 1},
+		{Verb: "PATCH", Statuses: []int{http.StatusBadGateway, http.StatusOK}, MaxRetries: 3, Status: http.StatusBadGateway, Attempts: 1},
+		{Verb: "PUT", Statuses: []int{http.StatusNotImplemented, http.StatusOK}, MaxRetries: 3, Status: http.StatusNotImplemented, Attempts: 1},
+	}
+	for i, test := range tests {
+		attempts := 0
+		client := clientFunc(func(req *http.Request) (*http.Response, error) {
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil || string(body) != "payload" {
+				t.Errorf("%d: expected the body to be sent on every attempt: %q %v", i, string(body), err)
+			}
+			status := test.Statuses[attempts]
+			attempts++
+			return &http.Response{StatusCode: status, Body: ioutil.NopCloser(bytes.NewReader([]byte{}))}, nil
+		})
+		err := NewRequest(client, test.Verb, &url.URL{}, testapi.Version(), testapi.Codec()).
+			Body([]byte("payload")).
+			Retry(test.MaxRetries, 0).
+			Do().
+			Error()
+		if test.Status == http.StatusOK && err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+		}
+		if status, ok := err.(APIStatus); test.Status != http.StatusOK && (!ok || status.Status().Code != test.Status) {
+			t.Errorf("%d: expected a %d error: %v", i, test.Status, err)
+		}
+		if attempts != test.Attempts {
+			t.Errorf("%d: unexpected attempts: %d", i, attempts)
+		}
+	}
+}
+
+func TestRequestRetryBackoff(t *testing.T) {
+	r := NewRequest(nil, "GET", &url.URL{}, testapi.Version(), testapi.Codec()).Retry(3, time.Second)
+	tests := []struct {
+		Status     int
+		RetryAfter string
+		Attempt    int
+		Delay      time.Duration
+		Retry      bool
+	}{
+		{Status: http.StatusServiceUnavailable, Attempt: 1, Delay: time.Second, Retry: true},
+		{Status: http.StatusServiceUnavailable, Attempt: 3, Delay: 4 * time.Second, Retry: true},
+		{Status: http.StatusServiceUnavailable, Attempt: 4},
+		{Status: apierrors.StatusTooManyRequests, RetryAfter: "10", Attempt: 2, Delay: 10 * time.Second, Retry: true},
+		{Status: apierrors.StatusTooManyRequests, RetryAfter: "1", Attempt: 3, Delay: 4 * time.Second, Retry: true},
+		{Status: http.StatusInternalServerError, Attempt: 1, Delay: time.Second, Retry: true},
+		{Status: http.StatusNotImplemented, Attempt: 1},
+		{Status: http.StatusConflict, Attempt: 1},
+	}
+	for i, test := range tests {
+		resp := &http.Response{StatusCode: test.Status, Header: http.Header{}}
+		if len(test.RetryAfter) > 0 {
+			resp.Header.Set("Retry-After", test.RetryAfter)
+		}
+		delay, retry := r.retryBackoff(resp, test.Attempt)
+		if delay != test.Delay || retry != test.Retry {
+			t.Errorf("%d: unexpected backoff: %v %t", i, delay, retry)
+		}
+	}
+}
+
+func TestResultWarnings(t *testing.T) {
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Warning": []string{`299 - "v1beta1 is deprecated"`}},
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte{})),
+		}, nil
+	})
+	result := NewRequest(client, "GET", &url.URL{}, testapi.Version(), testapi.Codec()).Do()
+	if err := result.Error(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if warnings := result.Warnings(); !reflect.DeepEqual(warnings, []string{`299 - "v1beta1 is deprecated"`}) {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+}
+
 func TestVerbs(t *testing.T) {
 	c := NewOrDie(&Config{})
 	if r := c.Post(); r.verb != "POST" {
@@ -1273,3 +1449,29 @@ func TestStream(t *testing.T) {
 		t.Errorf("Expected %s, got %s", expectedBody, resultBody)
 	}
 }
+
+func TestWrapClientDefault(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer testServer.Close()
+	serverURL, _ := url.Parse(testServer.URL)
+
+	wrapped := 0
+	body, err := NewRequest(nil, "GET", serverURL, testapi.Version(), testapi.Codec()).
+		WrapClient(func(next HTTPClient) HTTPClient {
+			if next != http.DefaultClient {
+				t.Errorf("expected the default client to be wrapped, got %#v", next)
+			}
+			return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+				wrapped++
+				return next.Do(req)
+			})
+		}).DoRaw()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(body) != "ok" || wrapped != 1 {
+		t.Errorf("expected the request to be sent through the wrapped client once: %q %d", body, wrapped)
+	}
+}
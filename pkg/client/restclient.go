@@ -48,6 +48,9 @@ type RESTClient struct {
 
 	Timeout time.Duration
 
+	// UserAgent, if set, is sent as the User-Agent header of every request.
+	UserAgent string
+
 	// TODO extract this into a wrapper interface via the RESTClient interface in kubectl.
 	Throttle util.RateLimiter
 }
@@ -91,7 +94,11 @@ func (c *RESTClient) Verb(verb string) *Request {
 	if c.Throttle != nil {
 		c.Throttle.Accept()
 	}
-	return NewRequest(c.Client, verb, c.baseURL, c.apiVersion, c.Codec).Timeout(c.Timeout)
+	req := NewRequest(c.Client, verb, c.baseURL, c.apiVersion, c.Codec).Timeout(c.Timeout)
+	if len(c.UserAgent) != 0 {
+		req.SetHeader("User-Agent", c.UserAgent)
+	}
+	return req
 }
 
 // Post begins a POST request. Short for c.Verb("POST").
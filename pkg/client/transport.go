@@ -22,55 +22,112 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"sync"
 )
 
 type userAgentRoundTripper struct {
-	agent string
-	rt    http.RoundTripper
+	agent  string
+	rt     http.RoundTripper
+	clones clonedRequests
 }
 
 func NewUserAgentRoundTripper(agent string, rt http.RoundTripper) http.RoundTripper {
-	return &userAgentRoundTripper{agent, rt}
+	return &userAgentRoundTripper{agent: agent, rt: rt}
 }
 
 func (rt *userAgentRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	if len(req.Header.Get("User-Agent")) != 0 {
 		return rt.rt.RoundTrip(req)
 	}
-	req = cloneRequest(req)
-	req.Header.Set("User-Agent", rt.agent)
-	return rt.rt.RoundTrip(req)
+	clone := cloneRequest(req)
+	clone.Header.Set("User-Agent", rt.agent)
+	return rt.clones.roundTrip(rt.rt, req, clone)
+}
+
+func (rt *userAgentRoundTripper) CancelRequest(req *http.Request) {
+	rt.clones.cancel(rt.rt, req)
 }
 
 type basicAuthRoundTripper struct {
 	username string
 	password string
 	rt       http.RoundTripper
+	clones   clonedRequests
 }
 
 func NewBasicAuthRoundTripper(username, password string, rt http.RoundTripper) http.RoundTripper {
-	return &basicAuthRoundTripper{username, password, rt}
+	return &basicAuthRoundTripper{username: username, password: password, rt: rt}
 }
 
 func (rt *basicAuthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
-	req = cloneRequest(req)
-	req.SetBasicAuth(rt.username, rt.password)
-	return rt.rt.RoundTrip(req)
+	clone := cloneRequest(req)
+	clone.SetBasicAuth(rt.username, rt.password)
+	return rt.clones.roundTrip(rt.rt, req, clone)
+}
+
+func (rt *basicAuthRoundTripper) CancelRequest(req *http.Request) {
+	rt.clones.cancel(rt.rt, req)
 }
 
 type bearerAuthRoundTripper struct {
 	bearer string
 	rt     http.RoundTripper
+	clones clonedRequests
 }
 
 func NewBearerAuthRoundTripper(bearer string, rt http.RoundTripper) http.RoundTripper {
-	return &bearerAuthRoundTripper{bearer, rt}
+	return &bearerAuthRoundTripper{bearer: bearer, rt: rt}
 }
 
 func (rt *bearerAuthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
-	req = cloneRequest(req)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", rt.bearer))
-	return rt.rt.RoundTrip(req)
+	clone := cloneRequest(req)
+	clone.Header.Set("Authorization", fmt.Sprintf("Bearer %s", rt.bearer))
+	return rt.clones.roundTrip(rt.rt, req, clone)
+}
+
+func (rt *bearerAuthRoundTripper) CancelRequest(req *http.Request) {
+	rt.clones.cancel(rt.rt, req)
+}
+
+// requestCanceler is implemented by round trippers that can abort a request in flight,
+// such as *http.Transport.
+type requestCanceler interface {
+	CancelRequest(*http.Request)
+}
+
+// clonedRequests tracks the copies of requests in flight that a round tripper sends
+// in their place, so that cancelling a request cancels the copy that was sent.
+type clonedRequests struct {
+	lock   sync.Mutex
+	clones map[*http.Request]*http.Request
+}
+
+// roundTrip sends clone, the copy of req, with rt.
+func (c *clonedRequests) roundTrip(rt http.RoundTripper, req, clone *http.Request) (*http.Response, error) {
+	c.lock.Lock()
+	if c.clones == nil {
+		c.clones = make(map[*http.Request]*http.Request)
+	}
+	c.clones[req] = clone
+	c.lock.Unlock()
+	defer func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		delete(c.clones, req)
+	}()
+	return rt.RoundTrip(clone)
+}
+
+// cancel cancels req, or the copy of req in flight, if rt can cancel requests.
+func (c *clonedRequests) cancel(rt http.RoundTripper, req *http.Request) {
+	c.lock.Lock()
+	if clone, ok := c.clones[req]; ok {
+		req = clone
+	}
+	c.lock.Unlock()
+	if canceler, ok := rt.(requestCanceler); ok {
+		canceler.CancelRequest(req)
+	}
 }
 
 // TLSConfigFor returns a tls.Config that will provide the transport level security defined
@@ -137,6 +137,9 @@ func NewTestFactory() (*cmdutil.Factory, *testFactory, runtime.Codec) {
 		RESTClient: func(*meta.RESTMapping) (resource.RESTClient, error) {
 			return t.Client, t.Err
 		},
+		ResourceHelper: func(client resource.RESTClient, mapping *meta.RESTMapping) resource.ResourceHelper {
+			return resource.NewHelper(client, mapping)
+		},
 		Describer: func(*meta.RESTMapping) (kubectl.Describer, error) {
 			return t.Describer, t.Err
 		},
@@ -191,6 +194,9 @@ func NewAPIFactory() (*cmdutil.Factory, *testFactory, runtime.Codec) {
 		RESTClient: func(*meta.RESTMapping) (resource.RESTClient, error) {
 			return t.Client, t.Err
 		},
+		ResourceHelper: func(client resource.RESTClient, mapping *meta.RESTMapping) resource.ResourceHelper {
+			return resource.NewHelper(client, mapping)
+		},
 		Describer: func(*meta.RESTMapping) (kubectl.Describer, error) {
 			return t.Describer, t.Err
 		},
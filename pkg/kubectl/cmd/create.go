@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -39,7 +40,10 @@ JSON and YAML formats are accepted.`
 $ kubectl create -f pod.json
 
 // Create a pod based on the JSON passed into stdin.
-$ cat pod.json | kubectl create -f -`
+$ cat pod.json | kubectl create -f -
+
+// Create the resources in a directory, and apply them again whenever the files change.
+$ kubectl create -f dir/ --watch-files`
 )
 
 func NewCmdCreate(f *cmdutil.Factory, out io.Writer) *cobra.Command {
@@ -51,13 +55,15 @@ func NewCmdCreate(f *cmdutil.Factory, out io.Writer) *cobra.Command {
 		Example: create_example,
 		Run: func(cmd *cobra.Command, args []string) {
 			cmdutil.CheckErr(ValidateArgs(cmd, args))
-			cmdutil.CheckErr(RunCreate(f, out, filenames))
+			cmdutil.CheckErr(RunCreate(f, out, cmd, filenames))
 		},
 	}
 
 	usage := "Filename, directory, or URL to file to use to create the resource"
 	kubectl.AddJsonFilenameFlag(cmd, &filenames, usage)
 	cmd.MarkFlagRequired("filename")
+	cmd.Flags().Bool("override-namespace", false, "If true, create namespaced resources in the namespace of the command even if their manifests specify a different one. Otherwise such resources are rejected when --namespace is passed.")
+	cmd.Flags().Bool("watch-files", false, "If true, keep watching the files and directories passed with --filename after creating the resources, and apply the manifests again whenever the files change, removing fields deleted from them.")
 
 	return cmd
 }
@@ -69,7 +75,7 @@ func ValidateArgs(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func RunCreate(f *cmdutil.Factory, out io.Writer, filenames util.StringList) error {
+func RunCreate(f *cmdutil.Factory, out io.Writer, cmd *cobra.Command, filenames util.StringList) error {
 	schema, err := f.Validator()
 	if err != nil {
 		return err
@@ -81,41 +87,75 @@ func RunCreate(f *cmdutil.Factory, out io.Writer, filenames util.StringList) err
 	}
 
 	mapper, typer := f.Object()
-	r := resource.NewBuilder(mapper, typer, f.ClientMapperForCommand()).
+	b := resource.NewBuilder(mapper, typer, f.ClientMapperForCommand()).
 		Schema(schema).
 		ContinueOnError().
 		NamespaceParam(cmdNamespace).DefaultNamespace().
 		FilenameParam(enforceNamespace, filenames...).
 		Flatten().
-		Do()
-	err = r.Err()
+		OrderByKind(resource.KindOrder, false)
+	if cmdutil.GetFlagBool(cmd, "override-namespace") {
+		b.OverrideNamespace()
+	}
+	if cmdutil.GetFlagBool(cmd, "watch-files") {
+		return b.WatchFiles(resource.FileWatchOptions{Interval: time.Second}, nil, func(r *resource.Result, changes resource.FileChanges) error {
+			if !changes.Empty() {
+				fmt.Fprintf(out, "Files changed: %s\n", changes)
+			}
+			if _, err := createResult(r, out, true); err != nil {
+				fmt.Fprintf(out, "error: %v\n", err)
+			}
+			return nil
+		})
+	}
+
+	count, err := createResult(b.Do(), out, false)
 	if err != nil {
 		return err
 	}
+	if count == 0 {
+		return fmt.Errorf("no objects passed to create")
+	}
+	return nil
+}
+
+// createResult creates the objects of r and returns how many it created. If apply is
+// true, the objects are applied instead, so that they are created if they do not
+// exist and updated to match their manifests, including fields removed from them,
+// if they do.
+func createResult(r *resource.Result, out io.Writer, apply bool) (int, error) {
+	if err := r.Err(); err != nil {
+		return 0, err
+	}
 
 	count := 0
-	err = r.Visit(func(info *resource.Info) error {
+	err := r.Visit(func(info *resource.Info) error {
 		data, err := info.Mapping.Codec.Encode(info.Object)
 		if err != nil {
-			return cmdutil.AddSourceToErr("creating", info.Source, err)
+			return cmdutil.AddSourceToErr("creating", info.Location(), err)
 		}
-		obj, err := resource.NewHelper(info.Client, info.Mapping).Create(info.Namespace, true, data)
+		helper := resource.NewHelper(info.Client, info.Mapping)
+		if apply {
+			obj, err := helper.Apply(info.Namespace, info.Name, data)
+			if err != nil {
+				return cmdutil.AddSourceToErr("applying", info.Location(), err)
+			}
+			info.Refresh(obj, true)
+			count++
+			fmt.Fprintf(out, "%s/%s applied\n", info.Mapping.Resource, info.Name)
+			return nil
+		}
+		obj, err := helper.Create(info.Namespace, true, data)
 		if err != nil {
-			return cmdutil.AddSourceToErr("creating", info.Source, err)
+			return cmdutil.AddSourceToErr("creating", info.Location(), err)
 		}
-		count++
 		info.Refresh(obj, true)
+		count++
 		printObjectSpecificMessage(info.Object, out)
 		fmt.Fprintf(out, "%s/%s\n", info.Mapping.Resource, info.Name)
 		return nil
 	})
-	if err != nil {
-		return err
-	}
-	if count == 0 {
-		return fmt.Errorf("no objects passed to create")
-	}
-	return nil
+	return count, err
 }
 
 func printObjectSpecificMessage(obj runtime.Object, out io.Writer) {
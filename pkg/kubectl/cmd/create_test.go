@@ -94,8 +94,9 @@ func TestCreateMultipleObject(t *testing.T) {
 	cmd.Flags().Set("filename", "../../../examples/guestbook/frontend-service.yaml")
 	cmd.Run(cmd, []string{})
 
-	// Names should come from the REST response, NOT the files
-	if buf.String() != "replicationcontrollers/rc1\nservices/baz\n" {
+	// Names should come from the REST response, NOT the files, and services are
+	// created before the controllers that depend on them.
+	if buf.String() != "services/baz\nreplicationcontrollers/rc1\n" {
 		t.Errorf("unexpected output: %s", buf.String())
 	}
 }
@@ -127,7 +128,7 @@ func TestCreateDirectory(t *testing.T) {
 	cmd.Flags().Set("filename", "../../../examples/guestbook")
 	cmd.Run(cmd, []string{})
 
-	if buf.String() != "replicationcontrollers/name\nservices/baz\nreplicationcontrollers/name\nservices/baz\nreplicationcontrollers/name\nservices/baz\n" {
+	if buf.String() != "services/baz\nservices/baz\nservices/baz\nreplicationcontrollers/name\nreplicationcontrollers/name\nreplicationcontrollers/name\n" {
 		t.Errorf("unexpected output: %s", buf.String())
 	}
 }
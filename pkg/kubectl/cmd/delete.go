@@ -95,6 +95,7 @@ func RunDelete(f *cmdutil.Factory, out io.Writer, cmd *cobra.Command, args []str
 		SelectAllParam(cmdutil.GetFlagBool(cmd, "all")).
 		ResourceTypeOrNameArgs(false, args...).RequireObject(false).
 		Flatten().
+		OrderByKind(resource.KindOrder, true).
 		Do()
 	err = r.Err()
 	if err != nil {
@@ -106,7 +107,7 @@ func RunDelete(f *cmdutil.Factory, out io.Writer, cmd *cobra.Command, args []str
 	if cmdutil.GetFlagBool(cmd, "cascade") {
 		return ReapResult(r, f, out, cmdutil.GetFlagBool(cmd, "cascade"), ignoreNotFound, cmdutil.GetFlagDuration(cmd, "timeout"), cmdutil.GetFlagInt(cmd, "grace-period"))
 	}
-	return DeleteResult(r, out, ignoreNotFound)
+	return DeleteResult(r, out, ignoreNotFound, cmdutil.GetFlagInt(cmd, "grace-period"))
 }
 
 func ReapResult(r *resource.Result, f *cmdutil.Factory, out io.Writer, isDefaultDelete, ignoreNotFound bool, timeout time.Duration, gracePeriod int) error {
@@ -114,22 +115,22 @@ func ReapResult(r *resource.Result, f *cmdutil.Factory, out io.Writer, isDefault
 	if ignoreNotFound {
 		r = r.IgnoreErrors(errors.IsNotFound)
 	}
+	var options *api.DeleteOptions
+	if gracePeriod >= 0 {
+		options = api.NewDeleteOptions(int64(gracePeriod))
+	}
 	err := r.Visit(func(info *resource.Info) error {
 		found++
 		reaper, err := f.Reaper(info.Mapping)
 		if err != nil {
 			// If there is no reaper for this resources and the user didn't explicitly ask for stop.
 			if kubectl.IsNoSuchReaperError(err) && isDefaultDelete {
-				return deleteResource(info, out)
+				return deleteResource(info, out, options)
 			}
-			return cmdutil.AddSourceToErr("reaping", info.Source, err)
-		}
-		var options *api.DeleteOptions
-		if gracePeriod >= 0 {
-			options = api.NewDeleteOptions(int64(gracePeriod))
+			return cmdutil.AddSourceToErr("reaping", info.Location(), err)
 		}
 		if _, err := reaper.Stop(info.Namespace, info.Name, timeout, options); err != nil {
-			return cmdutil.AddSourceToErr("stopping", info.Source, err)
+			return cmdutil.AddSourceToErr("stopping", info.Location(), err)
 		}
 		fmt.Fprintf(out, "%s/%s\n", info.Mapping.Resource, info.Name)
 		return nil
@@ -143,14 +144,18 @@ func ReapResult(r *resource.Result, f *cmdutil.Factory, out io.Writer, isDefault
 	return nil
 }
 
-func DeleteResult(r *resource.Result, out io.Writer, ignoreNotFound bool) error {
+func DeleteResult(r *resource.Result, out io.Writer, ignoreNotFound bool, gracePeriod int) error {
 	found := 0
 	if ignoreNotFound {
 		r = r.IgnoreErrors(errors.IsNotFound)
 	}
+	var options *api.DeleteOptions
+	if gracePeriod >= 0 {
+		options = api.NewDeleteOptions(int64(gracePeriod))
+	}
 	err := r.Visit(func(info *resource.Info) error {
 		found++
-		return deleteResource(info, out)
+		return deleteResource(info, out, options)
 	})
 	if err != nil {
 		return err
@@ -161,9 +166,9 @@ func DeleteResult(r *resource.Result, out io.Writer, ignoreNotFound bool) error
 	return nil
 }
 
-func deleteResource(info *resource.Info, out io.Writer) error {
-	if err := resource.NewHelper(info.Client, info.Mapping).Delete(info.Namespace, info.Name); err != nil {
-		return cmdutil.AddSourceToErr("deleting", info.Source, err)
+func deleteResource(info *resource.Info, out io.Writer, options *api.DeleteOptions) error {
+	if err := resource.NewHelper(info.Client, info.Mapping).DeleteWithOptions(info.Namespace, info.Name, options); err != nil {
+		return cmdutil.AddSourceToErr("deleting", info.Location(), err)
 	}
 	fmt.Fprintf(out, "%s/%s\n", info.Mapping.Resource, info.Name)
 	return nil
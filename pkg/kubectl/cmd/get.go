@@ -24,6 +24,7 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/kubectl"
 	cmdutil "github.com/GoogleCloudPlatform/kubernetes/pkg/kubectl/cmd/util"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/kubectl/resource"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 
@@ -84,6 +85,7 @@ func NewCmdGet(f *cmdutil.Factory, out io.Writer) *cobra.Command {
 	cmd.Flags().BoolP("watch", "w", false, "After listing/getting the requested object, watch for changes.")
 	cmd.Flags().Bool("watch-only", false, "Watch for changes to the requested object(s), without listing/getting first.")
 	cmd.Flags().Bool("all-namespaces", false, "If present, list the requested object(s) across all namespaces. Namespace in current context is ignored even if specified with --namespace.")
+	cmd.Flags().Bool("export", false, "If true, clear the status and the fields set by the server, such as the resource version and UID, so that the output can be created in another cluster. Only applies to json, yaml, and template output.")
 	kubectl.AddLabelsToColumnsFlag(cmd, &util.StringList{}, "Accepts a comma separated list of labels that are going to be presented as columns. Names are case-sensitive. You can also use multiple flag statements like -L label1 -L label2...")
 	return cmd
 }
@@ -193,6 +195,13 @@ You must specify the type of resource to get. Valid resource types include:
 		if err != nil {
 			return err
 		}
+		if cmdutil.GetFlagBool(cmd, "export") {
+			for _, info := range infos {
+				if err := resource.ExportObject(info.Object); err != nil {
+					return err
+				}
+			}
+		}
 
 		// the outermost object will be converted to the output-version, but inner
 		// objects can use their mappings
@@ -206,11 +215,74 @@ You must specify the type of resource to get. Valid resource types include:
 	}
 
 	// use the default printer for each object
-	return b.Do().Visit(func(r *resource.Info) error {
+	tablePrinter := &tablePrinter{out: out, noHeaders: cmdutil.GetFlagBool(cmd, "no-headers")}
+	err = b.Do().Visit(func(r *resource.Info) error {
 		printer, err := f.PrinterForMapping(cmd, r.Mapping, allNamespaces)
 		if err != nil {
 			return err
 		}
+		// kinds without a compiled-in printer are printed with the columns the
+		// server describes for them
+		if human, ok := printer.(*kubectl.HumanReadablePrinter); ok && !human.Handles(r.Object) {
+			return tablePrinter.Add(r, selector)
+		}
+		if err := tablePrinter.Flush(); err != nil {
+			return err
+		}
 		return printer.PrintObj(r.Object, out)
 	})
+	if flushErr := tablePrinter.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+// tablePrinter collects the rows of consecutive infos of the same resource into one
+// table, so that they are aligned under a single header.
+type tablePrinter struct {
+	out       io.Writer
+	noHeaders bool
+
+	table    *resource.Table
+	resource string
+}
+
+// Add retrieves the table the server renders for the object or list of objects of
+// info, and adds its rows to the table of the resource of info.
+func (p *tablePrinter) Add(info *resource.Info, selector string) error {
+	helper := resource.NewHelper(info.Client, info.Mapping)
+	var (
+		table *resource.Table
+		err   error
+	)
+	if len(info.Name) == 0 {
+		var labelSelector labels.Selector
+		if labelSelector, err = labels.Parse(selector); err == nil {
+			table, err = helper.ListTable(info.Namespace, labelSelector)
+		}
+	} else {
+		table, err = helper.GetTable(info.Namespace, info.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if p.table != nil && p.resource == info.Mapping.Resource {
+		p.table.Rows = append(p.table.Rows, table.Rows...)
+		return nil
+	}
+	if err := p.Flush(); err != nil {
+		return err
+	}
+	p.table, p.resource = table, info.Mapping.Resource
+	return nil
+}
+
+// Flush prints the collected table, if any.
+func (p *tablePrinter) Flush() error {
+	if p.table == nil {
+		return nil
+	}
+	table := p.table
+	p.table, p.resource = nil, ""
+	return table.Print(p.out, p.noHeaders)
 }
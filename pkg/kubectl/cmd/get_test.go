@@ -23,6 +23,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"path"
 	"reflect"
 	"strings"
 	"testing"
@@ -31,6 +32,7 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/testapi"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/kubectl"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
@@ -142,6 +144,38 @@ func TestGetUnknownSchemaObject(t *testing.T) {
 	}
 }
 
+// Verifies that objects without a compiled-in printer are printed with the columns of the
+// table served for them, aligned under a single header.
+func TestGetUnknownSchemaObjectTable(t *testing.T) {
+	f, tf, codec := NewTestFactory()
+	tf.Printer = kubectl.NewHumanReadablePrinter(false, false, false, []string{})
+	tf.Client = &client.FakeRESTClient{
+		Codec: codec,
+		Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			name := path.Base(req.URL.Path)
+			if !strings.Contains(req.Header.Get("Accept"), "as=Table") {
+				return &http.Response{StatusCode: 200, Body: objBody(codec, &internalType{Name: name})}, nil
+			}
+			table := fmt.Sprintf(`{"kind":"Table","columnDefinitions":[{"name":"Name"},{"name":"Phase"}],"rows":[{"cells":[%q,"Running"]}]}`, name)
+			return &http.Response{StatusCode: 200, Body: stringBody(table)}, nil
+		}),
+	}
+	tf.Namespace = "test"
+	tf.ClientConfig = &client.Config{Version: latest.Version}
+	buf := bytes.NewBuffer([]byte{})
+
+	cmd := NewCmdGet(f, buf)
+	cmd.SetOutput(buf)
+	if err := RunGet(f, buf, cmd, []string{"type", "foo", "longername"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "NAME         PHASE\nfoo          Running\nlongername   Running\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+}
+
 // Verifies that schemas that are not in the master tree of Kubernetes can be retrieved via Get.
 // Because api.List is part of the Kube API, resource.Builder has to perform a conversion on
 // api.Scheme, which may not have access to all objects, and not all objects are at the same
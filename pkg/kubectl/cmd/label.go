@@ -90,15 +90,6 @@ func updateObject(info *resource.Info, updateFn func(runtime.Object) (runtime.Ob
 	return obj, nil
 }
 
-func validateNoOverwrites(meta *api.ObjectMeta, labels map[string]string) error {
-	for key := range labels {
-		if value, found := meta.Labels[key]; found {
-			return fmt.Errorf("'%s' already has a value (%s), and --overwrite is false", key, value)
-		}
-	}
-	return nil
-}
-
 func parseLabels(spec []string) (map[string]string, []string, error) {
 	labels := map[string]string{}
 	var remove []string
@@ -128,21 +119,8 @@ func labelFunc(obj runtime.Object, overwrite bool, resourceVersion string, label
 	if err != nil {
 		return nil, err
 	}
-	if !overwrite {
-		if err := validateNoOverwrites(meta, labels); err != nil {
-			return nil, err
-		}
-	}
-
-	if meta.Labels == nil {
-		meta.Labels = make(map[string]string)
-	}
-
-	for key, value := range labels {
-		meta.Labels[key] = value
-	}
-	for _, label := range remove {
-		delete(meta.Labels, label)
+	if err := resource.LabelObject(obj, labels, remove, overwrite); err != nil {
+		return nil, err
 	}
 
 	if len(resourceVersion) != 0 {
@@ -29,7 +29,7 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 )
 
-func TestValidateLabels(t *testing.T) {
+func TestLabelFuncNoOverwrite(t *testing.T) {
 	tests := []struct {
 		meta      *api.ObjectMeta
 		labels    map[string]string
@@ -87,7 +87,7 @@ func TestValidateLabels(t *testing.T) {
 		},
 	}
 	for _, test := range tests {
-		err := validateNoOverwrites(test.meta, test.labels)
+		_, err := labelFunc(&api.Pod{ObjectMeta: *test.meta}, false, "", test.labels, nil)
 		if test.expectErr && err == nil {
 			t.Errorf("%s: unexpected non-error", test.test)
 		}
@@ -107,11 +107,11 @@ func RunReplace(f *cmdutil.Factory, out io.Writer, cmd *cobra.Command, args []st
 	return r.Visit(func(info *resource.Info) error {
 		data, err := info.Mapping.Codec.Encode(info.Object)
 		if err != nil {
-			return cmdutil.AddSourceToErr("replacing", info.Source, err)
+			return cmdutil.AddSourceToErr("replacing", info.Location(), err)
 		}
 		obj, err := resource.NewHelper(info.Client, info.Mapping).Replace(info.Namespace, info.Name, true, data)
 		if err != nil {
-			return cmdutil.AddSourceToErr("replacing", info.Source, err)
+			return cmdutil.AddSourceToErr("replacing", info.Location(), err)
 		}
 		info.Refresh(obj, true)
 		printObjectSpecificMessage(obj, out)
@@ -150,7 +150,7 @@ func forceReplace(f *cmdutil.Factory, out io.Writer, cmd *cobra.Command, args []
 		glog.Warningf("\"cascade\" is set, kubectl will delete and re-create all resources managed by this resource (e.g. Pods created by a ReplicationController). Consider using \"kubectl rolling-update\" if you want to update a ReplicationController together with its Pods.")
 		err = ReapResult(r, f, out, cmdutil.GetFlagBool(cmd, "cascade"), ignoreNotFound, cmdutil.GetFlagDuration(cmd, "timeout"), cmdutil.GetFlagInt(cmd, "grace-period"))
 	} else {
-		err = DeleteResult(r, out, ignoreNotFound)
+		err = DeleteResult(r, out, ignoreNotFound, cmdutil.GetFlagInt(cmd, "grace-period"))
 	}
 	if err != nil {
 		return err
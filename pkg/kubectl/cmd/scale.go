@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 
 	"github.com/spf13/cobra"
 
@@ -90,11 +91,6 @@ func RunScale(f *cmdutil.Factory, out io.Writer, cmd *cobra.Command, args []stri
 	if err != nil {
 		return err
 	}
-	mapping, err := r.ResourceMapping()
-	if err != nil {
-		return err
-	}
-
 	infos, err := r.Infos()
 	if err != nil {
 		return err
@@ -104,17 +100,30 @@ func RunScale(f *cmdutil.Factory, out io.Writer, cmd *cobra.Command, args []stri
 	}
 	info := infos[0]
 
-	scaler, err := f.Scaler(mapping)
-	if err != nil {
-		return err
-	}
-
 	resourceVersion := cmdutil.GetFlagString(cmd, "resource-version")
 	currentSize := cmdutil.GetFlagInt(cmd, "current-replicas")
-	precondition := &kubectl.ScalePrecondition{currentSize, resourceVersion}
-	retry := kubectl.NewRetryParams(kubectl.Interval, kubectl.Timeout)
-	waitForReplicas := kubectl.NewRetryParams(kubectl.Interval, kubectl.Timeout)
-	if err := scaler.Scale(info.Namespace, info.Name, uint(count), precondition, retry, waitForReplicas); err != nil {
+	precondition := func(scale *resource.Scale) error {
+		if currentSize != -1 && scale.Replicas != currentSize {
+			return kubectl.PreconditionError{
+				Precondition:  "replicas",
+				ExpectedValue: strconv.Itoa(currentSize),
+				ActualValue:   strconv.Itoa(scale.Replicas),
+			}
+		}
+		if resourceVersion != "" && scale.ResourceVersion != resourceVersion {
+			return kubectl.PreconditionError{
+				Precondition:  "resource version",
+				ExpectedValue: resourceVersion,
+				ActualValue:   scale.ResourceVersion,
+			}
+		}
+		return nil
+	}
+	scaler := resource.NewScaleHelper(f.ResourceHelper(info.Client, info.Mapping))
+	if _, err := scaler.Update(info.Namespace, info.Name, count, precondition); err != nil {
+		return err
+	}
+	if _, err := scaler.WaitForReplicas(info.Namespace, info.Name, kubectl.Timeout); err != nil {
 		return err
 	}
 	fmt.Fprint(out, "scaled\n")
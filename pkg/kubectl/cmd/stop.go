@@ -81,6 +81,7 @@ func RunStop(f *cmdutil.Factory, cmd *cobra.Command, args []string, filenames ut
 		SelectorParam(cmdutil.GetFlagString(cmd, "selector")).
 		SelectAllParam(cmdutil.GetFlagBool(cmd, "all")).
 		Flatten().
+		OrderByKind(resource.KindOrder, true).
 		Do()
 	if r.Err() != nil {
 		return r.Err()
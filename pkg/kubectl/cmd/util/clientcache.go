@@ -20,6 +20,8 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/registered"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/client/clientcmd"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/kubectl/resource"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 )
 
 func NewClientCache(loader clientcmd.ClientConfig) *clientCache {
@@ -39,10 +41,15 @@ type clientCache struct {
 	defaultConfig *client.Config
 	defaultClient *client.Client
 	matchVersion  bool
+	rateLimiter   util.RateLimiter
+
+	discovery *resource.CachedDiscoveryClient
+	// If true, the cached discovery information is discarded before it is first used.
+	refreshDiscovery bool
 }
 
-// ClientConfigForVersion returns the correct config for a server
-func (c *clientCache) ClientConfigForVersion(version string) (*client.Config, error) {
+// loadDefaultConfig loads the config the other configs are derived from, once.
+func (c *clientCache) loadDefaultConfig() (*client.Config, error) {
 	if c.defaultConfig == nil {
 		config, err := c.loader.ClientConfig()
 		if err != nil {
@@ -55,17 +62,63 @@ func (c *clientCache) ClientConfigForVersion(version string) (*client.Config, er
 			}
 		}
 	}
+	return c.defaultConfig, nil
+}
+
+// Discovery returns a client that caches the discovery information of the server on
+// disk, so that it is not retrieved from the server on every invocation. If a refresh
+// was requested, the cached information is discarded first.
+func (c *clientCache) Discovery() (*resource.CachedDiscoveryClient, error) {
+	if c.discovery != nil {
+		return c.discovery, nil
+	}
+	config, err := c.loadDefaultConfig()
+	if err != nil {
+		return nil, err
+	}
+	defaultClient := c.defaultClient
+	if defaultClient == nil {
+		if defaultClient, err = client.New(config); err != nil {
+			return nil, err
+		}
+	}
+	discovery := resource.NewCachedDiscoveryClient(resource.NewDiscoveryClient(defaultClient), resource.CacheDirForHost(config.Host), resource.DefaultDiscoveryTTL)
+	if c.refreshDiscovery {
+		if err := discovery.Invalidate(); err != nil {
+			return nil, err
+		}
+	}
+	c.discovery = discovery
+	return discovery, nil
+}
+
+// ClientConfigForVersion returns the correct config for a server
+func (c *clientCache) ClientConfigForVersion(version string) (*client.Config, error) {
+	if _, err := c.loadDefaultConfig(); err != nil {
+		return nil, err
+	}
 	if config, ok := c.configs[version]; ok {
 		return config, nil
 	}
+	discovery, err := c.Discovery()
+	if err != nil {
+		return nil, err
+	}
 	// TODO: have a better config copy method
 	config := *c.defaultConfig
-	negotiatedVersion, err := client.NegotiateVersion(c.defaultClient, &config, version, registered.RegisteredVersions)
+	negotiatedVersion, err := client.NegotiateVersionWith(discovery, &config, version, registered.RegisteredVersions)
 	if err != nil {
 		return nil, err
 	}
 	config.Version = negotiatedVersion
 	client.SetKubernetesDefaults(&config)
+	// Every client created by the cache shares a single budget of requests.
+	if config.RateLimiter == nil && config.QPS > 0 {
+		if c.rateLimiter == nil {
+			c.rateLimiter = util.NewTokenBucketRateLimiter(config.QPS, config.Burst)
+		}
+		config.RateLimiter = c.rateLimiter
+	}
 	c.configs[version] = &config
 
 	return &config, nil
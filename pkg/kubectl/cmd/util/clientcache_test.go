@@ -0,0 +1,81 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/testapi"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client/clientcmd"
+	clientcmdapi "github.com/GoogleCloudPlatform/kubernetes/pkg/client/clientcmd/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/kubectl/resource"
+)
+
+func TestClientCacheNegotiatesFromDiscoveryCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clientcache")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(cacheDir string) { resource.DefaultCacheDir = cacheDir }(resource.DefaultCacheDir)
+	resource.DefaultCacheDir = dir
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/api" {
+			t.Errorf("unexpected request: %s", req.URL.Path)
+		}
+		requests++
+		json.NewEncoder(w).Encode(&api.APIVersions{Versions: []string{testapi.Version()}})
+	}))
+	defer server.Close()
+
+	config := clientcmdapi.NewConfig()
+	config.Clusters["local"] = clientcmdapi.Cluster{Server: server.URL}
+	config.Contexts["local"] = clientcmdapi.Context{Cluster: "local"}
+	config.CurrentContext = "local"
+
+	tests := []struct {
+		Refresh  bool
+		Requests int
+	}{
+		{false, 1},
+		// a later invocation uses the cached versions
+		{false, 1},
+		{true, 2},
+	}
+	for i, test := range tests {
+		cache := NewClientCache(clientcmd.NewDefaultClientConfig(*config, &clientcmd.ConfigOverrides{}))
+		cache.refreshDiscovery = test.Refresh
+		cfg, err := cache.ClientConfigForVersion("")
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+		if cfg.Version != testapi.Version() {
+			t.Errorf("%d: unexpected version %q", i, cfg.Version)
+		}
+		if requests != test.Requests {
+			t.Errorf("%d: expected %d requests to the server, got %d", i, test.Requests, requests)
+		}
+	}
+}
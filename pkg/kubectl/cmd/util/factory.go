@@ -23,6 +23,7 @@ import (
 	"os"
 	"strconv"
 
+	"github.com/golang/glog"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
@@ -41,6 +42,7 @@ import (
 
 const (
 	FlagMatchBinaryVersion = "match-server-version"
+	FlagRefreshCache       = "refresh-cache"
 )
 
 // Factory provides abstractions that allow the Kubectl command to be extended across multiple types
@@ -62,6 +64,9 @@ type Factory struct {
 	// Returns a RESTClient for working with the specified RESTMapping or an error. This is intended
 	// for working with arbitrary resources and is not guaranteed to point to a Kubernetes APIServer.
 	RESTClient func(mapping *meta.RESTMapping) (resource.RESTClient, error)
+	// Returns a ResourceHelper for working with the objects of the specified RESTMapping through
+	// a RESTClient returned by RESTClient.
+	ResourceHelper func(client resource.RESTClient, mapping *meta.RESTMapping) resource.ResourceHelper
 	// Returns a Describer for displaying the specified RESTMapping type or an error.
 	Describer func(mapping *meta.RESTMapping) (kubectl.Describer, error)
 	// Returns a Printer for formatting objects of the given type or an error.
@@ -84,6 +89,9 @@ type Factory struct {
 	DefaultNamespace func() (string, bool, error)
 	// Returns the generator for the provided generator name
 	Generator func(name string) (kubectl.Generator, bool)
+	// Returns a target cluster for each of the named kubeconfig contexts, for sending
+	// objects to several clusters at once.
+	ClusterTargets func(contexts ...string) ([]resource.ClusterTarget, error)
 }
 
 // NewFactory creates a factory with the default Kubernetes resources defined
@@ -132,6 +140,9 @@ func NewFactory(optionalClientConfig clientcmd.ClientConfig) *Factory {
 			}
 			return client.RESTClient, nil
 		},
+		ResourceHelper: func(client resource.RESTClient, mapping *meta.RESTMapping) resource.ResourceHelper {
+			return resource.NewHelper(client, mapping)
+		},
 		Describer: func(mapping *meta.RESTMapping) (kubectl.Describer, error) {
 			client, err := clients.ClientForVersion(mapping.APIVersion)
 			if err != nil {
@@ -203,11 +214,11 @@ func NewFactory(optionalClientConfig clientcmd.ClientConfig) *Factory {
 		},
 		Validator: func() (validation.Schema, error) {
 			if flags.Lookup("validate").Value.String() == "true" {
-				client, err := clients.ClientForVersion("")
+				discovery, err := clients.Discovery()
 				if err != nil {
 					return nil, err
 				}
-				return &clientSwaggerSchema{client, api.Scheme}, nil
+				return &clientSwaggerSchema{discovery, api.Scheme}, nil
 			}
 			return validation.NullSchema{}, nil
 		},
@@ -218,6 +229,30 @@ func NewFactory(optionalClientConfig clientcmd.ClientConfig) *Factory {
 			generator, ok := generators[name]
 			return generator, ok
 		},
+		ClusterTargets: func(contexts ...string) ([]resource.ClusterTarget, error) {
+			config, err := clientConfig.RawConfig()
+			if err != nil {
+				return nil, err
+			}
+			targets := []resource.ClusterTarget{}
+			for _, name := range contexts {
+				if _, found := config.Contexts[name]; !found {
+					return nil, fmt.Errorf("context %q does not exist", name)
+				}
+				contextClients := NewClientCache(clientcmd.NewNonInteractiveClientConfig(config, name, &clientcmd.ConfigOverrides{}))
+				targets = append(targets, resource.ClusterTarget{
+					Name: name,
+					Clients: resource.ClientMapperFunc(func(mapping *meta.RESTMapping) (resource.RESTClient, error) {
+						client, err := contextClients.ClientForVersion(mapping.APIVersion)
+						if err != nil {
+							return nil, err
+						}
+						return client.RESTClient, nil
+					}),
+				})
+			}
+			return targets, nil
+		},
 	}
 }
 
@@ -244,6 +279,7 @@ func (f *Factory) BindFlags(flags *pflag.FlagSet) {
 	// TODO Add a verbose flag that turns on glog logging. Probably need a way
 	// to do that automatically for every subcommand.
 	flags.BoolVar(&f.clients.matchVersion, FlagMatchBinaryVersion, false, "Require server version to match client version")
+	flags.BoolVar(&f.clients.refreshDiscovery, FlagRefreshCache, false, "If true, discard the API versions and schemas cached for the server and retrieve them again")
 
 	// Normalize all flags that are comming from other packages or pre-configurations
 	// a.k.a. change all "_" to "-". e.g. glog package
@@ -261,7 +297,7 @@ func getPorts(spec api.PodSpec) []string {
 }
 
 type clientSwaggerSchema struct {
-	c *client.Client
+	c resource.DiscoveryClient
 	t runtime.ObjectTyper
 }
 
@@ -273,10 +309,7 @@ func (c *clientSwaggerSchema) ValidateBytes(data []byte) error {
 	if ok := registered.IsRegisteredAPIVersion(version); !ok {
 		return fmt.Errorf("API version %q isn't supported, only supports API versions %q", version, registered.RegisteredVersions)
 	}
-	schemaData, err := c.c.RESTClient.Get().
-		AbsPath("/swaggerapi/api", version).
-		Do().
-		Raw()
+	schemaData, err := c.c.SwaggerSchema(version)
 	if err != nil {
 		return err
 	}
@@ -392,9 +425,17 @@ func (f *Factory) PrinterForMapping(cmd *cobra.Command, mapping *meta.RESTMappin
 	return printer, nil
 }
 
-// ClientMapperForCommand returns a ClientMapper for the factory.
+// ClientMapperForCommand returns a ClientMapper for the factory. At --v=6 or higher
+// every request made by the returned clients is logged with its status and latency.
 func (f *Factory) ClientMapperForCommand() resource.ClientMapper {
 	return resource.ClientMapperFunc(func(mapping *meta.RESTMapping) (resource.RESTClient, error) {
-		return f.RESTClient(mapping)
+		c, err := f.RESTClient(mapping)
+		if err != nil {
+			return nil, err
+		}
+		if glog.V(6) {
+			return resource.NewMiddlewareClient(c, resource.LogRequests(glog.Infof)), nil
+		}
+		return c, nil
 	})
 }
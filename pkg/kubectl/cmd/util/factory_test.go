@@ -45,6 +45,26 @@ func TestNewFactoryNoFlagBindings(t *testing.T) {
 	}
 }
 
+func TestClusterTargets(t *testing.T) {
+	config := clientcmdapi.NewConfig()
+	config.Clusters["east"] = clientcmdapi.Cluster{Server: "http://east:8080"}
+	config.Clusters["west"] = clientcmdapi.Cluster{Server: "http://west:8080"}
+	config.Contexts["east"] = clientcmdapi.Context{Cluster: "east"}
+	config.Contexts["west"] = clientcmdapi.Context{Cluster: "west"}
+	factory := NewFactory(clientcmd.NewDefaultClientConfig(*config, &clientcmd.ConfigOverrides{}))
+
+	targets, err := factory.ClusterTargets("west", "east")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(targets) != 2 || targets[0].Name != "west" || targets[1].Name != "east" {
+		t.Errorf("unexpected targets: %#v", targets)
+	}
+	if _, err := factory.ClusterTargets("north"); err == nil {
+		t.Errorf("expected an error for a missing context")
+	}
+}
+
 func TestPodSelectorForObject(t *testing.T) {
 	f := NewFactory(nil)
 
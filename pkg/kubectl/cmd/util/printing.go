@@ -24,10 +24,10 @@ import (
 
 // AddPrinterFlags adds printing related flags to a command (e.g. output format, no headers, template path)
 func AddPrinterFlags(cmd *cobra.Command) {
-	cmd.Flags().StringP("output", "o", "", "Output format. One of: json|yaml|template|templatefile|wide.")
+	cmd.Flags().StringP("output", "o", "", "Output format. One of: json|yaml|template|templatefile|jsonpath|wide.")
 	cmd.Flags().String("output-version", "", "Output the formatted object with the given version (default api-version).")
 	cmd.Flags().Bool("no-headers", false, "When using the default output, don't print headers.")
-	cmd.Flags().StringP("template", "t", "", "Template string or path to template file to use when -o=template or -o=templatefile.  The template format is golang templates [http://golang.org/pkg/text/template/#pkg-overview]")
+	cmd.Flags().StringP("template", "t", "", "Template string or path to template file to use when -o=template or -o=templatefile, or JSONPath expression to use when -o=jsonpath.  The template format is golang templates [http://golang.org/pkg/text/template/#pkg-overview]")
 }
 
 // OutputVersion returns the preferred output version for generic content (JSON, YAML, or templates)
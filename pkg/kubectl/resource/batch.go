@@ -0,0 +1,141 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/meta"
+	resourceerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/kubectl/resource/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+// BatchCreator creates a set of objects as a unit: if one of them cannot be created,
+// the objects that were already created are deleted again, so that a bundle of
+// objects is either created completely or, as far as possible, not at all. Rolling
+// back is best-effort; objects that cannot be deleted are reported in the BatchError.
+type BatchCreator struct {
+	// If set, the options used to delete the objects that are rolled back.
+	DeleteOptions *api.DeleteOptions
+	// If set, returns the helper used to create and delete each object; otherwise a
+	// Helper is used.
+	HelperFunc HelperFunc
+}
+
+// BatchError is returned by BatchCreator.Create when an object could not be created.
+type BatchError struct {
+	// The object that could not be created and why.
+	Failed *Info
+	Err    error
+	// The objects that were created and then deleted again.
+	RolledBack []*Info
+	// The objects that were created but could not be deleted, and the errors that
+	// prevented their deletion, in the same order.
+	LeftBehind     []*Info
+	RollbackErrors []error
+}
+
+// Error implements error.
+func (e *BatchError) Error() string {
+	msg := fmt.Sprintf("unable to create %s: %v", describeInfo(e.Failed), e.Err)
+	if len(e.RolledBack) > 0 {
+		names := []string{}
+		for _, info := range e.RolledBack {
+			names = append(names, describeInfo(info))
+		}
+		msg += fmt.Sprintf("; rolled back %s", strings.Join(names, ", "))
+	}
+	if len(e.LeftBehind) > 0 {
+		names := []string{}
+		for i, info := range e.LeftBehind {
+			names = append(names, fmt.Sprintf("%s (%v)", describeInfo(info), e.RollbackErrors[i]))
+		}
+		msg += fmt.Sprintf("; unable to roll back %s", strings.Join(names, ", "))
+	}
+	return msg
+}
+
+// Cause returns the error that prevented the failed object from being created.
+func (e *BatchError) Cause() error {
+	return e.Err
+}
+
+// describeInfo returns the resource and name of the object of info.
+func describeInfo(info *Info) string {
+	if info.Mapping == nil {
+		return fmt.Sprintf("%q", info.Name)
+	}
+	return fmt.Sprintf("%s %q", info.Mapping.Resource, info.Name)
+}
+
+// Create creates the objects visited by v in the order they are visited, and returns
+// them as stored by the server. All the objects are read before the first one is
+// created, so an object that cannot be read prevents the whole batch from being
+// created. If an object cannot be created, the objects created before it are deleted
+// in reverse order and a *BatchError is returned. Only an object with the UID it was
+// created with is deleted, so an object that was replaced by someone else in the
+// meantime is left alone and reported.
+func (c *BatchCreator) Create(v Visitor) ([]*Info, error) {
+	infos := []*Info{}
+	err := v.Visit(func(info *Info) error {
+		infos = append(infos, info)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created := []*Info{}
+	for _, info := range infos {
+		data, err := info.Mapping.Codec.Encode(info.Object)
+		if err == nil {
+			var obj runtime.Object
+			obj, err = c.HelperFunc.helperFor(info.Client, info.Mapping).Create(info.Namespace, true, data)
+			if err == nil {
+				err = info.Refresh(obj, true)
+			}
+		}
+		if err != nil {
+			return nil, c.rollback(info, err, created)
+		}
+		created = append(created, info)
+	}
+	return created, nil
+}
+
+// rollback deletes the created objects in reverse order after failed could not be
+// created because of err.
+func (c *BatchCreator) rollback(failed *Info, err error, created []*Info) error {
+	batchErr := &BatchError{Failed: failed, Err: err}
+	for i := len(created) - 1; i >= 0; i-- {
+		info := created[i]
+		preconditions := Preconditions{}
+		if accessor, err := meta.Accessor(info.Object); err == nil {
+			preconditions.UID = accessor.UID()
+		}
+		err := c.HelperFunc.helperFor(info.Client, info.Mapping).DeleteWithPreconditions(info.Namespace, info.Name, preconditions, c.DeleteOptions)
+		if err != nil && !resourceerrors.IsNotFound(err) {
+			batchErr.LeftBehind = append(batchErr.LeftBehind, info)
+			batchErr.RollbackErrors = append(batchErr.RollbackErrors, err)
+			continue
+		}
+		batchErr.RolledBack = append(batchErr.RolledBack, info)
+	}
+	return batchErr
+}
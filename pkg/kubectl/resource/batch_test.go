@@ -0,0 +1,204 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/meta"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/testapi"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/types"
+)
+
+func TestBatchCreator(t *testing.T) {
+	tests := map[string]struct {
+		FailCreate string
+		FailDelete string
+		Replaced   string
+
+		Deletes    []string
+		RolledBack []string
+		LeftBehind []string
+		Err        string
+	}{
+		"all created": {},
+		"rolled back in reverse order": {
+			FailCreate: "c",
+			Deletes:    []string{"b", "a"},
+			RolledBack: []string{"b", "a"},
+			Err:        `unable to create pods "c": internal error; rolled back pods "b", pods "a"`,
+		},
+		"first object fails": {
+			FailCreate: "a",
+			Err:        `unable to create pods "a": internal error`,
+		},
+		"delete fails": {
+			FailCreate: "c",
+			FailDelete: "a",
+			Deletes:    []string{"b", "a"},
+			RolledBack: []string{"b"},
+			LeftBehind: []string{"a"},
+			Err:        `unable to create pods "c": internal error; rolled back pods "b"; unable to roll back pods "a" (internal error)`,
+		},
+		"replaced objects are left alone": {
+			FailCreate: "c",
+			Replaced:   "b",
+			Deletes:    []string{"a"},
+			RolledBack: []string{"a"},
+			LeftBehind: []string{"b"},
+		},
+	}
+	for k, test := range tests {
+		mapping, err := latest.RESTMapper.RESTMapping("Pod")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		failure := &api.Status{Status: api.StatusFailure, Code: http.StatusInternalServerError, Reason: api.StatusReasonUnknown, Message: "internal error"}
+		var deletes []string
+		c := &client.FakeRESTClient{
+			Codec: latest.Codec,
+			Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+				name := path.Base(req.URL.Path)
+				switch req.Method {
+				case "POST":
+					data, _ := ioutil.ReadAll(req.Body)
+					obj, err := latest.Codec.Decode(data)
+					if err != nil {
+						t.Fatalf("%s: unexpected error: %v", k, err)
+					}
+					pod := obj.(*api.Pod)
+					if pod.Name == test.FailCreate {
+						return &http.Response{StatusCode: http.StatusInternalServerError, Body: objBody(failure)}, nil
+					}
+					pod.UID = types.UID("uid-" + pod.Name)
+					return &http.Response{StatusCode: http.StatusCreated, Body: objBody(pod)}, nil
+				case "GET":
+					uid := types.UID("uid-" + name)
+					if name == test.Replaced {
+						uid = "other"
+					}
+					return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Pod{ObjectMeta: api.ObjectMeta{Name: name, Namespace: "test", UID: uid}})}, nil
+				case "DELETE":
+					deletes = append(deletes, name)
+					if name == test.FailDelete {
+						return &http.Response{StatusCode: http.StatusInternalServerError, Body: objBody(failure)}, nil
+					}
+					return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Status{Status: api.StatusSuccess})}, nil
+				}
+				t.Fatalf("%s: unexpected request: %#v", k, req)
+				return nil, nil
+			}),
+		}
+		visitors := VisitorList{}
+		for _, name := range []string{"a", "b", "c"} {
+			visitors = append(visitors, &Info{
+				Client:    c,
+				Mapping:   mapping,
+				Namespace: "test",
+				Name:      name,
+				Object:    &api.Pod{ObjectMeta: api.ObjectMeta{Name: name, Namespace: "test"}},
+			})
+		}
+
+		created, err := (&BatchCreator{}).Create(visitors)
+		if !reflect.DeepEqual(test.Deletes, deletes) {
+			t.Errorf("%s: unexpected deletes: %v", k, deletes)
+		}
+		if len(test.FailCreate) == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", k, err)
+				continue
+			}
+			for _, info := range created {
+				if info.Object.(*api.Pod).UID != types.UID("uid-"+info.Name) {
+					t.Errorf("%s: expected the created object to be returned: %#v", k, info.Object)
+				}
+			}
+			continue
+		}
+
+		batchErr, ok := err.(*BatchError)
+		if !ok {
+			t.Errorf("%s: unexpected error: %v", k, err)
+			continue
+		}
+		if created != nil || batchErr.Failed.Name != test.FailCreate {
+			t.Errorf("%s: unexpected result: %v %#v", k, created, batchErr)
+		}
+		if names := infoNames(batchErr.RolledBack); !reflect.DeepEqual(test.RolledBack, names) {
+			t.Errorf("%s: unexpected rolled back objects: %v", k, names)
+		}
+		if names := infoNames(batchErr.LeftBehind); !reflect.DeepEqual(test.LeftBehind, names) {
+			t.Errorf("%s: unexpected objects left behind: %v", k, names)
+		}
+		if len(batchErr.RollbackErrors) != len(batchErr.LeftBehind) {
+			t.Errorf("%s: expected an error for every object left behind: %v", k, batchErr.RollbackErrors)
+		}
+		if len(test.Err) > 0 && batchErr.Error() != test.Err {
+			t.Errorf("%s: unexpected error message: %s", k, batchErr.Error())
+		}
+		if len(test.Replaced) > 0 && !strings.Contains(batchErr.Error(), "precondition on the UID failed") {
+			t.Errorf("%s: expected a precondition failure: %s", k, batchErr.Error())
+		}
+	}
+}
+
+func infoNames(infos []*Info) []string {
+	var names []string
+	for _, info := range infos {
+		names = append(names, info.Name)
+	}
+	return names
+}
+
+func TestBatchCreatorWithHelperFunc(t *testing.T) {
+	f, mapping := newFakePodHelper(t, fakePod("test", "c", nil))
+	infos := VisitorList{}
+	for _, name := range []string{"a", "b", "c"} {
+		info := NewInfo(nil, mapping, "test", name)
+		info.Object = fakePod("test", name, nil)
+		infos = append(infos, info)
+	}
+	creator := &BatchCreator{
+		HelperFunc: func(RESTClient, *meta.RESTMapping) ResourceHelper { return f },
+	}
+	_, err := creator.Create(infos)
+	batchErr, ok := err.(*BatchError)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := infoNames(batchErr.RolledBack); !reflect.DeepEqual(names, []string{"b", "a"}) {
+		t.Errorf("unexpected rolled back objects: %v", names)
+	}
+	list, err := f.List("test", testapi.Version(), labels.Everything(), fields.Everything())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := fakePodNames(t, list); !reflect.DeepEqual(names, []string{"test/c"}) {
+		t.Errorf("unexpected objects left: %v", names)
+	}
+}
@@ -23,6 +23,8 @@ import (
 	"os"
 	"strings"
 
+	"golang.org/x/net/context"
+
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/meta"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/validation"
@@ -40,9 +42,12 @@ type Builder struct {
 
 	errs []error
 
-	paths  []Visitor
-	stream bool
-	dir    bool
+	paths      []Visitor
+	filePaths  []string
+	stream     bool
+	dir        bool
+	recursive  bool
+	urlOptions URLOptions
 
 	selector  labels.Selector
 	selectAll bool
@@ -54,8 +59,9 @@ type Builder struct {
 
 	resourceTuples []resourceTuple
 
-	defaultNamespace bool
-	requireNamespace bool
+	defaultNamespace  bool
+	requireNamespace  bool
+	overrideNamespace bool
 
 	flatten bool
 	latest  bool
@@ -64,6 +70,15 @@ type Builder struct {
 
 	singleResourceType bool
 	continueOnError    bool
+	parallelism        int
+	pruneSet           string
+	filters            []FilterFunc
+	decorators         []VisitorFunc
+	kindOrder          []string
+	reverseKindOrder   bool
+	checkQuota         bool
+	clusters           []ClusterTarget
+	clientWrappers     []func(RESTClient) RESTClient
 
 	schema validation.Schema
 }
@@ -81,6 +96,56 @@ func NewBuilder(mapper meta.RESTMapper, typer runtime.ObjectTyper, clientMapper
 	}
 }
 
+// Context binds every request made on behalf of the builder, including those made
+// while visiting the result, to ctx so that they are aborted when ctx is cancelled
+// or its deadline passes.
+func (b *Builder) Context(ctx context.Context) *Builder {
+	return b.wrapClients(func(client RESTClient) RESTClient {
+		return NewContextClient(client, ctx)
+	})
+}
+
+// Middleware sends every request made on behalf of the builder, including those
+// made while visiting the result, through middleware. See Middleware.
+func (b *Builder) Middleware(middleware ...Middleware) *Builder {
+	return b.wrapClients(func(client RESTClient) RESTClient {
+		return NewMiddlewareClient(client, middleware...)
+	})
+}
+
+// Clusters sends every object of the result to each of targets, such as the clusters
+// of several kubeconfig contexts, instead of to the cluster of the builder's clients:
+// each object is visited once per target, with a client for that target and
+// Info.Cluster set to its name, and the failures are reported per cluster. See
+// ClusterVisitor. The objects are still read, and the objects named by resource
+// arguments retrieved, through the builder's own clients.
+func (b *Builder) Clusters(targets ...ClusterTarget) *Builder {
+	b.clusters = append(b.clusters, targets...)
+	return b
+}
+
+// wrapClients wraps every client of the builder and of its target clusters with wrap.
+func (b *Builder) wrapClients(wrap func(RESTClient) RESTClient) *Builder {
+	b.clientWrappers = append(b.clientWrappers, wrap)
+	b.mapper.ClientMapper = wrapClientMapper(b.mapper.ClientMapper, wrap)
+	return b
+}
+
+// wrapClientMapper returns a ClientMapper whose clients are those of clientMapper
+// wrapped with each of wrappers in turn.
+func wrapClientMapper(clientMapper ClientMapper, wrappers ...func(RESTClient) RESTClient) ClientMapper {
+	return ClientMapperFunc(func(mapping *meta.RESTMapping) (RESTClient, error) {
+		client, err := clientMapper.ClientForMapping(mapping)
+		if err != nil {
+			return nil, err
+		}
+		for _, wrap := range wrappers {
+			client = wrap(client)
+		}
+		return client, nil
+	})
+}
+
 func (b *Builder) Schema(schema validation.Schema) *Builder {
 	b.schema = schema
 	return b
@@ -120,14 +185,23 @@ func (b *Builder) FilenameParam(enforceNamespace bool, paths ...string) *Builder
 func (b *Builder) URL(urls ...*url.URL) *Builder {
 	for _, u := range urls {
 		b.paths = append(b.paths, &URLVisitor{
-			Mapper: b.mapper,
-			URL:    u,
-			Schema: b.schema,
+			Mapper:  b.mapper,
+			URL:     u,
+			Schema:  b.schema,
+			Options: b.urlOptions,
 		})
 	}
 	return b
 }
 
+// URLOptions sets the request headers, limits and expected checksum used to download
+// the manifests passed to FilenameParam() or URL(). It must be called prior to those
+// methods.
+func (b *Builder) URLOptions(options URLOptions) *Builder {
+	b.urlOptions = options
+	return b
+}
+
 // Stdin will read objects from the standard input. If ContinueOnError() is set
 // prior to this method being called, objects in the stream that are unrecognized
 // will be ignored (but logged at V(2)).
@@ -147,36 +221,106 @@ func (b *Builder) Stream(r io.Reader, name string) *Builder {
 	return b
 }
 
+// Recursive causes directories passed to FilenameParam() or Path() to be walked
+// recursively instead of only reading the files directly inside them. It must be
+// called prior to those methods.
+func (b *Builder) Recursive(recursive bool) *Builder {
+	b.recursive = recursive
+	return b
+}
+
 // Path accepts a set of paths that may be files, directories (all can containing
-// one or more resources). Creates a FileVisitor for each file and then each
-// FileVisitor is streaming the content to a StreamVisitor. If ContinueOnError() is set
-// prior to this method being called, objects on the path that are unrecognized will be
-// ignored (but logged at V(2)).
+// one or more resources), or glob patterns such as "manifests/**/*.yaml" that match
+// them. Creates a FileVisitor for each file and then each FileVisitor is streaming
+// the content to a StreamVisitor. Files are visited in lexical order, and files in
+// directories without a .json, .yaml or .yml extension are skipped. If
+// ContinueOnError() is set prior to this method being called, objects on the path
+// that are unrecognized will be ignored (but logged at V(2)).
 func (b *Builder) Path(paths ...string) *Builder {
+	b.filePaths = append(b.filePaths, paths...)
 	for _, p := range paths {
-		_, err := os.Stat(p)
-		if os.IsNotExist(err) {
-			b.errs = append(b.errs, fmt.Errorf("the path %q does not exist", p))
+		if !hasGlobMeta(p) {
+			b.paths = append(b.paths, b.expandPath(p)...)
 			continue
 		}
+
+		matches, err := ExpandGlob(p)
 		if err != nil {
-			b.errs = append(b.errs, fmt.Errorf("the path %q cannot be accessed: %v", p, err))
+			b.errs = append(b.errs, fmt.Errorf("the pattern %q is not valid: %v", p, err))
 			continue
 		}
-
-		visitors, err := ExpandPathsToFileVisitors(b.mapper, p, false, []string{".json", ".yaml", ".yml"}, b.continueOnError, b.schema)
-		if err != nil {
-			b.errs = append(b.errs, fmt.Errorf("error reading %q: %v", p, err))
+		if len(matches) == 0 {
+			b.errs = append(b.errs, fmt.Errorf("no files match the pattern %q", p))
+			continue
+		}
+		// A pattern may match both a directory and the files in it.
+		seen := util.StringSet{}
+		var visitors []Visitor
+		for _, match := range matches {
+			for _, visitor := range b.expandPath(match) {
+				path := visitor.(*FileVisitor).Path
+				if seen.Has(path) {
+					continue
+				}
+				seen.Insert(path)
+				visitors = append(visitors, visitor)
+			}
 		}
 		if len(visitors) > 1 {
 			b.dir = true
 		}
-
 		b.paths = append(b.paths, visitors...)
 	}
 	return b
 }
 
+// Overlay accepts the manifests in the base directory, customized by each of the
+// overlay directories in order. See OverlayVisitor for the layout of an overlay.
+// Subdirectories are read if Recursive(true) was called first.
+func (b *Builder) Overlay(base string, overlays ...string) *Builder {
+	for _, dir := range append([]string{base}, overlays...) {
+		if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+			b.errs = append(b.errs, fmt.Errorf("the overlay path %q is not a directory", dir))
+			return b
+		}
+	}
+	b.dir = true
+	b.paths = append(b.paths, &OverlayVisitor{
+		Mapper:    b.mapper,
+		Base:      base,
+		Overlays:  overlays,
+		Recursive: b.recursive,
+		Schema:    b.schema,
+	})
+	return b
+}
+
+// manifestExtensions are the extensions of the files read from directories.
+var manifestExtensions = []string{".json", ".yaml", ".yml"}
+
+// expandPath returns a FileVisitor for the file at p, or for each of the files in
+// the directory at p.
+func (b *Builder) expandPath(p string) []Visitor {
+	_, err := os.Stat(p)
+	if os.IsNotExist(err) {
+		b.errs = append(b.errs, fmt.Errorf("the path %q does not exist", p))
+		return nil
+	}
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("the path %q cannot be accessed: %v", p, err))
+		return nil
+	}
+
+	visitors, err := ExpandPathsToFileVisitors(b.mapper, p, b.recursive, manifestExtensions, b.continueOnError, b.schema)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("error reading %q: %v", p, err))
+	}
+	if len(visitors) > 1 {
+		b.dir = true
+	}
+	return visitors
+}
+
 // ResourceTypes is a list of types of resources to operate on, when listing objects on
 // the server or retrieving objects that match a selector.
 func (b *Builder) ResourceTypes(types ...string) *Builder {
@@ -223,6 +367,15 @@ func (b *Builder) DefaultNamespace() *Builder {
 	return b
 }
 
+// OverrideNamespace instructs the builder to set the namespace value for every
+// namespaced object to NamespaceParam(), replacing the namespace of objects that
+// specify a different one instead of returning an error as RequireNamespace() does.
+// It takes precedence over RequireNamespace().
+func (b *Builder) OverrideNamespace() *Builder {
+	b.overrideNamespace = true
+	return b
+}
+
 // AllNamespaces instructs the builder to use NamespaceAll as a namespace to request resources
 // acroll all namespace. This overrides the namespace set by NamespaceParam().
 func (b *Builder) AllNamespaces(allNamespace bool) *Builder {
@@ -361,12 +514,72 @@ func (b *Builder) RequireObject(require bool) *Builder {
 
 // ContinueOnError will attempt to load and visit as many objects as possible, even if some visits
 // return errors or some objects cannot be loaded. The default behavior is to terminate after
-// the first error is returned from a VisitorFunc.
+// the first error is returned from a VisitorFunc. The failures are returned together once every
+// object has been visited; objects from files or streams that fail validation are reported as
+// DocumentErrors naming their source and position. Must be called before FilenameParam or
+// Stream for it to apply to the objects they read.
 func (b *Builder) ContinueOnError() *Builder {
 	b.continueOnError = true
 	return b
 }
 
+// Parallel allows up to limit items of the result to be visited concurrently,
+// including the requests made to retrieve them from the server. Visitor functions
+// passed to the result must then be safe to call from multiple goroutines and must
+// not depend on the order in which items are visited; Infos returns items in the
+// order their visits completed. A limit of one or less, the default, visits items
+// sequentially in order. With OrderByKind, only objects of the same kind are visited
+// concurrently.
+func (b *Builder) Parallel(limit int) *Builder {
+	b.parallelism = limit
+	return b
+}
+
+// PruneSet labels every object in the result as a member of the named set before it
+// is visited, so that once the objects are created, those later removed from the
+// set's manifests can be deleted with a Pruner.
+func (b *Builder) PruneSet(set string) *Builder {
+	b.pruneSet = set
+	return b
+}
+
+// Filter only visits the objects of the result that every filter accepts, such as
+// those returned by JSONPathFilter and TemplateFilter. Filters are invoked once the
+// object has been retrieved and may be called multiple times.
+func (b *Builder) Filter(filters ...FilterFunc) *Builder {
+	b.filters = append(b.filters, filters...)
+	return b
+}
+
+// Decorate invokes each of fns on the objects of the result before they are visited,
+// for instance SetLabels or SetAnnotations to change the objects before they are
+// created or replaced. The functions run once the object has been retrieved and
+// after the namespace and prune set have been applied.
+func (b *Builder) Decorate(fns ...VisitorFunc) *Builder {
+	b.decorators = append(b.decorators, fns...)
+	return b
+}
+
+// CheckQuota refuses to visit any object if one of them would exceed a ResourceQuota
+// or violate a LimitRange of its namespace when it is created, reporting every
+// violation instead. The quotas and limit ranges are retrieved from the server. See
+// QuotaVisitor.
+func (b *Builder) CheckQuota() *Builder {
+	b.checkQuota = true
+	return b
+}
+
+// OrderByKind sorts the objects of the result by the position of their kind in order,
+// such as KindOrder, or in the reverse of that order if reverse is true, so that the
+// objects a resource depends on are created before it and deleted after it. Every
+// object is read before the first one is visited. With Parallel, the objects of one
+// kind are all visited before those of the next.
+func (b *Builder) OrderByKind(order []string, reverse bool) *Builder {
+	b.kindOrder = order
+	b.reverseKindOrder = reverse
+	return b
+}
+
 // SingleResourceType will cause the builder to error if the user specifies more than a single type
 // of resource.
 func (b *Builder) SingleResourceType() *Builder {
@@ -614,21 +827,45 @@ func (b *Builder) Do() *Result {
 	if b.flatten {
 		r.visitor = NewFlattenListVisitor(r.visitor, b.mapper)
 	}
+	if b.kindOrder != nil {
+		// kinds are still visited one after the other
+		r.visitor = NewOrderedVisitor(r.visitor, b.kindOrder, b.reverseKindOrder, b.parallelism)
+	} else {
+		r.visitor = NewParallelVisitor(r.visitor, b.parallelism)
+	}
 	helpers := []VisitorFunc{}
 	if b.defaultNamespace {
 		helpers = append(helpers, SetNamespace(b.namespace))
 	}
-	if b.requireNamespace {
+	switch {
+	case b.overrideNamespace:
+		helpers = append(helpers, OverrideNamespace(b.namespace))
+	case b.requireNamespace:
 		helpers = append(helpers, RequireNamespace(b.namespace))
 	}
 	helpers = append(helpers, FilterNamespace)
 	if b.requireObject {
 		helpers = append(helpers, RetrieveLazy)
 	}
+	if len(b.pruneSet) > 0 {
+		helpers = append(helpers, TagPruneSet(b.pruneSet))
+	}
+	helpers = append(helpers, b.decorators...)
 	r.visitor = NewDecoratedVisitor(r.visitor, helpers...)
+	r.visitor = NewFilteredVisitor(r.visitor, b.filters...)
+	if b.checkQuota {
+		r.visitor = NewQuotaVisitor(r.visitor, b.mapper)
+	}
 	if b.continueOnError {
 		r.visitor = ContinueOnErrorVisitor{r.visitor}
 	}
+	if len(b.clusters) > 0 {
+		targets := []ClusterTarget{}
+		for _, target := range b.clusters {
+			targets = append(targets, ClusterTarget{target.Name, wrapClientMapper(target.Clients, b.clientWrappers...)})
+		}
+		r.visitor = NewClusterVisitor(r.visitor, b.continueOnError, targets...)
+	}
 	return r
 }
 
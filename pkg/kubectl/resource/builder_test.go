@@ -18,13 +18,21 @@ package resource
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/ghodss/yaml"
 
@@ -35,6 +43,7 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/testapi"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/errors"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 	watchjson "github.com/GoogleCloudPlatform/kubernetes/pkg/watch/json"
@@ -269,6 +278,76 @@ func TestDirectoryBuilder(t *testing.T) {
 	}
 }
 
+// writeManifests creates a directory holding a pod manifest for each of the given
+// relative paths, and returns the directory.
+func writeManifests(t *testing.T, paths ...string) string {
+	dir, err := ioutil.TempDir("", "manifests")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, p := range paths {
+		path := filepath.Join(dir, p)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		name := strings.TrimSuffix(filepath.Base(p), filepath.Ext(p))
+		data := runtime.EncodeOrDie(latest.Codec, &api.Pod{ObjectMeta: api.ObjectMeta{Name: name}})
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestRecursiveAndGlobPathBuilder(t *testing.T) {
+	dir := writeManifests(t, "b.yaml", "a.json", "notes.txt", "sub/d.json", "sub/c.yml", "sub/deeper/e.yaml")
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		Path      string
+		Recursive bool
+		Names     []string
+		Err       bool
+	}{
+		{Path: dir, Names: []string{"a", "b"}},
+		{Path: dir, Recursive: true, Names: []string{"a", "b", "c", "d", "e"}},
+		{Path: filepath.Join(dir, "*.yaml"), Names: []string{"b"}},
+		{Path: filepath.Join(dir, "sub", "*"), Names: []string{"c", "d", "e"}},
+		{Path: filepath.Join(dir, "**", "*.y*ml"), Names: []string{"b", "c", "e"}},
+		{Path: filepath.Join(dir, "**"), Names: []string{"a", "b", "c", "d", "e"}},
+		{Path: filepath.Join(dir, "**", "deeper", "*"), Names: []string{"e"}},
+		{Path: filepath.Join(dir, "*.xml"), Err: true},
+		{Path: filepath.Join(dir, "[", "*"), Err: true},
+	}
+	for i, test := range tests {
+		b := NewBuilder(latest.RESTMapper, api.Scheme, fakeClient()).
+			ContinueOnError().
+			Recursive(test.Recursive).
+			FilenameParam(false, test.Path).
+			NamespaceParam("test").DefaultNamespace()
+
+		visitor := &testVisitor{}
+		err := b.Do().Visit(visitor.Handle)
+		if test.Err {
+			if err == nil {
+				t.Errorf("%d: expected an error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		names := []string{}
+		for _, info := range visitor.Infos {
+			names = append(names, info.Name)
+		}
+		if !reflect.DeepEqual(names, test.Names) {
+			t.Errorf("%d: unexpected objects: %v", i, names)
+		}
+	}
+}
+
 func TestNamespaceOverride(t *testing.T) {
 	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -343,6 +422,116 @@ func TestURLBuilderRequireNamespace(t *testing.T) {
 	}
 }
 
+func TestBuilderOverrideNamespace(t *testing.T) {
+	pods, svc := testData()
+	pods.Items[1].Namespace = ""
+	newBuilder := func() *Builder {
+		return NewBuilder(latest.RESTMapper, api.Scheme, fakeClient()).
+			Stream(strings.NewReader(runtime.EncodeOrDie(latest.Codec, pods)), "STDIN").
+			Stream(strings.NewReader(runtime.EncodeOrDie(latest.Codec, svc)), "STDIN").
+			Flatten().
+			NamespaceParam("other").DefaultNamespace().RequireNamespace()
+	}
+
+	if err := newBuilder().Do().Visit(func(*Info) error { return nil }); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	infos, err := newBuilder().OverrideNamespace().Do().Infos()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(infos) != 3 {
+		t.Fatalf("unexpected infos: %#v", infos)
+	}
+	for _, info := range infos {
+		namespace, _ := info.Mapping.MetadataAccessor.Namespace(info.Object)
+		if info.Namespace != "other" || namespace != "other" {
+			t.Errorf("%s: unexpected namespace %q on info and %q on object", info.Name, info.Namespace, namespace)
+		}
+	}
+}
+
+func TestURLBuilderOptions(t *testing.T) {
+	pods, _ := testData()
+	manifest := runtime.EncodeOrDie(latest.Codec, &pods.Items[0]) + runtime.EncodeOrDie(latest.Codec, &pods.Items[1])
+	sum := sha256.Sum256([]byte(manifest))
+	checksum := hex.EncodeToString(sum[:])
+
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/redirect":
+			http.Redirect(w, req, "/manifest", http.StatusFound)
+		case "/manifest":
+			if req.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(manifest))
+		}
+	}))
+	defer s.Close()
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(manifest))
+	}))
+	defer plain.Close()
+
+	header := http.Header{"Authorization": []string{"Bearer token"}}
+	tests := map[string]struct {
+		URL     string
+		Options URLOptions
+		Infos   int
+		Err     string
+	}{
+		"authenticated": {
+			URL:     s.URL + "/redirect",
+			Options: URLOptions{Header: header, SHA256: checksum},
+			Infos:   2,
+		},
+		"unauthenticated": {
+			URL: s.URL + "/manifest",
+			Err: "401",
+		},
+		"too many redirects": {
+			URL:     s.URL + "/redirect",
+			Options: URLOptions{Header: header, MaxRedirects: -1},
+			Err:     "redirects",
+		},
+		"too large": {
+			URL:     s.URL + "/manifest",
+			Options: URLOptions{Header: header, MaxBytes: 10},
+			Err:     "larger than 10 bytes",
+		},
+		"checksum mismatch": {
+			URL:     s.URL + "/manifest",
+			Options: URLOptions{Header: header, SHA256: strings.Repeat("0", 64)},
+			Err:     "checksum",
+		},
+		"credentials over http": {
+			URL:     plain.URL,
+			Options: URLOptions{Header: header},
+			Err:     "https",
+		},
+	}
+	for k, test := range tests {
+		test.Options.Transport = s.Client().Transport
+		b := NewBuilder(latest.RESTMapper, api.Scheme, fakeClient()).
+			URLOptions(test.Options).
+			FilenameParam(false, test.URL)
+
+		infos, err := b.Do().Infos()
+		if len(test.Err) > 0 {
+			if err == nil || !strings.Contains(err.Error(), test.Err) {
+				t.Errorf("%s: unexpected error: %v", k, err)
+			}
+			continue
+		}
+		if err != nil || len(infos) != test.Infos {
+			t.Errorf("%s: unexpected response: %v %#v", k, err, infos)
+		}
+	}
+}
+
 func TestResourceByName(t *testing.T) {
 	pods, _ := testData()
 	b := NewBuilder(latest.RESTMapper, api.Scheme, fakeClientWith("", t, map[string]string{
@@ -598,6 +787,35 @@ func TestStream(t *testing.T) {
 	}
 }
 
+func TestStreamNestedList(t *testing.T) {
+	object := func(kind, name string) string {
+		return fmt.Sprintf(`{"kind":%q,"apiVersion":%q,"metadata":{"name":%q}}`, kind, testapi.Version(), name)
+	}
+	list := func(items ...string) string {
+		return fmt.Sprintf(`{"kind":"List","apiVersion":%q,"items":[%s]}`, testapi.Version(), strings.Join(items, ","))
+	}
+	manifest := object("Service", "baz") + list(object("Pod", "foo"), list(object("Pod", "bar"), list()))
+	b := NewBuilder(latest.RESTMapper, api.Scheme, fakeClient()).
+		NamespaceParam("test").DefaultNamespace().Stream(strings.NewReader(manifest), "STDIN").Flatten()
+
+	infos, err := b.Do().Infos()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := infoNames(infos); !reflect.DeepEqual(names, []string{"baz", "foo", "bar"}) {
+		t.Fatalf("unexpected infos: %v", names)
+	}
+	for i, info := range infos {
+		namespace, _ := info.Mapping.MetadataAccessor.Namespace(info.Object)
+		if info.Namespace != "test" || namespace != "test" {
+			t.Errorf("%s: unexpected namespace %q on info and %q on object", info.Name, info.Namespace, namespace)
+		}
+		if document := []int{1, 2, 2}[i]; info.Source != "STDIN" || info.Document != document {
+			t.Errorf("%s: expected document %d of STDIN, got %d of %q", info.Name, document, info.Document, info.Source)
+		}
+	}
+}
+
 func TestYAMLStream(t *testing.T) {
 	r, pods, rc := streamYAMLTestData()
 	b := NewBuilder(latest.RESTMapper, api.Scheme, fakeClient()).
@@ -873,9 +1091,12 @@ func TestIgnoreStreamErrors(t *testing.T) {
 	singular := false
 
 	err := b.Do().IntoSingular(&singular).Visit(test.Handle)
-	if err != nil || singular || len(test.Infos) != 2 {
+	if singular || len(test.Infos) != 2 {
 		t.Fatalf("unexpected response: %v %t %#v", err, singular, test.Infos)
 	}
+	if errs, ok := err.(errors.Aggregate); !ok || len(errs.Errors()) != 2 {
+		t.Errorf("expected the unreadable objects to be reported: %v", err)
+	}
 
 	if !api.Semantic.DeepDerivative([]runtime.Object{&pods.Items[0], &svc.Items[0]}, test.Objects()) {
 		t.Errorf("unexpected visited objects: %#v", test.Objects())
@@ -920,6 +1141,96 @@ func TestReceiveMultipleErrors(t *testing.T) {
 	}
 }
 
+// substringSchema rejects any object containing the given text.
+type substringSchema string
+
+func (s substringSchema) ValidateBytes(data []byte) error {
+	if strings.Contains(string(data), string(s)) {
+		return fmt.Errorf("unknown field %q", string(s))
+	}
+	return nil
+}
+
+func TestContinueOnErrorReportsEveryDocument(t *testing.T) {
+	pods, svc := testData()
+	bad := strings.Replace(runtime.EncodeOrDie(latest.Codec, &pods.Items[1]), `"spec":{`, `"spec":{"replica":3,`, 1)
+	stream := strings.Join([]string{
+		runtime.EncodeOrDie(latest.Codec, &pods.Items[0]),
+		bad,
+		runtime.EncodeOrDie(latest.Codec, &svc.Items[0]),
+		bad,
+		`{"kind":"Unknown","apiVersion":"` + testapi.Version() + `","metadata":{"name":"foo"}}`,
+	}, "\n")
+
+	b := NewBuilder(latest.RESTMapper, api.Scheme, fakeClient()).
+		ContinueOnError().Schema(substringSchema("replica")).
+		Stream(strings.NewReader(stream), "manifest.json")
+
+	test := &testVisitor{}
+	err := b.Do().Visit(test.Handle)
+	if len(test.Infos) != 2 {
+		t.Errorf("expected the valid objects to be visited: %#v", test.Infos)
+	}
+	errs, ok := err.(errors.Aggregate)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	documents := []int{}
+	for _, err := range errs.Errors() {
+		docErr, ok := err.(*DocumentError)
+		if !ok {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		if docErr.Source != "manifest.json" {
+			t.Errorf("unexpected source: %s", docErr.Source)
+		}
+		documents = append(documents, docErr.Document)
+	}
+	if !reflect.DeepEqual(documents, []int{2, 4, 5}) {
+		t.Errorf("unexpected documents: %v", documents)
+	}
+}
+
+func TestMultipleDocumentStream(t *testing.T) {
+	pods, svc := testData()
+	podJSON := runtime.EncodeOrDie(latest.Codec, &pods.Items[0])
+	svcJSON := runtime.EncodeOrDie(latest.Codec, &svc.Items[0])
+	tests := map[string]string{
+		"yaml": strings.Join([]string{
+			string(JSONToYAMLOrDie([]byte(podJSON))),
+			"# nothing to see here\n",
+			string(JSONToYAMLOrDie([]byte(svcJSON))),
+		}, "---\n"),
+		"json": podJSON + "\n" + svcJSON,
+	}
+	for k, stream := range tests {
+		test := &testVisitor{}
+		err := NewBuilder(latest.RESTMapper, api.Scheme, fakeClient()).
+			Stream(strings.NewReader(stream), "bundle").
+			Do().Visit(test.Handle)
+		if err != nil || len(test.Infos) != 2 {
+			t.Fatalf("%s: unexpected response: %v %#v", k, err, test.Infos)
+		}
+		if test.Infos[0].Name != "foo" || test.Infos[1].Name != "baz" {
+			t.Errorf("%s: unexpected objects: %#v", k, test.Infos)
+		}
+		if k == "yaml" && test.Infos[1].Location() != "bundle (document 3)" {
+			t.Errorf("%s: unexpected location: %s", k, test.Infos[1].Location())
+		}
+		if k == "json" && test.Infos[1].Location() != "bundle (document 2)" {
+			t.Errorf("%s: unexpected location: %s", k, test.Infos[1].Location())
+		}
+	}
+
+	unknown := strings.Replace(svcJSON, `"kind":"Service"`, `"kind":"Widget"`, 1)
+	err := NewBuilder(latest.RESTMapper, api.Scheme, fakeClient()).
+		Stream(strings.NewReader(podJSON+unknown), "bundle").
+		Do().Visit(func(*Info) error { return nil })
+	if docErr, ok := err.(*DocumentError); !ok || docErr.Source != "bundle" || docErr.Document != 2 {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
 func TestReplaceAliases(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -952,3 +1263,90 @@ func TestReplaceAliases(t *testing.T) {
 		}
 	}
 }
+
+func TestParallelVisitor(t *testing.T) {
+	visitors := VisitorList{}
+	for i := 0; i < 10; i++ {
+		visitors = append(visitors, &Info{Name: strconv.Itoa(i)})
+	}
+
+	var lock sync.Mutex
+	running, maxRunning := 0, 0
+	visited := util.StringSet{}
+	err := NewParallelVisitor(visitors, 3).Visit(func(info *Info) error {
+		lock.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		visited.Insert(info.Name)
+		lock.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		lock.Lock()
+		running--
+		lock.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(visited) != len(visitors) {
+		t.Errorf("expected every item to be visited: %v", visited.List())
+	}
+	if maxRunning < 2 || maxRunning > 3 {
+		t.Errorf("expected between 2 and 3 concurrent visits, got %d", maxRunning)
+	}
+
+	err = NewParallelVisitor(visitors, 3).Visit(func(info *Info) error {
+		if info.Name == "4" {
+			return fmt.Errorf("failed on %s", info.Name)
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "failed on 4" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// concurrentClient gives every GET its own client from the wrapped ClientMapper,
+// since a FakeRESTClient records the last request it sent and cannot be shared
+// between goroutines.
+type concurrentClient struct {
+	RESTClient
+	mapper ClientMapper
+}
+
+func (c concurrentClient) Get() *client.Request {
+	fake, _ := c.mapper.ClientForMapping(nil)
+	return fake.Get()
+}
+
+func TestParallelBuilder(t *testing.T) {
+	pods, _ := testData()
+	mapper := fakeClientWith("", t, map[string]string{
+		"/namespaces/test/pods/foo": runtime.EncodeOrDie(latest.Codec, &pods.Items[0]),
+		"/namespaces/test/pods/bar": runtime.EncodeOrDie(latest.Codec, &pods.Items[1]),
+	})
+	b := NewBuilder(latest.RESTMapper, api.Scheme, ClientMapperFunc(func(*meta.RESTMapping) (RESTClient, error) {
+		return concurrentClient{mapper: mapper}, nil
+	})).
+		NamespaceParam("test").ResourceTypeOrNameArgs(true, "pods", "foo", "bar").
+		ContinueOnError().Parallel(2)
+
+	infos, err := b.Do().Infos()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	names := util.StringSet{}
+	for _, info := range infos {
+		if info.Object == nil {
+			t.Errorf("expected the object of %s to be retrieved", info.Name)
+		}
+		names.Insert(info.Name)
+	}
+	if !names.HasAll("foo", "bar") || len(names) != 2 {
+		t.Errorf("unexpected infos: %v", names.List())
+	}
+}
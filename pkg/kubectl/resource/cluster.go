@@ -0,0 +1,129 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/errors"
+)
+
+// ClusterTarget is a cluster, such as the cluster of a kubeconfig context, that
+// objects are sent to.
+type ClusterTarget struct {
+	// The name the cluster is reported by.
+	Name    string
+	Clients ClientMapper
+}
+
+// ClusterErrors holds the errors that occurred on each target cluster, by the name of
+// the cluster. Clusters without errors are not included.
+type ClusterErrors map[string][]error
+
+// Error implements error.
+func (e ClusterErrors) Error() string {
+	names := []string{}
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	messages := []string{}
+	for _, name := range names {
+		errs := []string{}
+		for _, err := range e[name] {
+			errs = append(errs, err.Error())
+		}
+		messages = append(messages, fmt.Sprintf("cluster %q: %s", name, strings.Join(errs, ", ")))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ClusterVisitor visits every item of a visitor once for each of its targets, in the
+// order of Targets, with a copy of the item whose Client belongs to the target and
+// whose Cluster is the name of the target. A failure on one cluster does not affect
+// the others: once an item fails on a cluster, the remaining items are not visited on
+// that cluster unless ContinueOnError is set, and the failures are returned together
+// as ClusterErrors after every item has been visited.
+type ClusterVisitor struct {
+	Visitor
+	Targets         []ClusterTarget
+	ContinueOnError bool
+}
+
+// NewClusterVisitor creates a visitor that visits the items of v on every target.
+func NewClusterVisitor(v Visitor, continueOnError bool, targets ...ClusterTarget) Visitor {
+	return ClusterVisitor{v, targets, continueOnError}
+}
+
+// Visit implements Visitor.
+func (v ClusterVisitor) Visit(fn VisitorFunc) error {
+	var lock sync.Mutex
+	clusterErrs := ClusterErrors{}
+	failed := func(name string) bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(clusterErrs[name]) > 0
+	}
+	err := v.Visitor.Visit(func(info *Info) error {
+		for _, target := range v.Targets {
+			if !v.ContinueOnError && failed(target.Name) {
+				continue
+			}
+			if err := v.visit(target, info, fn); err != nil {
+				lock.Lock()
+				clusterErrs[target.Name] = append(clusterErrs[target.Name], err)
+				lock.Unlock()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if len(clusterErrs) == 0 {
+			return err
+		}
+		return errors.NewAggregate([]error{err, clusterErrs})
+	}
+	if len(clusterErrs) > 0 {
+		return clusterErrs
+	}
+	return nil
+}
+
+// visit invokes fn with a copy of info that targets the given cluster.
+func (v ClusterVisitor) visit(target ClusterTarget, info *Info, fn VisitorFunc) error {
+	copied := *info
+	copied.Cluster = target.Name
+	if info.Mapping != nil {
+		client, err := target.Clients.ClientForMapping(info.Mapping)
+		if err != nil {
+			return err
+		}
+		copied.Client = client
+	}
+	if info.Object != nil {
+		obj, err := api.Scheme.Copy(info.Object)
+		if err != nil {
+			return err
+		}
+		copied.Object = obj
+	}
+	return fn(&copied)
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+func clusterTarget(name string, t *testing.T) ClusterTarget {
+	data := map[string]string{}
+	for _, pod := range []string{"foo", "bar"} {
+		obj := &api.Pod{ObjectMeta: api.ObjectMeta{Name: pod, Namespace: "test", Labels: map[string]string{"cluster": name}}}
+		data["/namespaces/test/pods/"+pod] = runtime.EncodeOrDie(latest.Codec, obj)
+	}
+	return ClusterTarget{Name: name, Clients: fakeClientWith(name, t, data)}
+}
+
+func TestBuilderClusters(t *testing.T) {
+	tests := map[string]struct {
+		ContinueOnError bool
+		Visited         []string
+		Errs            int
+	}{
+		"failed cluster stops": {
+			Visited: []string{"east/foo", "west/foo", "east/bar"},
+			Errs:    1,
+		},
+		"continue on error": {
+			ContinueOnError: true,
+			Visited:         []string{"east/foo", "west/foo", "east/bar", "west/bar"},
+			Errs:            2,
+		},
+	}
+	for k, test := range tests {
+		pods, _ := testData()
+		requests := 0
+		counter := func(next client.HTTPClient) client.HTTPClient {
+			return client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+				requests++
+				return next.Do(req)
+			})
+		}
+		b := NewBuilder(latest.RESTMapper, api.Scheme, fakeClient()).
+			Stream(strings.NewReader(runtime.EncodeOrDie(latest.Codec, pods)), "STDIN").
+			Flatten().
+			Middleware(counter).
+			Clusters(clusterTarget("east", t), clusterTarget("west", t))
+		if test.ContinueOnError {
+			b.ContinueOnError()
+		}
+
+		visited := []string{}
+		err := b.Do().Visit(func(info *Info) error {
+			visited = append(visited, info.Cluster+"/"+info.Name)
+			obj, err := NewHelper(info.Client, info.Mapping).Get(info.Namespace, info.Name)
+			if err != nil {
+				return err
+			}
+			if obj.(*api.Pod).Labels["cluster"] != info.Cluster {
+				t.Errorf("%s: expected a client for cluster %s: %#v", k, info.Cluster, obj)
+			}
+			if info.Cluster == "west" {
+				return fmt.Errorf("unable to create %s", info.Name)
+			}
+			return nil
+		})
+		if !reflect.DeepEqual(test.Visited, visited) {
+			t.Errorf("%s: unexpected visits: %v", k, visited)
+		}
+		if requests != len(test.Visited) {
+			t.Errorf("%s: expected the middleware to see every request, got %d", k, requests)
+		}
+		clusterErrs, ok := err.(ClusterErrors)
+		if !ok || len(clusterErrs) != 1 || len(clusterErrs["west"]) != test.Errs {
+			t.Errorf("%s: unexpected error: %v", k, err)
+		}
+	}
+}
+
+func TestClusterErrors(t *testing.T) {
+	err := ClusterErrors{
+		"west": {fmt.Errorf("a"), fmt.Errorf("b")},
+		"east": {fmt.Errorf("c")},
+	}
+	if err.Error() != `cluster "east": c; cluster "west": a, b` {
+		t.Errorf("unexpected message: %s", err.Error())
+	}
+}
@@ -0,0 +1,350 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/meta"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+// FieldDiff is a field whose value differs between the live object and the local
+// manifest.
+type FieldDiff struct {
+	// The path of the field, such as "spec.containers[0].image".
+	Path string
+	// The live and local values of the field, decoded from JSON. A value is nil if
+	// the field is not set on that side.
+	Live  interface{}
+	Local interface{}
+}
+
+// ObjectDiff is the difference between a live object and the local manifest that
+// describes it.
+type ObjectDiff struct {
+	// The normalized live and local objects, decoded from JSON. Live is nil if the
+	// object does not exist.
+	Live  map[string]interface{}
+	Local map[string]interface{}
+	// The fields that differ, ordered by path.
+	Fields []FieldDiff
+}
+
+// Empty returns true if the live object already matches the local manifest.
+func (d *ObjectDiff) Empty() bool {
+	return len(d.Fields) == 0
+}
+
+// Diff retrieves the named object and compares it to the local manifest in data, to
+// show what replacing the object with the manifest would change. Both are normalized
+// before they are compared: the manifest is decoded with the helper's codec, which
+// fills in the fields that are defaulted, and the fields set by the server that
+// ExportObject clears are ignored on both sides, as is the configuration recorded by
+// Apply. Spec fields that the server fills in, such as the portal IP of a service,
+// are ignored when the manifest leaves them unset. If the object does not exist every
+// field of the manifest is reported.
+func (m *Helper) Diff(namespace, name string, data []byte) (*ObjectDiff, error) {
+	local, err := m.Codec.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if m.NamespaceScoped {
+		accessor, err := meta.Accessor(local)
+		if err != nil {
+			return nil, err
+		}
+		if len(accessor.Namespace()) == 0 {
+			accessor.SetNamespace(namespace)
+		}
+	}
+	diff := &ObjectDiff{}
+	if diff.Local, err = m.normalizeForDiff(local); err != nil {
+		return nil, err
+	}
+
+	live, err := m.get(namespace, name)
+	switch {
+	case errors.IsNotFound(err):
+	case err != nil:
+		return nil, err
+	default:
+		if diff.Live, err = m.normalizeForDiff(live); err != nil {
+			return nil, err
+		}
+		ignoreServerPopulated(diff.Live, diff.Local)
+	}
+
+	diff.Fields = diffValues("", diff.Live, diff.Local, nil)
+	return diff, nil
+}
+
+// normalizeForDiff clears the fields of obj that Diff ignores and returns it decoded
+// from its JSON encoding.
+func (m *Helper) normalizeForDiff(obj runtime.Object) (map[string]interface{}, error) {
+	if err := ExportObject(obj); err != nil {
+		return nil, err
+	}
+	if err := setLastAppliedConfig(obj, ""); err != nil {
+		return nil, err
+	}
+	data, err := m.Codec.Encode(obj)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]interface{}{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// serverPopulatedFields are the paths of the spec fields of each kind that the server
+// fills in when they are not set, by their names in every API version.
+var serverPopulatedFields = map[string][][]string{
+	"Pod":     {{"spec", "host"}, {"spec", "nodeName"}, {"spec", "serviceAccount"}, {"spec", "serviceAccountName"}},
+	"Service": {{"spec", "portalIP"}, {"spec", "clusterIP"}},
+}
+
+// ignoreServerPopulated removes the fields the server fills in from live if they are
+// not set in local.
+func ignoreServerPopulated(live, local map[string]interface{}) {
+	kind, _ := local["kind"].(string)
+	for _, path := range serverPopulatedFields[kind] {
+		if _, found := lookupField(local, path); found {
+			continue
+		}
+		if parent, found := lookupField(live, path[:len(path)-1]); found {
+			if fields, ok := parent.(map[string]interface{}); ok {
+				delete(fields, path[len(path)-1])
+			}
+		}
+	}
+}
+
+// lookupField returns the value at path in obj, and whether it was found.
+func lookupField(obj map[string]interface{}, path []string) (interface{}, bool) {
+	var value interface{} = obj
+	for _, key := range path {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = fields[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// diffValues appends the differences between the live and local values at path to
+// diffs. Maps are compared key by key and lists element by element; any other
+// difference is reported for the value as a whole.
+func diffValues(path string, live, local interface{}, diffs []FieldDiff) []FieldDiff {
+	liveMap, liveIsMap := live.(map[string]interface{})
+	localMap, localIsMap := local.(map[string]interface{})
+	if liveIsMap && localIsMap {
+		keys := []string{}
+		for key := range liveMap {
+			keys = append(keys, key)
+		}
+		for key := range localMap {
+			if _, ok := liveMap[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			field := key
+			if len(path) != 0 {
+				field = path + "." + key
+			}
+			diffs = diffValues(field, liveMap[key], localMap[key], diffs)
+		}
+		return diffs
+	}
+
+	liveList, liveIsList := live.([]interface{})
+	localList, localIsList := local.([]interface{})
+	if liveIsList && localIsList {
+		for i := 0; i < len(liveList) || i < len(localList); i++ {
+			var liveItem, localItem interface{}
+			if i < len(liveList) {
+				liveItem = liveList[i]
+			}
+			if i < len(localList) {
+				localItem = localList[i]
+			}
+			diffs = diffValues(fmt.Sprintf("%s[%d]", path, i), liveItem, localItem, diffs)
+		}
+		return diffs
+	}
+
+	if !reflect.DeepEqual(live, local) {
+		diffs = append(diffs, FieldDiff{Path: path, Live: live, Local: local})
+	}
+	return diffs
+}
+
+// Unified returns the difference as text in the unified diff format, comparing the
+// indented JSON of the live and local objects with the given number of lines of
+// context around each change. An empty string is returned if there is no difference.
+func (d *ObjectDiff) Unified(context int) (string, error) {
+	liveLines, err := diffLines(d.Live)
+	if err != nil {
+		return "", err
+	}
+	localLines, err := diffLines(d.Local)
+	if err != nil {
+		return "", err
+	}
+	ops := editScript(liveLines, localLines)
+
+	// The number of live and local lines that precede each operation.
+	liveNo, localNo := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	for i, op := range ops {
+		liveNo[i+1], localNo[i+1] = liveNo[i], localNo[i]
+		if op.kind != '+' {
+			liveNo[i+1]++
+		}
+		if op.kind != '-' {
+			localNo[i+1]++
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		// Extend the hunk over every change separated by no more than twice the
+		// context, so that their context lines do not overlap.
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := 0
+			for end+run < len(ops) && ops[end+run].kind == ' ' {
+				run++
+			}
+			if end+run == len(ops) || run > 2*context {
+				if run > context {
+					run = context
+				}
+				end += run
+				break
+			}
+			end += run
+		}
+
+		if buf.Len() == 0 {
+			fmt.Fprintf(buf, "--- live\n+++ local\n")
+		}
+		fmt.Fprintf(buf, "@@ -%s +%s @@\n", hunkRange(liveNo[start], liveNo[end]), hunkRange(localNo[start], localNo[end]))
+		for _, op := range ops[start:end] {
+			fmt.Fprintf(buf, "%c%s\n", op.kind, op.text)
+		}
+		i = end
+	}
+	return buf.String(), nil
+}
+
+// hunkRange formats the lines from (exclusive) to to (inclusive) as a unified diff
+// range.
+func hunkRange(from, to int) string {
+	count := to - from
+	if count == 0 {
+		return fmt.Sprintf("%d,0", from)
+	}
+	return fmt.Sprintf("%d,%d", from+1, count)
+}
+
+// diffLines returns the lines of the indented JSON of obj, or no lines if obj is nil.
+func diffLines(obj map[string]interface{}) ([]string, error) {
+	if obj == nil {
+		return nil, nil
+	}
+	data, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(string(data), "\n"), nil
+}
+
+// lineOp is a line that is kept (' '), removed ('-'), or added ('+').
+type lineOp struct {
+	kind byte
+	text string
+}
+
+// editScript returns the shortest sequence of line operations that turns a into b,
+// computed from their longest common subsequence.
+func editScript(a, b []string) []lineOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	ops := []lineOp{}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, lineOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, lineOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, lineOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, lineOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, lineOp{'+', b[j]})
+	}
+	return ops
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/testapi"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+func diffTestPod(image string, labels map[string]string) *api.Pod {
+	return &api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "foo", Labels: labels},
+		Spec: api.PodSpec{
+			Containers:    []api.Container{{Name: "web", Image: image, ImagePullPolicy: api.PullIfNotPresent, TerminationMessagePath: api.TerminationMessagePathDefault}},
+			RestartPolicy: api.RestartPolicyAlways,
+			DNSPolicy:     api.DNSClusterFirst,
+		},
+	}
+}
+
+func TestHelperDiff(t *testing.T) {
+	live := diffTestPod("nginx:1.7", map[string]string{"app": "web", "tier": "frontend"})
+	live.Namespace = "test"
+	live.ResourceVersion = "10"
+	live.UID = "uid"
+	live.Annotations = map[string]string{LastAppliedConfigAnnotation: "{}"}
+	live.Spec.NodeName = "node-1"
+	live.Status = api.PodStatus{Phase: api.PodRunning, PodIP: "10.0.0.1"}
+	elsewhere := diffTestPod("nginx:1.7", map[string]string{"app": "web", "tier": "frontend"})
+	elsewhere.Spec.NodeName = "node-2"
+
+	tests := map[string]struct {
+		Resp   *http.Response
+		Local  runtime.Object
+		Expect []FieldDiff
+		Text   string
+	}{
+		"unchanged": {
+			Resp:  &http.Response{StatusCode: http.StatusOK, Body: objBody(live)},
+			Local: diffTestPod("nginx:1.7", map[string]string{"app": "web", "tier": "frontend"}),
+		},
+		"changed": {
+			Resp:  &http.Response{StatusCode: http.StatusOK, Body: objBody(live)},
+			Local: diffTestPod("nginx:1.8", map[string]string{"app": "web"}),
+			Expect: []FieldDiff{
+				{Path: "metadata.labels.tier", Live: "frontend"},
+				{Path: "spec.containers[0].image", Live: "nginx:1.7", Local: "nginx:1.8"},
+			},
+			Text: "-      \"tier\": \"frontend\"\n",
+		},
+		"server populated field set": {
+			Resp:  &http.Response{StatusCode: http.StatusOK, Body: objBody(live)},
+			Local: elsewhere,
+			Expect: []FieldDiff{
+				{Path: "spec." + nodeNameField(), Live: "node-1", Local: "node-2"},
+			},
+			Text: "\"node-2\"",
+		},
+		"not found": {
+			Resp:  &http.Response{StatusCode: http.StatusNotFound, Body: objBody(&api.Status{Status: api.StatusFailure, Code: http.StatusNotFound, Reason: api.StatusReasonNotFound})},
+			Local: diffTestPod("nginx:1.8", nil),
+			Text:  "@@ -0,0 +1,",
+		},
+	}
+	for k, test := range tests {
+		client := &client.FakeRESTClient{
+			Codec: testapi.Codec(),
+			Resp:  test.Resp,
+		}
+		mapping, err := latest.RESTMapper.RESTMapping("Pod", testapi.Version())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		diff, err := NewHelper(client, mapping).Diff("test", "foo", []byte(runtime.EncodeOrDie(testapi.Codec(), test.Local)))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", k, err)
+			continue
+		}
+		if k == "not found" {
+			if diff.Live != nil || len(diff.Fields) == 0 {
+				t.Errorf("%s: expected every field to be reported: %#v", k, diff)
+			}
+		} else if !reflect.DeepEqual(test.Expect, diff.Fields) {
+			t.Errorf("%s: unexpected fields: %#v", k, diff.Fields)
+		}
+
+		text, err := diff.Unified(3)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", k, err)
+			continue
+		}
+		if len(test.Text) == 0 != diff.Empty() || !strings.Contains(text, test.Text) {
+			t.Errorf("%s: unexpected unified diff:\n%s", k, text)
+		}
+	}
+}
+
+// nodeNameField returns the name of the field of the pod spec that holds the node the
+// pod is scheduled to in the test API version.
+func nodeNameField() string {
+	data := runtime.EncodeOrDie(testapi.Codec(), &api.Pod{Spec: api.PodSpec{NodeName: "node"}})
+	if strings.Contains(data, `"nodeName"`) {
+		return "nodeName"
+	}
+	return "host"
+}
+
+func TestUnifiedDiffHunks(t *testing.T) {
+	diff := &ObjectDiff{
+		Live:  map[string]interface{}{"a": 1, "b": 2, "c": 3, "d": 4, "e": 5, "f": 6, "g": 7},
+		Local: map[string]interface{}{"a": 0, "b": 2, "c": 3, "d": 4, "e": 5, "f": 6, "g": 8},
+	}
+	text, err := diff.Unified(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `--- live
++++ local
+@@ -1,3 +1,3 @@
+ {
+-  "a": 1,
++  "a": 0,
+   "b": 2,
+@@ -7,3 +7,3 @@
+   "f": 6,
+-  "g": 7
++  "g": 8
+ }
+`
+	if text != expected {
+		t.Errorf("unexpected unified diff:\n%s", text)
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/golang/glog"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+)
+
+// DefaultCacheDir is the directory under which discovery information is cached for
+// each server, unless another directory is provided.
+var DefaultCacheDir = filepath.Join(os.Getenv("HOME"), ".kube", "cache")
+
+// DefaultDiscoveryTTL is how long cached discovery information is used before it
+// is retrieved from the server again.
+const DefaultDiscoveryTTL = 10 * time.Minute
+
+// DiscoveryClient retrieves the API versions a server supports and the schema
+// describing the resources of each version.
+type DiscoveryClient interface {
+	ServerAPIVersions() (*api.APIVersions, error)
+	SwaggerSchema(version string) ([]byte, error)
+}
+
+// NewDiscoveryClient returns a DiscoveryClient that queries the server c talks to.
+func NewDiscoveryClient(c *client.Client) DiscoveryClient {
+	return clientDiscovery{c}
+}
+
+type clientDiscovery struct {
+	*client.Client
+}
+
+// SwaggerSchema implements DiscoveryClient
+func (c clientDiscovery) SwaggerSchema(version string) ([]byte, error) {
+	return c.RESTClient.Get().AbsPath("/swaggerapi/api", version).Do().Raw()
+}
+
+var unsafeCacheDirChars = regexp.MustCompile(`[^A-Za-z0-9.\-]`)
+
+// CacheDirForHost returns the directory under DefaultCacheDir in which discovery
+// information for the server at host is cached.
+func CacheDirForHost(host string) string {
+	return filepath.Join(DefaultCacheDir, unsafeCacheDirChars.ReplaceAllString(host, "_"))
+}
+
+// CachedDiscoveryClient is a DiscoveryClient that keeps the responses of the client
+// it wraps in files under a directory, so that short lived processes such as kubectl
+// do not have to retrieve them from the server every time they are run. Cached
+// responses older than the TTL are retrieved again. Failures to write the cache are
+// logged and otherwise ignored.
+type CachedDiscoveryClient struct {
+	delegate DiscoveryClient
+	dir      string
+	ttl      time.Duration
+}
+
+// NewCachedDiscoveryClient caches the responses of delegate in dir for ttl. The
+// directory is created when the first response is cached.
+func NewCachedDiscoveryClient(delegate DiscoveryClient, dir string, ttl time.Duration) *CachedDiscoveryClient {
+	return &CachedDiscoveryClient{
+		delegate: delegate,
+		dir:      dir,
+		ttl:      ttl,
+	}
+}
+
+// ServerAPIVersions implements DiscoveryClient
+func (c *CachedDiscoveryClient) ServerAPIVersions() (*api.APIVersions, error) {
+	data, err := c.cached("apiversions.json", func() ([]byte, error) {
+		versions, err := c.delegate.ServerAPIVersions()
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(versions)
+	})
+	if err != nil {
+		return nil, err
+	}
+	versions := &api.APIVersions{}
+	if err := json.Unmarshal(data, versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// SwaggerSchema implements DiscoveryClient
+func (c *CachedDiscoveryClient) SwaggerSchema(version string) ([]byte, error) {
+	return c.cached(filepath.Join("swagger", version+".json"), func() ([]byte, error) {
+		return c.delegate.SwaggerSchema(version)
+	})
+}
+
+// Invalidate discards everything that has been cached, so that the next requests
+// retrieve fresh information from the server. Use it when the server is known to
+// have changed, for example after it was upgraded.
+func (c *CachedDiscoveryClient) Invalidate() error {
+	return os.RemoveAll(c.dir)
+}
+
+// cached returns the contents of the named file in the cache if they are fresh, or
+// calls fetch and caches the result otherwise.
+func (c *CachedDiscoveryClient) cached(name string, fetch func() ([]byte, error)) ([]byte, error) {
+	path := filepath.Join(c.dir, name)
+	if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) < c.ttl {
+		if data, err := ioutil.ReadFile(path); err == nil {
+			return data, nil
+		}
+	}
+
+	data, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	if err := writeCacheFile(path, data); err != nil {
+		glog.V(3).Infof("Unable to cache %s: %v", path, err)
+	}
+	return data, nil
+}
+
+// writeCacheFile replaces the file at path with data atomically, so that concurrent
+// processes never read a partially written file.
+func writeCacheFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
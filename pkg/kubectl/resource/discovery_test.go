@@ -0,0 +1,122 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+type fakeDiscovery struct {
+	versions []string
+	calls    int
+	err      error
+}
+
+func (d *fakeDiscovery) ServerAPIVersions() (*api.APIVersions, error) {
+	d.calls++
+	if d.err != nil {
+		return nil, d.err
+	}
+	return &api.APIVersions{Versions: d.versions}, nil
+}
+
+func (d *fakeDiscovery) SwaggerSchema(version string) ([]byte, error) {
+	d.calls++
+	if d.err != nil {
+		return nil, d.err
+	}
+	return []byte(`{"apiVersion":"` + version + `"}`), nil
+}
+
+func TestCachedDiscoveryClient(t *testing.T) {
+	dir, err := ioutil.TempDir("", "discovery")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	fake := &fakeDiscovery{versions: []string{"v1beta3", "v1"}}
+	cached := NewCachedDiscoveryClient(fake, filepath.Join(dir, "localhost_8080"), time.Minute)
+
+	for i := 0; i < 2; i++ {
+		versions, err := cached.ServerAPIVersions()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(versions.Versions, fake.versions) {
+			t.Errorf("unexpected versions: %v", versions.Versions)
+		}
+		schema, err := cached.SwaggerSchema("v1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(schema) != `{"apiVersion":"v1"}` {
+			t.Errorf("unexpected schema: %s", string(schema))
+		}
+	}
+	if fake.calls != 2 {
+		t.Errorf("expected the server to be queried once per request type, got %d calls", fake.calls)
+	}
+
+	// A new process reads the cache written by the previous one, even if the server
+	// is unreachable.
+	fake.err = errors.New("unreachable")
+	cached = NewCachedDiscoveryClient(fake, filepath.Join(dir, "localhost_8080"), time.Minute)
+	if _, err := cached.ServerAPIVersions(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Expired entries are retrieved again.
+	fake.err = nil
+	stale := time.Now().Add(-2 * time.Minute)
+	if err := os.Chtimes(filepath.Join(dir, "localhost_8080", "apiversions.json"), stale, stale); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fake.versions = []string{"v1"}
+	versions, err := cached.ServerAPIVersions()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(versions.Versions, []string{"v1"}) || fake.calls != 3 {
+		t.Errorf("expected the expired entry to be refreshed: %v after %d calls", versions.Versions, fake.calls)
+	}
+
+	// Invalidate forces every entry to be retrieved again.
+	if err := cached.Invalidate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := cached.SwaggerSchema("v1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fake.calls != 4 {
+		t.Errorf("expected the schema to be retrieved after invalidation, got %d calls", fake.calls)
+	}
+}
+
+func TestCacheDirForHost(t *testing.T) {
+	if e, a := filepath.Join(DefaultCacheDir, "https___10.0.0.1_443"), CacheDirForHost("https://10.0.0.1:443"); e != a {
+		t.Errorf("expected %s, got %s", e, a)
+	}
+}
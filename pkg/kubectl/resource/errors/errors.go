@@ -0,0 +1,115 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package errors classifies the errors returned by a resource.Helper or visitor by
+// the API status the server responded with, so that callers can react to them
+// without matching on error messages.
+package errors
+
+import (
+	"net/http"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	apierrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	utilerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/util/errors"
+)
+
+// Causer is implemented by errors that wrap the error that caused them, such as
+// resource.DocumentError.
+type Causer interface {
+	Cause() error
+}
+
+// Status returns the API status err was derived from, and false if err does not
+// carry one. Errors implementing Causer and aggregates of a single error are
+// looked through.
+func Status(err error) (api.Status, bool) {
+	for err != nil {
+		switch t := err.(type) {
+		case *apierrors.StatusError:
+			return t.Status(), true
+		case Causer:
+			err = t.Cause()
+		case utilerrors.Aggregate:
+			if len(t.Errors()) != 1 {
+				return api.Status{}, false
+			}
+			err = t.Errors()[0]
+		default:
+			return api.Status{}, false
+		}
+	}
+	return api.Status{}, false
+}
+
+// Reason returns the reason of the API status err was derived from. If the server
+// did not give a reason, it is inferred from the HTTP status code of the response.
+func Reason(err error) api.StatusReason {
+	status, ok := Status(err)
+	if !ok {
+		return api.StatusReasonUnknown
+	}
+	if status.Reason != api.StatusReasonUnknown {
+		return status.Reason
+	}
+	switch status.Code {
+	case http.StatusNotFound:
+		return api.StatusReasonNotFound
+	case http.StatusConflict:
+		return api.StatusReasonConflict
+	case http.StatusForbidden:
+		return api.StatusReasonForbidden
+	case http.StatusUnauthorized:
+		return api.StatusReasonUnauthorized
+	case http.StatusGatewayTimeout:
+		return api.StatusReasonTimeout
+	}
+	return api.StatusReasonUnknown
+}
+
+// IsNotFound returns true if err indicates that the object does not exist.
+func IsNotFound(err error) bool {
+	return Reason(err) == api.StatusReasonNotFound
+}
+
+// IsConflict returns true if err indicates that the object was modified since it
+// was read.
+func IsConflict(err error) bool {
+	return Reason(err) == api.StatusReasonConflict
+}
+
+// IsForbidden returns true if err indicates that the user is not allowed to perform
+// the request.
+func IsForbidden(err error) bool {
+	return Reason(err) == api.StatusReasonForbidden
+}
+
+// IsServerTimeout returns true if err indicates that the server did not complete
+// the request in time. The request may be retried, although it may still have
+// taken effect.
+func IsServerTimeout(err error) bool {
+	reason := Reason(err)
+	return reason == api.StatusReasonServerTimeout || reason == api.StatusReasonTimeout
+}
+
+// IgnoreNotFound returns nil if err indicates that the object does not exist, and
+// err otherwise.
+func IgnoreNotFound(err error) error {
+	if IsNotFound(err) {
+		return nil
+	}
+	return err
+}
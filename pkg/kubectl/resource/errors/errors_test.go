@@ -0,0 +1,83 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	apierrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	utilerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/util/errors"
+)
+
+type wrapped struct {
+	err error
+}
+
+func (w wrapped) Error() string { return "wrapped: " + w.err.Error() }
+func (w wrapped) Cause() error  { return w.err }
+
+func statusWithCode(code int) error {
+	return apierrors.FromObject(&api.Status{Status: api.StatusFailure, Code: code})
+}
+
+func TestReason(t *testing.T) {
+	tests := map[string]struct {
+		Err    error
+		Reason api.StatusReason
+	}{
+		"not found":            {apierrors.NewNotFound("pods", "foo"), api.StatusReasonNotFound},
+		"not found code":       {statusWithCode(http.StatusNotFound), api.StatusReasonNotFound},
+		"conflict code":        {statusWithCode(http.StatusConflict), api.StatusReasonConflict},
+		"forbidden":            {apierrors.NewForbidden("pods", "foo", errors.New("denied")), api.StatusReasonForbidden},
+		"gateway timeout code": {statusWithCode(http.StatusGatewayTimeout), api.StatusReasonTimeout},
+		"unknown code":         {statusWithCode(http.StatusTeapot), api.StatusReasonUnknown},
+		"wrapped":              {wrapped{apierrors.NewNotFound("pods", "foo")}, api.StatusReasonNotFound},
+		"single aggregate":     {utilerrors.NewAggregate([]error{apierrors.NewConflict("pods", "foo", errors.New("stale"))}), api.StatusReasonConflict},
+		"multiple aggregate":   {utilerrors.NewAggregate([]error{apierrors.NewNotFound("pods", "foo"), apierrors.NewNotFound("pods", "bar")}), api.StatusReasonUnknown},
+		"not a status":         {errors.New("not found"), api.StatusReasonUnknown},
+		"nil":                  {nil, api.StatusReasonUnknown},
+	}
+	for k, test := range tests {
+		if reason := Reason(test.Err); reason != test.Reason {
+			t.Errorf("%s: expected %q, got %q", k, test.Reason, reason)
+		}
+	}
+}
+
+func TestPredicates(t *testing.T) {
+	if !IsNotFound(statusWithCode(http.StatusNotFound)) || IsNotFound(statusWithCode(http.StatusConflict)) {
+		t.Errorf("unexpected IsNotFound")
+	}
+	if !IsConflict(apierrors.NewConflict("pods", "foo", errors.New("stale"))) || IsConflict(apierrors.NewAlreadyExists("pods", "foo")) {
+		t.Errorf("unexpected IsConflict")
+	}
+	if !IsForbidden(statusWithCode(http.StatusForbidden)) {
+		t.Errorf("unexpected IsForbidden")
+	}
+	if !IsServerTimeout(apierrors.NewServerTimeout("pods", "list", 1)) || !IsServerTimeout(apierrors.NewTimeoutError("slow", 1)) {
+		t.Errorf("unexpected IsServerTimeout")
+	}
+	if IgnoreNotFound(apierrors.NewNotFound("pods", "foo")) != nil {
+		t.Errorf("expected not found to be ignored")
+	}
+	if err := statusWithCode(http.StatusForbidden); IgnoreNotFound(err) != err {
+		t.Errorf("expected other errors to be returned")
+	}
+}
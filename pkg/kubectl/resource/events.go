@@ -0,0 +1,145 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"sort"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/meta"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+)
+
+// EventFinder retrieves the events that involve an object. Events are matched by the
+// namespace, kind, name, and UID of the object they involve, as far as the reference
+// to the object sets them, both by the server and again on the client, so that an
+// event of an earlier object with the same name is not mistaken for one of the
+// current object.
+type EventFinder struct {
+	// Helper operates on events.
+	Helper ResourceHelper
+	// The API version of the events, which determines their field labels.
+	APIVersion string
+}
+
+// NewEventFinder creates an EventFinder that retrieves events through the clients of
+// mapper.
+func NewEventFinder(mapper *Mapper) (*EventFinder, error) {
+	mapping, err := mapper.RESTMapping("Event")
+	if err != nil {
+		return nil, err
+	}
+	client, err := mapper.ClientForMapping(mapping)
+	if err != nil {
+		return nil, err
+	}
+	return &EventFinder{NewHelper(client, mapping), mapping.APIVersion}, nil
+}
+
+// InfoReference returns a reference to the object of info, including its UID if the
+// object has been retrieved.
+func InfoReference(info *Info) *api.ObjectReference {
+	ref := &api.ObjectReference{
+		Namespace:       info.Namespace,
+		Name:            info.Name,
+		ResourceVersion: info.ResourceVersion,
+	}
+	if info.Mapping != nil {
+		ref.Kind = info.Mapping.Kind
+		ref.APIVersion = info.Mapping.APIVersion
+	}
+	if info.Object != nil {
+		if accessor, err := meta.Accessor(info.Object); err == nil {
+			ref.UID = accessor.UID()
+		}
+	}
+	return ref
+}
+
+// EventFieldSelector returns the field selector of the events that involve the object
+// ref refers to, with the field labels of apiVersion.
+func EventFieldSelector(ref *api.ObjectReference, apiVersion string) fields.Selector {
+	set := fields.Set{}
+	if api.PreV1Beta3(apiVersion) {
+		set["involvedObject.id"] = ref.Name
+	} else {
+		set["involvedObject.name"] = ref.Name
+	}
+	if len(ref.Namespace) > 0 {
+		set["involvedObject.namespace"] = ref.Namespace
+	}
+	if len(ref.Kind) > 0 {
+		set["involvedObject.kind"] = ref.Kind
+	}
+	if len(ref.UID) > 0 {
+		set["involvedObject.uid"] = string(ref.UID)
+	}
+	return set.AsSelector()
+}
+
+// List returns the events that involve the object ref refers to, ordered by the time
+// they last occurred, oldest first. The resource version of the list can be passed
+// to Watch to stream the events that follow.
+func (f *EventFinder) List(ref *api.ObjectReference) (*api.EventList, error) {
+	obj, err := f.Helper.List(ref.Namespace, f.APIVersion, labels.Everything(), EventFieldSelector(ref, f.APIVersion))
+	if err != nil {
+		return nil, err
+	}
+	list := obj.(*api.EventList)
+	events := []api.Event{}
+	for _, event := range list.Items {
+		if involves(ref, &event) {
+			events = append(events, event)
+		}
+	}
+	sort.Stable(eventsByTimestamp(events))
+	list.Items = events
+	return list, nil
+}
+
+// Watch watches for events that involve the object ref refers to, starting after
+// resourceVersion, such as the resource version of a list of the events.
+func (f *EventFinder) Watch(ref *api.ObjectReference, resourceVersion string) (watch.Interface, error) {
+	w, err := f.Helper.Watch(ref.Namespace, resourceVersion, f.APIVersion, labels.Everything(), EventFieldSelector(ref, f.APIVersion))
+	if err != nil {
+		return nil, err
+	}
+	return watch.Filter(w, func(in watch.Event) (watch.Event, bool) {
+		event, ok := in.Object.(*api.Event)
+		return in, !ok || involves(ref, event)
+	}), nil
+}
+
+// involves returns true if event involves the object ref refers to.
+func involves(ref *api.ObjectReference, event *api.Event) bool {
+	involved := event.InvolvedObject
+	return involved.Name == ref.Name &&
+		(len(ref.Namespace) == 0 || involved.Namespace == ref.Namespace) &&
+		(len(ref.Kind) == 0 || involved.Kind == ref.Kind) &&
+		(len(ref.UID) == 0 || involved.UID == ref.UID)
+}
+
+// eventsByTimestamp sorts events by the time they last occurred.
+type eventsByTimestamp []api.Event
+
+func (e eventsByTimestamp) Len() int      { return len(e) }
+func (e eventsByTimestamp) Swap(i, j int) { e[i], e[j] = e[j], e[i] }
+func (e eventsByTimestamp) Less(i, j int) bool {
+	return e[i].LastTimestamp.Time.Before(e[j].LastTimestamp.Time)
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/meta"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/types"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+)
+
+func podEvent(name string, uid types.UID, reason string, minute int) api.Event {
+	return api.Event{
+		ObjectMeta:     api.ObjectMeta{Name: name + "." + reason, Namespace: "test"},
+		InvolvedObject: api.ObjectReference{Kind: "Pod", Namespace: "test", Name: name, UID: uid},
+		Reason:         reason,
+		LastTimestamp:  util.NewTime(time.Date(2015, 1, 1, 0, minute, 0, 0, time.UTC)),
+	}
+}
+
+func TestEventFinder(t *testing.T) {
+	var query url.Values
+	events := &api.EventList{
+		ListMeta: api.ListMeta{ResourceVersion: "20"},
+		Items: []api.Event{
+			podEvent("foo", "uid", "started", 3),
+			podEvent("foo", "old-uid", "failed", 1),
+			podEvent("foo", "uid", "scheduled", 2),
+		},
+	}
+	clients := ClientMapperFunc(func(*meta.RESTMapping) (RESTClient, error) {
+		return &client.FakeRESTClient{
+			Codec: latest.Codec,
+			Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+				query = req.URL.Query()
+				switch req.URL.Path {
+				case "/namespaces/test/events":
+					return &http.Response{StatusCode: http.StatusOK, Body: objBody(events)}, nil
+				case "/watch/namespaces/test/events":
+					stale := podEvent("foo", "old-uid", "killed", 4)
+					current := podEvent("foo", "uid", "killed", 4)
+					return &http.Response{StatusCode: http.StatusOK, Body: stringBody(watchBody(
+						watch.Event{Type: watch.Added, Object: &stale},
+						watch.Event{Type: watch.Added, Object: &current},
+					))}, nil
+				}
+				t.Fatalf("unexpected request: %#v", req)
+				return nil, nil
+			}),
+		}, nil
+	})
+	finder, err := NewEventFinder(&Mapper{api.Scheme, latest.RESTMapper, clients})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pod := &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", Namespace: "test", UID: "uid"}}
+	mapping, _ := latest.RESTMapper.RESTMapping("Pod")
+	ref := InfoReference(&Info{Namespace: "test", Name: "foo", Mapping: mapping, Object: pod})
+	list, err := finder.List(ref)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reasons := []string{}
+	for _, event := range list.Items {
+		reasons = append(reasons, event.Reason)
+	}
+	if !reflect.DeepEqual(reasons, []string{"scheduled", "started"}) {
+		t.Errorf("unexpected events: %v", reasons)
+	}
+	selector := []string{"involvedObject.kind=Pod", "involvedObject.name=foo", "involvedObject.namespace=test", "involvedObject.uid=uid"}
+	fields := strings.Split(query.Get(api.FieldSelectorQueryParam(finder.APIVersion)), ",")
+	sort.Strings(fields)
+	if !reflect.DeepEqual(fields, selector) {
+		t.Errorf("unexpected field selector: %v", fields)
+	}
+
+	w, err := finder.Watch(ref, list.ResourceVersion)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Stop()
+	event := <-w.ResultChan()
+	if e, ok := event.Object.(*api.Event); !ok || e.InvolvedObject.UID != "uid" {
+		t.Errorf("expected events of other objects to be filtered out: %#v", event.Object)
+	}
+	if query.Get("resourceVersion") != "20" {
+		t.Errorf("expected the watch to start from the list: %v", query)
+	}
+}
+
+func TestEventFieldSelector(t *testing.T) {
+	ref := &api.ObjectReference{Name: "foo"}
+	if selector := EventFieldSelector(ref, "v1beta1").String(); selector != "involvedObject.id=foo" {
+		t.Errorf("unexpected selector: %s", selector)
+	}
+	if selector := EventFieldSelector(ref, "v1").String(); selector != "involvedObject.name=foo" {
+		t.Errorf("unexpected selector: %s", selector)
+	}
+}
@@ -0,0 +1,430 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	jsonpatch "github.com/evanphx/json-patch"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/meta"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/strategicpatch"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+)
+
+// FakeHelperAction records a call to a FakeHelper.
+type FakeHelperAction struct {
+	// One of "get", "list", "watch", "create", "replace", "patch", or "delete".
+	Verb      string
+	Namespace string
+	Name      string
+	// The object decoded from the data passed to Create or Replace.
+	Object runtime.Object
+	// The type and contents of the patch passed to Patch.
+	PatchType api.PatchType
+	Patch     []byte
+}
+
+// FakeHelperReaction is called with every action of a FakeHelper before the action is
+// performed. If it returns true, the action is not performed, and the object and
+// error it returns are returned to the caller instead. A reaction to a watch can only
+// make the watch fail.
+type FakeHelperReaction func(action FakeHelperAction) (bool, runtime.Object, error)
+
+// fakeWatchQueueLength is the number of events a watch of a FakeHelper holds before
+// changes to the objects block until they are received.
+const fakeWatchQueueLength = 100
+
+// FakeHelper implements ResourceHelper with objects of a single resource held in
+// memory, so that code written against a ResourceHelper can be tested without a
+// server. Like a server, it assigns resource versions and UIDs, rejects objects that
+// already exist or changed in the meantime, applies each type of patch, and reports
+// changes to watches. Every call is recorded and can be intercepted by reactions.
+//
+// Watches receive the changes made after they are opened; the resource version to
+// watch from is ignored. Lists only honor field selectors on metadata.name and
+// metadata.namespace.
+type FakeHelper struct {
+	// Reactions are tried in order for every action before the action is performed.
+	Reactions []FakeHelperReaction
+
+	mapping     *meta.RESTMapping
+	scoped      bool
+	broadcaster *watch.Broadcaster
+	// broadcastLock keeps changes reported to watches in the order they were made.
+	broadcastLock sync.Mutex
+
+	lock    sync.Mutex
+	objects map[string]runtime.Object
+	actions []FakeHelperAction
+	version int
+	// pending holds the changes made with the lock held, to be reported once it is
+	// released.
+	pending []watch.Event
+}
+
+var _ ResourceHelper = &FakeHelper{}
+
+// NewFakeHelper creates a FakeHelper for the resource of mapping that holds objects.
+func NewFakeHelper(mapping *meta.RESTMapping, objects ...runtime.Object) (*FakeHelper, error) {
+	f := &FakeHelper{
+		mapping:     mapping,
+		scoped:      mapping.Scope.Name() == meta.RESTScopeNameNamespace,
+		broadcaster: watch.NewBroadcaster(fakeWatchQueueLength, watch.WaitIfChannelFull),
+		objects:     map[string]runtime.Object{},
+	}
+	for _, obj := range objects {
+		if err := f.Add(obj); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Add stores obj as if it had been created, without recording an action.
+func (f *FakeHelper) Add(obj runtime.Object) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	f.lock.Lock()
+	defer f.unlock()
+	_, err = f.store(watch.Added, f.key(accessor.Namespace(), accessor.Name()), obj)
+	return err
+}
+
+// Actions returns the actions recorded so far, in the order they were made.
+func (f *FakeHelper) Actions() []FakeHelperAction {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]FakeHelperAction{}, f.actions...)
+}
+
+// Shutdown closes every open watch.
+func (f *FakeHelper) Shutdown() {
+	f.broadcaster.Shutdown()
+}
+
+func (f *FakeHelper) Get(namespace, name string) (runtime.Object, error) {
+	if handled, obj, err := f.invoke(FakeHelperAction{Verb: "get", Namespace: namespace, Name: name}); handled {
+		return obj, err
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	obj, found := f.objects[f.key(namespace, name)]
+	if !found {
+		return nil, errors.NewNotFound(f.mapping.Resource, name)
+	}
+	return api.Scheme.Copy(obj)
+}
+
+func (f *FakeHelper) List(namespace, apiVersion string, labelSelector labels.Selector, fieldSelector fields.Selector) (runtime.Object, error) {
+	if handled, obj, err := f.invoke(FakeHelperAction{Verb: "list", Namespace: namespace}); handled {
+		return obj, err
+	}
+	list, err := api.Scheme.New("", f.mapping.Kind+"List")
+	if err != nil {
+		return nil, err
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	keys := []string{}
+	for key, obj := range f.objects {
+		if f.matches(obj, namespace, labelSelector, fieldSelector) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	items := []runtime.Object{}
+	for _, key := range keys {
+		item, err := api.Scheme.Copy(f.objects[key])
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := runtime.SetList(list, items); err != nil {
+		return nil, err
+	}
+	if err := f.mapping.MetadataAccessor.SetResourceVersion(list, strconv.Itoa(f.version)); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (f *FakeHelper) Watch(namespace, resourceVersion, apiVersion string, labelSelector labels.Selector, fieldSelector fields.Selector) (watch.Interface, error) {
+	if handled, _, err := f.invoke(FakeHelperAction{Verb: "watch", Namespace: namespace}); handled {
+		return nil, err
+	}
+	return watch.Filter(f.broadcaster.Watch(), func(event watch.Event) (watch.Event, bool) {
+		return event, f.matches(event.Object, namespace, labelSelector, fieldSelector)
+	}), nil
+}
+
+func (f *FakeHelper) WatchSingle(namespace, name, resourceVersion string) (watch.Interface, error) {
+	return f.Watch(namespace, resourceVersion, "", labels.Everything(), fields.SelectorFromSet(fields.Set{"metadata.name": name}))
+}
+
+func (f *FakeHelper) Create(namespace string, modify bool, data []byte) (runtime.Object, error) {
+	obj, err := f.mapping.Codec.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	if handled, obj, err := f.invoke(FakeHelperAction{Verb: "create", Namespace: namespace, Name: accessor.Name(), Object: obj}); handled {
+		return obj, err
+	}
+	if err := f.checkNamespace(namespace, accessor); err != nil {
+		return nil, err
+	}
+
+	f.lock.Lock()
+	defer f.unlock()
+	if len(accessor.Name()) == 0 && len(accessor.GenerateName()) > 0 {
+		accessor.SetName(fmt.Sprintf("%s%d", accessor.GenerateName(), f.version+1))
+	}
+	key := f.key(namespace, accessor.Name())
+	if _, found := f.objects[key]; found {
+		return nil, errors.NewAlreadyExists(f.mapping.Resource, accessor.Name())
+	}
+	accessor.SetUID(util.NewUUID())
+	return f.store(watch.Added, key, obj)
+}
+
+func (f *FakeHelper) Replace(namespace, name string, overwrite bool, data []byte) (runtime.Object, error) {
+	obj, err := f.mapping.Codec.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if handled, obj, err := f.invoke(FakeHelperAction{Verb: "replace", Namespace: namespace, Name: name, Object: obj}); handled {
+		return obj, err
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.checkNamespace(namespace, accessor); err != nil {
+		return nil, err
+	}
+
+	f.lock.Lock()
+	defer f.unlock()
+	return f.update(namespace, name, obj)
+}
+
+func (f *FakeHelper) Patch(namespace, name string, pt api.PatchType, data []byte) (runtime.Object, error) {
+	if handled, obj, err := f.invoke(FakeHelperAction{Verb: "patch", Namespace: namespace, Name: name, PatchType: pt, Patch: data}); handled {
+		return obj, err
+	}
+
+	f.lock.Lock()
+	defer f.unlock()
+	current, found := f.objects[f.key(namespace, name)]
+	if !found {
+		return nil, errors.NewNotFound(f.mapping.Resource, name)
+	}
+	original, err := f.mapping.Codec.Encode(current)
+	if err != nil {
+		return nil, err
+	}
+	var patched []byte
+	switch pt {
+	case api.StrategicMergePatchType:
+		versioned, err := versionedType(original)
+		if err != nil {
+			return nil, err
+		}
+		patched, err = strategicpatch.StrategicMergePatchData(original, data, versioned)
+	case api.MergePatchType:
+		patched, err = jsonpatch.MergePatch(original, data)
+	case api.JSONPatchType:
+		var patch jsonpatch.Patch
+		if patch, err = jsonpatch.DecodePatch(data); err == nil {
+			patched, err = patch.Apply(original)
+		}
+	default:
+		return nil, errors.NewBadRequest(fmt.Sprintf("unsupported patch type %q", pt))
+	}
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("unable to apply the patch to %s %q: %v", f.mapping.Resource, name, err))
+	}
+	obj, err := f.mapping.Codec.Decode(patched)
+	if err != nil {
+		return nil, err
+	}
+	return f.update(namespace, name, obj)
+}
+
+func (f *FakeHelper) Delete(namespace, name string) error {
+	if handled, _, err := f.invoke(FakeHelperAction{Verb: "delete", Namespace: namespace, Name: name}); handled {
+		return err
+	}
+
+	f.lock.Lock()
+	defer f.unlock()
+	key := f.key(namespace, name)
+	obj, found := f.objects[key]
+	if !found {
+		return errors.NewNotFound(f.mapping.Resource, name)
+	}
+	delete(f.objects, key)
+	f.pending = append(f.pending, watch.Event{Type: watch.Deleted, Object: obj})
+	return nil
+}
+
+func (f *FakeHelper) DeleteWithPreconditions(namespace, name string, preconditions Preconditions, options *api.DeleteOptions) error {
+	if handled, _, err := f.invoke(FakeHelperAction{Verb: "delete", Namespace: namespace, Name: name}); handled {
+		return err
+	}
+
+	f.lock.Lock()
+	defer f.unlock()
+	key := f.key(namespace, name)
+	obj, found := f.objects[key]
+	if !found {
+		return errors.NewNotFound(f.mapping.Resource, name)
+	}
+	if err := preconditions.check(f.mapping.Resource, name, obj); err != nil {
+		return err
+	}
+	delete(f.objects, key)
+	f.pending = append(f.pending, watch.Event{Type: watch.Deleted, Object: obj})
+	return nil
+}
+
+// unlock releases the lock and then reports the pending changes to watches, so that
+// a watch that is not being read from blocks only further changes.
+func (f *FakeHelper) unlock() {
+	events := f.pending
+	f.pending = nil
+	f.broadcastLock.Lock()
+	defer f.broadcastLock.Unlock()
+	f.lock.Unlock()
+	for _, event := range events {
+		f.broadcaster.Action(event.Type, event.Object)
+	}
+}
+
+// invoke records action and tries the reactions in order. It returns true with the
+// result of the first reaction that handled the action.
+func (f *FakeHelper) invoke(action FakeHelperAction) (bool, runtime.Object, error) {
+	f.lock.Lock()
+	f.actions = append(f.actions, action)
+	f.lock.Unlock()
+	for _, reaction := range f.Reactions {
+		if handled, obj, err := reaction(action); handled {
+			return true, obj, err
+		}
+	}
+	return false, nil, nil
+}
+
+// key returns the key of the named object in the objects of the fake.
+func (f *FakeHelper) key(namespace, name string) string {
+	if !f.scoped {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+// checkNamespace defaults the namespace of a new or replaced object to the namespace
+// of the request, and rejects an object in a different namespace.
+func (f *FakeHelper) checkNamespace(namespace string, accessor meta.Interface) error {
+	if !f.scoped {
+		return nil
+	}
+	switch accessor.Namespace() {
+	case "":
+		accessor.SetNamespace(namespace)
+	case namespace:
+	default:
+		return errors.NewBadRequest("the namespace of the provided object does not match the namespace sent on the request")
+	}
+	return nil
+}
+
+// matches returns true if obj is in namespace, or namespace is empty, and matches the
+// selectors.
+func (f *FakeHelper) matches(obj runtime.Object, namespace string, labelSelector labels.Selector, fieldSelector fields.Selector) bool {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return false
+	}
+	if f.scoped && len(namespace) > 0 && accessor.Namespace() != namespace {
+		return false
+	}
+	if labelSelector != nil && !labelSelector.Matches(labels.Set(accessor.Labels())) {
+		return false
+	}
+	objectFields := fields.Set{"metadata.name": accessor.Name(), "metadata.namespace": accessor.Namespace()}
+	return fieldSelector == nil || fieldSelector.Matches(objectFields)
+}
+
+// update replaces the named object with obj, unless obj has a resource version other
+// than that of the object it replaces. It must be called with the lock held.
+func (f *FakeHelper) update(namespace, name string, obj runtime.Object) (runtime.Object, error) {
+	key := f.key(namespace, name)
+	current, found := f.objects[key]
+	if !found {
+		return nil, errors.NewNotFound(f.mapping.Resource, name)
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	currentAccessor, err := meta.Accessor(current)
+	if err != nil {
+		return nil, err
+	}
+	if accessor.Name() != name {
+		return nil, errors.NewBadRequest("the name of the object does not match the name on the URL")
+	}
+	if version := accessor.ResourceVersion(); len(version) > 0 && version != currentAccessor.ResourceVersion() {
+		return nil, errors.NewConflict(f.mapping.Resource, name, fmt.Errorf("the object has been modified; please apply your changes to the latest version and try again"))
+	}
+	accessor.SetUID(currentAccessor.UID())
+	return f.store(watch.Modified, key, obj)
+}
+
+// store assigns obj the next resource version, stores a copy of it under key, and
+// queues the change to be reported to watches. It must be called with the lock held,
+// which must then be released with unlock.
+func (f *FakeHelper) store(eventType watch.EventType, key string, obj runtime.Object) (runtime.Object, error) {
+	f.version++
+	if err := f.mapping.MetadataAccessor.SetResourceVersion(obj, strconv.Itoa(f.version)); err != nil {
+		return nil, err
+	}
+	stored, err := api.Scheme.Copy(obj)
+	if err != nil {
+		return nil, err
+	}
+	f.objects[key] = stored
+	f.pending = append(f.pending, watch.Event{Type: eventType, Object: stored})
+	return obj, nil
+}
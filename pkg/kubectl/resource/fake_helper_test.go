@@ -0,0 +1,288 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/meta"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/testapi"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+)
+
+func newFakePodHelper(t *testing.T, objects ...runtime.Object) (*FakeHelper, *meta.RESTMapping) {
+	mapping, err := latest.RESTMapper.RESTMapping("Pod", testapi.Version())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := NewFakeHelper(mapping, objects...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return f, mapping
+}
+
+func fakePod(namespace, name string, labels map[string]string) *api.Pod {
+	return &api.Pod{ObjectMeta: api.ObjectMeta{Name: name, Namespace: namespace, Labels: labels}}
+}
+
+func fakePodNames(t *testing.T, list runtime.Object) []string {
+	items, err := runtime.ExtractList(list)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	names := []string{}
+	for _, item := range items {
+		pod := item.(*api.Pod)
+		names = append(names, pod.Namespace+"/"+pod.Name)
+	}
+	return names
+}
+
+func TestFakeHelperGetAndList(t *testing.T) {
+	f, _ := newFakePodHelper(t,
+		fakePod("test", "foo", map[string]string{"app": "web"}),
+		fakePod("test", "bar", map[string]string{"app": "db"}),
+		fakePod("other", "baz", map[string]string{"app": "web"}),
+	)
+
+	obj, err := f.Get("test", "foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pod := obj.(*api.Pod); pod.Name != "foo" || pod.ResourceVersion != "1" {
+		t.Errorf("unexpected object: %#v", pod)
+	}
+	if _, err := f.Get("other", "foo"); !errors.IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+
+	tests := []struct {
+		Namespace string
+		Labels    labels.Selector
+		Fields    fields.Selector
+		Expected  []string
+	}{
+		{"", labels.Everything(), fields.Everything(), []string{"other/baz", "test/bar", "test/foo"}},
+		{"test", labels.Everything(), fields.Everything(), []string{"test/bar", "test/foo"}},
+		{"", labels.SelectorFromSet(labels.Set{"app": "web"}), nil, []string{"other/baz", "test/foo"}},
+		{"", nil, fields.SelectorFromSet(fields.Set{"metadata.name": "bar"}), []string{"test/bar"}},
+	}
+	for i, test := range tests {
+		list, err := f.List(test.Namespace, testapi.Version(), test.Labels, test.Fields)
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+		if names := fakePodNames(t, list); !reflect.DeepEqual(names, test.Expected) {
+			t.Errorf("%d: unexpected items: %v", i, names)
+		}
+		if version := list.(*api.PodList).ResourceVersion; version != "3" {
+			t.Errorf("%d: unexpected resource version %q", i, version)
+		}
+	}
+}
+
+func TestFakeHelperChanges(t *testing.T) {
+	f, mapping := newFakePodHelper(t)
+	encode := func(obj runtime.Object) []byte {
+		return []byte(runtime.EncodeOrDie(mapping.Codec, obj))
+	}
+
+	w, err := f.Watch("test", "0", testapi.Version(), labels.Everything(), fields.Everything())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Stop()
+
+	obj, err := f.Create("test", true, encode(fakePod("", "foo", nil)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	created := obj.(*api.Pod)
+	if created.Namespace != "test" || created.ResourceVersion != "1" || len(created.UID) == 0 {
+		t.Errorf("unexpected object: %#v", created)
+	}
+	if _, err := f.Create("test", true, encode(fakePod("test", "foo", nil))); !errors.IsAlreadyExists(err) {
+		t.Errorf("expected an already exists error, got %v", err)
+	}
+	if _, err := f.Create("test", true, encode(fakePod("other", "bar", nil))); !errors.IsBadRequest(err) {
+		t.Errorf("expected a bad request error, got %v", err)
+	}
+
+	stale := fakePod("test", "foo", map[string]string{"version": "2"})
+	stale.ResourceVersion = "1"
+	if _, err := f.Replace("test", "foo", false, encode(stale)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := f.Replace("test", "foo", false, encode(stale)); !errors.IsConflict(err) {
+		t.Errorf("expected a conflict error, got %v", err)
+	}
+
+	patches := []struct {
+		Type  api.PatchType
+		Patch string
+		Value string
+	}{
+		{api.StrategicMergePatchType, `{"metadata":{"labels":{"version":"3"}}}`, "3"},
+		{api.MergePatchType, `{"metadata":{"labels":{"version":"4"}}}`, "4"},
+		{api.JSONPatchType, `[{"op":"replace","path":"/metadata/labels/version","value":"5"}]`, "5"},
+	}
+	for _, patch := range patches {
+		obj, err := f.Patch("test", "foo", patch.Type, []byte(patch.Patch))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", patch.Type, err)
+		}
+		if pod := obj.(*api.Pod); pod.Labels["version"] != patch.Value || pod.UID != created.UID {
+			t.Errorf("%s: unexpected object: %#v", patch.Type, pod)
+		}
+	}
+
+	if err := f.Delete("test", "foo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.Delete("test", "foo"); !errors.IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+
+	expected := []struct {
+		Type    watch.EventType
+		Version string
+	}{
+		{watch.Added, "1"}, {watch.Modified, "2"}, {watch.Modified, "3"}, {watch.Modified, "4"}, {watch.Modified, "5"}, {watch.Deleted, "5"},
+	}
+	for i, e := range expected {
+		select {
+		case event := <-w.ResultChan():
+			if pod := event.Object.(*api.Pod); event.Type != e.Type || pod.ResourceVersion != e.Version {
+				t.Errorf("%d: unexpected event %s of version %s", i, event.Type, pod.ResourceVersion)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%d: timed out waiting for an event", i)
+		}
+	}
+
+	verbs := []string{}
+	for _, action := range f.Actions() {
+		verbs = append(verbs, action.Verb)
+	}
+	if !reflect.DeepEqual(verbs, []string{"watch", "create", "create", "create", "replace", "replace", "patch", "patch", "patch", "delete", "delete"}) {
+		t.Errorf("unexpected actions: %v", verbs)
+	}
+}
+
+func TestFakeHelperReactions(t *testing.T) {
+	f, _ := newFakePodHelper(t, fakePod("test", "foo", nil))
+	f.Reactions = []FakeHelperReaction{
+		func(action FakeHelperAction) (bool, runtime.Object, error) {
+			return action.Verb == "delete", nil, fmt.Errorf("deletion is disabled")
+		},
+		func(action FakeHelperAction) (bool, runtime.Object, error) {
+			if action.Verb == "get" && action.Name == "bar" {
+				return true, fakePod("test", "bar", nil), nil
+			}
+			return false, nil, nil
+		},
+	}
+
+	if err := f.Delete("test", "foo"); err == nil || err.Error() != "deletion is disabled" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := f.Get("test", "foo"); err != nil {
+		t.Errorf("expected the object to remain, got %v", err)
+	}
+	if obj, err := f.Get("test", "bar"); err != nil || obj.(*api.Pod).Name != "bar" {
+		t.Errorf("unexpected object: %#v %v", obj, err)
+	}
+	expected := []FakeHelperAction{
+		{Verb: "delete", Namespace: "test", Name: "foo"},
+		{Verb: "get", Namespace: "test", Name: "foo"},
+		{Verb: "get", Namespace: "test", Name: "bar"},
+	}
+	if actions := f.Actions(); !reflect.DeepEqual(actions, expected) {
+		t.Errorf("unexpected actions: %#v", actions)
+	}
+}
+
+func TestFakeHelperWatchSingleAndPreconditions(t *testing.T) {
+	f, _ := newFakePodHelper(t, fakePod("test", "foo", nil), fakePod("test", "bar", nil))
+	w, err := f.WatchSingle("test", "foo", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Stop()
+
+	if err := f.DeleteWithPreconditions("test", "bar", Preconditions{ResourceVersion: "1"}, nil); !errors.IsConflict(err) {
+		t.Errorf("expected a conflict error, got %v", err)
+	}
+	if err := f.DeleteWithPreconditions("test", "bar", Preconditions{ResourceVersion: "2"}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.DeleteWithPreconditions("test", "foo", Preconditions{}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for {
+		select {
+		case event := <-w.ResultChan():
+			// events queued before the watch was opened may still be delivered
+			if event.Type == watch.Added {
+				continue
+			}
+			if pod := event.Object.(*api.Pod); event.Type != watch.Deleted || pod.Name != "foo" {
+				t.Errorf("unexpected event %s for %s", event.Type, pod.Name)
+			}
+			return
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for an event")
+		}
+	}
+}
+
+func TestFakeHelperWatchReadWhileChanging(t *testing.T) {
+	f, mapping := newFakePodHelper(t)
+	w, err := f.Watch("test", "0", testapi.Version(), labels.Everything(), fields.Everything())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Stop()
+
+	count := 2 * fakeWatchQueueLength
+	go func() {
+		for i := 0; i < count; i++ {
+			f.Create("test", true, []byte(runtime.EncodeOrDie(mapping.Codec, fakePod("", fmt.Sprintf("pod-%d", i), nil))))
+		}
+	}()
+	// Reading the objects while the queue of the watch is full must not block.
+	for i := 0; i < count; i++ {
+		select {
+		case event := <-w.ResultChan():
+			if _, err := f.Get("test", event.Object.(*api.Pod).Name); err != nil {
+				t.Fatalf("%d: unexpected error: %v", i, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%d: timed out waiting for an event", i)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/jsonpath"
+)
+
+// FilterFunc decides whether an object is visited.
+type FilterFunc func(info *Info) (bool, error)
+
+// FilteredVisitor only invokes the visitor function passed to Visit for the objects
+// that every filter accepts. An error returned by a filter terminates the visit.
+type FilteredVisitor struct {
+	Visitor
+	filters []FilterFunc
+}
+
+// NewFilteredVisitor creates a visitor that skips the objects rejected by any of
+// filters.
+func NewFilteredVisitor(v Visitor, filters ...FilterFunc) Visitor {
+	if len(filters) == 0 {
+		return v
+	}
+	return FilteredVisitor{v, filters}
+}
+
+// Visit implements Visitor
+func (v FilteredVisitor) Visit(fn VisitorFunc) error {
+	return v.Visitor.Visit(func(info *Info) error {
+		for _, filter := range v.filters {
+			ok, err := filter(info)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return nil
+			}
+		}
+		return fn(info)
+	})
+}
+
+// JSONPathFilter returns a FilterFunc that accepts the objects for which the JSONPath
+// expr selects at least one value that is not null or false, for example
+// "{.spec.nodeSelector}".
+func JSONPathFilter(expr string) (FilterFunc, error) {
+	path, err := jsonpath.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	return func(info *Info) (bool, error) {
+		data, err := ObjectData(info.Object)
+		if err != nil {
+			return false, err
+		}
+		for _, value := range path.Execute(data) {
+			if value != nil && value != false {
+				return true, nil
+			}
+		}
+		return false, nil
+	}, nil
+}
+
+// TemplateFilter returns a FilterFunc that accepts the objects for which the Go
+// template text evaluates to "true", for example "{{gt .spec.replicas 3}}". The
+// template is executed against the JSON representation of the object, with whole
+// numbers decoded as integers so that they can be compared with constants. A
+// template that evaluates to anything but "true", "false" or nothing is an error.
+func TemplateFilter(text string) (FilterFunc, error) {
+	tmpl, err := template.New("filter").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return func(info *Info) (bool, error) {
+		buf := &bytes.Buffer{}
+		if err := executeTemplate(tmpl, buf, info.Object); err != nil {
+			return false, err
+		}
+		switch result := strings.TrimSpace(buf.String()); result {
+		case "true":
+			return true, nil
+		case "false", "":
+			return false, nil
+		default:
+			return false, fmt.Errorf("filter %q evaluated to %q for %s %q, expected true or false", text, result, info.Mapping.Resource, info.Name)
+		}
+	}, nil
+}
+
+// PrintJSONPath returns a VisitorFunc that writes the values the JSONPath expr
+// selects from each object to w, one line per object.
+func PrintJSONPath(w io.Writer, expr string) (VisitorFunc, error) {
+	path, err := jsonpath.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	return func(info *Info) error {
+		data, err := ObjectData(info.Object)
+		if err != nil {
+			return err
+		}
+		return path.Print(w, data)
+	}, nil
+}
+
+// PrintTemplate returns a VisitorFunc that writes the output of the Go template text,
+// executed against each object as described in TemplateFilter, to w.
+func PrintTemplate(w io.Writer, text string) (VisitorFunc, error) {
+	tmpl, err := template.New("output").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return func(info *Info) error {
+		return executeTemplate(tmpl, w, info.Object)
+	}, nil
+}
+
+func executeTemplate(tmpl *template.Template, w io.Writer, obj runtime.Object) error {
+	data, err := ObjectData(obj)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, data)
+}
+
+// ObjectData returns obj decoded from its JSON representation into maps, lists and
+// values. Whole numbers are decoded as int64 and other numbers as float64.
+func ObjectData(obj runtime.Object) (interface{}, error) {
+	if obj == nil {
+		return nil, nil
+	}
+	var value interface{} = obj
+	if unstructured, ok := obj.(*runtime.Unstructured); ok {
+		value = unstructured.Object
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+	return convertNumbers(data), nil
+}
+
+func convertNumbers(data interface{}) interface{} {
+	switch t := data.(type) {
+	case map[string]interface{}:
+		for key, value := range t {
+			t[key] = convertNumbers(value)
+		}
+	case []interface{}:
+		for i, value := range t {
+			t[i] = convertNumbers(value)
+		}
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	}
+	return data
+}
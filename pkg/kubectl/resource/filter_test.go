@@ -0,0 +1,147 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+func TestFilters(t *testing.T) {
+	mapping, err := latest.RESTMapper.RESTMapping("ReplicationController")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	controller := func(replicas int, selector map[string]string) *Info {
+		return &Info{
+			Name:    "foo",
+			Mapping: mapping,
+			Object: &api.ReplicationController{
+				ObjectMeta: api.ObjectMeta{Name: "foo"},
+				Spec:       api.ReplicationControllerSpec{Replicas: replicas, Selector: selector},
+			},
+		}
+	}
+	template := func(text string) FilterFunc {
+		filter, err := TemplateFilter(text)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return filter
+	}
+	jsonPath := func(expr string) FilterFunc {
+		filter, err := JSONPathFilter(expr)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return filter
+	}
+
+	tests := map[string]struct {
+		Filter FilterFunc
+		Info   *Info
+		Accept bool
+		Err    bool
+	}{
+		"template accepts": {
+			Filter: template("{{gt .spec.replicas 3}}"),
+			Info:   controller(5, nil),
+			Accept: true,
+		},
+		"template rejects": {
+			Filter: template("{{gt .spec.replicas 3}}"),
+			Info:   controller(2, nil),
+		},
+		"template without a boolean result": {
+			Filter: template("{{.metadata.name}}"),
+			Info:   controller(2, nil),
+			Err:    true,
+		},
+		"jsonpath selects a value": {
+			Filter: jsonPath("{.spec.selector.app}"),
+			Info:   controller(1, map[string]string{"app": "web"}),
+			Accept: true,
+		},
+		"jsonpath selects nothing": {
+			Filter: jsonPath("{.spec.selector.app}"),
+			Info:   controller(1, nil),
+		},
+	}
+	for k, test := range tests {
+		accept, err := test.Filter(test.Info)
+		if test.Err != (err != nil) {
+			t.Errorf("%s: unexpected error: %v", k, err)
+		}
+		if accept != test.Accept {
+			t.Errorf("%s: expected %t, got %t", k, test.Accept, accept)
+		}
+	}
+
+	if _, err := TemplateFilter("{{gt .spec.replicas"); err == nil {
+		t.Errorf("expected an invalid template to be rejected")
+	}
+	if _, err := JSONPathFilter(".spec[replicas"); err == nil {
+		t.Errorf("expected an invalid expression to be rejected")
+	}
+}
+
+func TestBuilderFilter(t *testing.T) {
+	pods, _ := testData()
+	filter, err := TemplateFilter(`{{eq .metadata.name "bar"}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b := NewBuilder(latest.RESTMapper, api.Scheme, fakeClient()).
+		Stream(strings.NewReader(runtime.EncodeOrDie(latest.Codec, pods)), "STDIN").
+		Flatten().
+		Filter(filter)
+
+	buf := &bytes.Buffer{}
+	print, err := PrintJSONPath(buf, "{.metadata.name}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := b.Do().Visit(print); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.String() != "bar\n" {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+}
+
+func TestPrintTemplate(t *testing.T) {
+	buf := &bytes.Buffer{}
+	print, err := PrintTemplate(buf, "{{.metadata.name}}={{.spec.replicas}};")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info := &Info{Object: &api.ReplicationController{
+		ObjectMeta: api.ObjectMeta{Name: "foo"},
+		Spec:       api.ReplicationControllerSpec{Replicas: 3},
+	}}
+	if err := print(info); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.String() != "foo=3;" {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+// AddFinalizer adds finalizer to the finalizers of the named object, and returns the
+// object as stored by the server. If the object already has the finalizer, nothing
+// is changed and the object is returned as retrieved. If the object changes while
+// the finalizer is being added, it is retrieved again and the addition retried with
+// backoff, at most retries more times.
+//
+// Only namespaces have finalizers, in spec.finalizers. Ordinary updates leave them
+// unchanged, so they are replaced through the "finalize" subresource.
+func (m *Helper) AddFinalizer(name string, finalizer api.FinalizerName, retries int) (runtime.Object, error) {
+	return m.updateFinalizers(name, retries, func(finalizers []api.FinalizerName) ([]api.FinalizerName, bool) {
+		for _, existing := range finalizers {
+			if existing == finalizer {
+				return finalizers, false
+			}
+		}
+		return append(finalizers, finalizer), true
+	})
+}
+
+// RemoveFinalizer removes finalizer from the finalizers of the named object like
+// AddFinalizer adds one. If the object does not have the finalizer, nothing is
+// changed.
+func (m *Helper) RemoveFinalizer(name string, finalizer api.FinalizerName, retries int) (runtime.Object, error) {
+	return m.updateFinalizers(name, retries, func(finalizers []api.FinalizerName) ([]api.FinalizerName, bool) {
+		remaining := []api.FinalizerName{}
+		for _, existing := range finalizers {
+			if existing != finalizer {
+				remaining = append(remaining, existing)
+			}
+		}
+		return remaining, len(remaining) != len(finalizers)
+	})
+}
+
+// updateFinalizers retrieves the named object, applies change to its finalizers, and
+// replaces them on the server if change reports that they changed, retrying on
+// conflicts like ReplaceWithRetry.
+func (m *Helper) updateFinalizers(name string, retries int, change func([]api.FinalizerName) ([]api.FinalizerName, bool)) (runtime.Object, error) {
+	finalize := m.WithSubresource("finalize")
+	delay := replaceRetryDelay
+	for attempt := 0; ; attempt++ {
+		obj, err := m.get("", name)
+		if err != nil {
+			return nil, err
+		}
+		namespace, ok := obj.(*api.Namespace)
+		if !ok {
+			return nil, fmt.Errorf("%s %q does not have finalizers", m.Resource, name)
+		}
+		finalizers, changed := change(namespace.Spec.Finalizers)
+		if !changed {
+			return obj, nil
+		}
+		namespace.Spec.Finalizers = finalizers
+		data, err := m.Codec.Encode(namespace)
+		if err != nil {
+			return nil, err
+		}
+		result, err := finalize.replaceResource(finalize.RESTClient, m.Resource, "", name, data)
+		if err == nil || !errors.IsConflict(err) || attempt >= retries {
+			return result, err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
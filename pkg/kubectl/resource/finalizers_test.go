@@ -0,0 +1,150 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	apierrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/testapi"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+func TestHelperFinalizers(t *testing.T) {
+	conflict := apierrors.NewConflict("namespaces", "foo", nil).(*apierrors.StatusError).ErrStatus
+	tests := map[string]struct {
+		Remove    bool
+		Finalizer api.FinalizerName
+		Conflicts int
+		Retries   int
+
+		Puts       int
+		Finalizers []api.FinalizerName
+		Err        bool
+	}{
+		"add": {
+			Finalizer:  "example.com/cleanup",
+			Puts:       1,
+			Finalizers: []api.FinalizerName{api.FinalizerKubernetes, "example.com/cleanup"},
+		},
+		"add an existing finalizer": {
+			Finalizer:  api.FinalizerKubernetes,
+			Finalizers: []api.FinalizerName{api.FinalizerKubernetes},
+		},
+		"remove": {
+			Remove:     true,
+			Finalizer:  api.FinalizerKubernetes,
+			Puts:       1,
+			Finalizers: []api.FinalizerName{},
+		},
+		"remove a missing finalizer": {
+			Remove:     true,
+			Finalizer:  "example.com/cleanup",
+			Finalizers: []api.FinalizerName{api.FinalizerKubernetes},
+		},
+		"retry on conflict": {
+			Finalizer:  "example.com/cleanup",
+			Conflicts:  1,
+			Retries:    1,
+			Puts:       2,
+			Finalizers: []api.FinalizerName{api.FinalizerKubernetes, "example.com/cleanup"},
+		},
+		"retries exhausted": {
+			Finalizer: "example.com/cleanup",
+			Conflicts: 2,
+			Retries:   1,
+			Puts:      2,
+			Err:       true,
+		},
+	}
+	for k, test := range tests {
+		puts := 0
+		client := &client.FakeRESTClient{
+			Codec: testapi.Codec(),
+			Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+				switch {
+				case req.Method == "GET" && req.URL.Path == "/namespaces/foo":
+					namespace := &api.Namespace{
+						ObjectMeta: api.ObjectMeta{Name: "foo", ResourceVersion: "10"},
+						Spec:       api.NamespaceSpec{Finalizers: []api.FinalizerName{api.FinalizerKubernetes}},
+					}
+					return &http.Response{StatusCode: http.StatusOK, Body: objBody(namespace)}, nil
+				case req.Method == "PUT" && req.URL.Path == "/namespaces/foo/finalize":
+					puts++
+					if puts <= test.Conflicts {
+						return &http.Response{StatusCode: http.StatusConflict, Body: objBody(&conflict)}, nil
+					}
+					data, err := ioutil.ReadAll(req.Body)
+					if err != nil {
+						t.Fatalf("%s: unexpected error: %v", k, err)
+					}
+					return &http.Response{StatusCode: http.StatusOK, Body: stringBody(string(data))}, nil
+				}
+				t.Fatalf("%s: unexpected request: %#v", k, req)
+				return nil, nil
+			}),
+		}
+		helper := &Helper{
+			RESTClient: client,
+			Codec:      testapi.Codec(),
+			Resource:   "namespaces",
+		}
+		var obj runtime.Object
+		var err error
+		if test.Remove {
+			obj, err = helper.RemoveFinalizer("foo", test.Finalizer, test.Retries)
+		} else {
+			obj, err = helper.AddFinalizer("foo", test.Finalizer, test.Retries)
+		}
+		if puts != test.Puts {
+			t.Errorf("%s: unexpected number of updates: %d", k, puts)
+		}
+		if test.Err {
+			if !apierrors.IsConflict(err) {
+				t.Errorf("%s: expected a conflict: %v", k, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", k, err)
+			continue
+		}
+		finalizers := obj.(*api.Namespace).Spec.Finalizers
+		if finalizers == nil {
+			finalizers = []api.FinalizerName{}
+		}
+		if !reflect.DeepEqual(test.Finalizers, finalizers) {
+			t.Errorf("%s: unexpected finalizers: %v", k, finalizers)
+		}
+	}
+}
+
+func TestHelperFinalizersUnsupported(t *testing.T) {
+	client := &client.FakeRESTClient{
+		Codec: testapi.Codec(),
+		Resp:  &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}})},
+	}
+	helper := &Helper{RESTClient: client, Codec: testapi.Codec(), Resource: "pods", NamespaceScoped: true}
+	if _, err := helper.AddFinalizer("foo", api.FinalizerKubernetes, 0); err == nil {
+		t.Errorf("expected an error for an object without finalizers")
+	}
+}
@@ -17,12 +17,35 @@ limitations under the License.
 package resource
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/meta"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/validation"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/conversion"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
+	resourceerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/kubectl/resource/errors"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/types"
+	utilerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/util/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/strategicpatch"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+	watchjson "github.com/GoogleCloudPlatform/kubernetes/pkg/watch/json"
 )
 
 // Helper provides methods for retrieving or mutating a RESTful
@@ -37,8 +60,143 @@ type Helper struct {
 	// An interface for reading or writing the resource version of this
 	// type.
 	Versioner runtime.ResourceVersioner
+	// Converts objects to OutputVersion. If nil, api.Scheme is used.
+	Convertor runtime.ObjectConvertor
 	// True if the resource type is scoped to namespaces
 	NamespaceScoped bool
+	// If set, Get, Replace, and Patch operate on this subresource of the named
+	// object (for example "status") instead of the object itself.
+	Subresource string
+	// If nonzero, the server is asked to close watches after this period so
+	// that callers can reconnect. Watches opened with a timeout are returned
+	// as a *TimeoutWatcher.
+	WatchTimeout time.Duration
+	// If true, watches ask the server to send Bookmark events that carry the
+	// resource version the watch has reached, so that a watch closed while no
+	// objects were changing can be resumed from there rather than from the last
+	// change. Servers that do not support bookmarks ignore the request.
+	AllowWatchBookmarks bool
+	// If set, objects decoded from watch events are drawn from this pool.
+	// Consumers must hand each object back with ReleaseObject when they are
+	// done with it and must not retain it afterwards.
+	ObjectPool *ObjectPool
+	// If set, objects are checked against this schema before they are sent to
+	// the server by Create, Replace, and Apply, so that mistakes such as an
+	// unknown field name are reported locally instead of being silently dropped.
+	Schema validation.Schema
+	// If true, Get returns a nil object and no error, and Delete returns no error,
+	// when the named object does not exist.
+	IgnoreNotFound bool
+	// If true, Get, List, and ListStream ask the server not to compress their
+	// responses, overriding the gzip encoding the HTTP transport requests by default.
+	// Compression saves transfer time on large lists, especially over slow links,
+	// but can add latency to small responses on fast ones.
+	DisableCompression bool
+	// If set, Get, List, ListPage, and ListStream accept objects at least as new as
+	// this resource version instead of requiring the latest ones, so that the server
+	// may answer from its cache instead of with a quorum read from storage. Readers
+	// that tolerate stale objects can use AnyResourceVersion, and readers that must
+	// see their own writes can use the resource version returned by the write.
+	// Servers that do not cache objects ignore it and return the latest ones.
+	ReadResourceVersion string
+	// If set, Get, List, ListPage, and ListStream return objects converted from the
+	// internal version to this API version, such as "v1", so that objects written out
+	// have the same version whichever version the server prefers or stores. Objects
+	// are converted on the client with Convertor.
+	OutputVersion string
+}
+
+// AnyResourceVersion is the ReadResourceVersion of a Helper whose reads accept objects
+// of any version, however stale.
+const AnyResourceVersion = "0"
+
+// WithSubresource returns a copy of the Helper whose Get, Replace, and Patch
+// operate on the named subresource, for example "status" or "scale".
+func (m *Helper) WithSubresource(subresource string) *Helper {
+	helper := *m
+	helper.Subresource = subresource
+	return &helper
+}
+
+// WithReadResourceVersion returns a copy of the Helper whose reads accept objects at
+// least as new as resourceVersion. See ReadResourceVersion.
+func (m *Helper) WithReadResourceVersion(resourceVersion string) *Helper {
+	helper := *m
+	helper.ReadResourceVersion = resourceVersion
+	return &helper
+}
+
+// WithOutputVersion returns a copy of the Helper whose reads return objects of
+// version. See OutputVersion.
+func (m *Helper) WithOutputVersion(version string) *Helper {
+	helper := *m
+	helper.OutputVersion = version
+	return &helper
+}
+
+// WithContext returns a copy of the Helper whose requests are aborted when ctx
+// is cancelled or its deadline passes, including Lists and open Watches.
+func (m *Helper) WithContext(ctx context.Context) *Helper {
+	helper := *m
+	helper.RESTClient = NewContextClient(m.RESTClient, ctx)
+	return &helper
+}
+
+// WithTimeout returns a copy of the Helper whose requests fail once timeout has
+// passed without a complete response, so that an unresponsive server cannot block
+// callers indefinitely. The timeout is also sent to the server. When retries are
+// enabled the timeout covers all the attempts of a request. ListStream only sends
+// the timeout to the server, and watches are not affected; use WatchTimeout to
+// bound them.
+func (m *Helper) WithTimeout(timeout time.Duration) *Helper {
+	helper := *m
+	helper.RESTClient = NewTimeoutClient(m.RESTClient, timeout)
+	return &helper
+}
+
+// WithRetry returns a copy of the Helper whose requests are retried up to
+// maxRetries times while the server is throttling requests or failing with 5xx
+// errors, for example during an apiserver restart. Creates and patches are only
+// retried when the server is throttling them or unavailable, since other errors
+// may be returned after the change was made. Retries back off exponentially
+// starting at delay, or wait as long as the server's Retry-After header asks.
+func (m *Helper) WithRetry(maxRetries int, delay time.Duration) *Helper {
+	helper := *m
+	helper.RESTClient = NewRetryClient(m.RESTClient, maxRetries, delay)
+	return &helper
+}
+
+// WithMiddleware returns a copy of the Helper whose requests are sent through
+// middleware, the first of which is the outermost. See Middleware.
+func (m *Helper) WithMiddleware(middleware ...Middleware) *Helper {
+	helper := *m
+	helper.RESTClient = NewMiddlewareClient(m.RESTClient, middleware...)
+	return &helper
+}
+
+// WithHeaders returns a copy of the Helper that sets headers on every request.
+func (m *Helper) WithHeaders(headers http.Header) *Helper {
+	return m.WithMiddleware(SetHeaders(headers))
+}
+
+// WithUserAgent returns a copy of the Helper that appends suffix to the User-Agent of
+// every request. See AppendUserAgent.
+func (m *Helper) WithUserAgent(suffix string) *Helper {
+	return m.WithMiddleware(AppendUserAgent(suffix))
+}
+
+// WithRequestID returns a copy of the Helper that sends id as the RequestIDHeader of
+// every request, so that the requests of an operation can be correlated. Use a copy
+// per operation, with an ID from NewRequestID or one received from the caller of the
+// operation.
+func (m *Helper) WithRequestID(id string) *Helper {
+	return m.WithMiddleware(SetRequestID(id))
+}
+
+// WithMetrics returns a copy of the Helper that records every request it sends,
+// including retries, into metrics, labeled with the Helper's resource.
+func (m *Helper) WithMetrics(metrics Metrics) *Helper {
+	return m.WithMiddleware(ObserveMetrics(metrics, m.Resource))
 }
 
 // NewHelper creates a Helper from a ResourceMapping
@@ -48,57 +206,831 @@ func NewHelper(client RESTClient, mapping *meta.RESTMapping) *Helper {
 		Resource:        mapping.Resource,
 		Codec:           mapping.Codec,
 		Versioner:       mapping.MetadataAccessor,
+		Convertor:       mapping.ObjectConvertor,
 		NamespaceScoped: mapping.Scope.Name() == meta.RESTScopeNameNamespace,
 	}
 }
 
+// NewUnstructuredHelper creates a Helper for a resource the client has no compiled-in
+// type for, such as one provided by a plug-in. Objects are read and written as
+// *runtime.Unstructured. The client must decode responses with
+// runtime.UnstructuredJSONCodec, for example by setting it as the Codec in the
+// client.Config it was created from.
+func NewUnstructuredHelper(client RESTClient, resource string, namespaceScoped bool) *Helper {
+	return &Helper{
+		RESTClient:      client,
+		Resource:        resource,
+		Codec:           runtime.UnstructuredJSONCodec,
+		Versioner:       meta.NewAccessor(),
+		NamespaceScoped: namespaceScoped,
+	}
+}
+
+// Get retrieves the named object. If IgnoreNotFound is set and the object does not
+// exist, it returns nil and no error.
 func (m *Helper) Get(namespace, name string) (runtime.Object, error) {
-	return m.RESTClient.Get().
+	obj, err := m.readAt(m.getRequest(namespace, name)).Do().Get()
+	if err != nil {
+		if m.IgnoreNotFound && resourceerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return m.convertOutput(obj)
+}
+
+// get retrieves the latest version of the named object, for operations that change
+// it.
+func (m *Helper) get(namespace, name string) (runtime.Object, error) {
+	return m.getRequest(namespace, name).Do().Get()
+}
+
+// getLatest retrieves the latest version of the named object from h. A Helper is
+// asked for it regardless of its ReadResourceVersion, OutputVersion, and
+// IgnoreNotFound settings.
+func getLatest(h ResourceHelper, namespace, name string) (runtime.Object, error) {
+	if helper, ok := h.(*Helper); ok {
+		return helper.get(namespace, name)
+	}
+	return h.Get(namespace, name)
+}
+
+func (m *Helper) getRequest(namespace, name string) *client.Request {
+	return m.compress(m.RESTClient.Get()).
 		NamespaceIfScoped(namespace, m.NamespaceScoped).
 		Resource(m.Resource).
 		Name(name).
-		Do().
-		Get()
+		SubResource(m.Subresource)
 }
 
-// TODO: add field selector
-func (m *Helper) List(namespace, apiVersion string, selector labels.Selector) (runtime.Object, error) {
-	return m.RESTClient.Get().
+// convertOutput converts obj to OutputVersion, if it is set.
+func (m *Helper) convertOutput(obj runtime.Object) (runtime.Object, error) {
+	if len(m.OutputVersion) == 0 {
+		return obj, nil
+	}
+	convertor := m.Convertor
+	if convertor == nil {
+		convertor = api.Scheme
+	}
+	converted, err := convertor.ConvertToVersion(obj, m.OutputVersion)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert %s to version %q: %v", m.Resource, m.OutputVersion, err)
+	}
+	return converted, nil
+}
+
+// readAt asks the server for objects at least as new as ReadResourceVersion, if it
+// is set, instead of the latest ones.
+func (m *Helper) readAt(req *client.Request) *client.Request {
+	if len(m.ReadResourceVersion) == 0 {
+		return req
+	}
+	return req.Param("resourceVersion", m.ReadResourceVersion)
+}
+
+// compress asks the server to gzip the response to req, or to leave it uncompressed
+// if DisableCompression is set.
+func (m *Helper) compress(req *client.Request) *client.Request {
+	if m.DisableCompression {
+		return req.WrapClient(SetHeaders(http.Header{"Accept-Encoding": []string{"identity"}}))
+	}
+	return req.WrapClient(Gzip())
+}
+
+// Export retrieves the named object with the fields that are specific to the cluster
+// it was read from cleared, so that it can be created as is in another cluster. See
+// ExportObject for the fields that are cleared.
+func (m *Helper) Export(namespace, name string) (runtime.Object, error) {
+	obj, err := m.get(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := ExportObject(obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// ExportObject clears the status, resource version, UID, creation timestamp, and self
+// link of obj, which are set by the server that stores it.
+func ExportObject(obj runtime.Object) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	accessor.SetResourceVersion("")
+	accessor.SetUID("")
+	accessor.SetSelfLink("")
+
+	if unstructured, ok := obj.(*runtime.Unstructured); ok {
+		delete(unstructured.Object, "status")
+		if metadata, ok := unstructured.Object["metadata"].(map[string]interface{}); ok {
+			delete(metadata, "creationTimestamp")
+		}
+		return nil
+	}
+
+	v, err := conversion.EnforcePtr(obj)
+	if err != nil {
+		return err
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	if status := v.FieldByName("Status"); status.IsValid() && status.CanSet() {
+		status.Set(reflect.Zero(status.Type()))
+	}
+	if objectMeta := v.FieldByName("ObjectMeta"); objectMeta.IsValid() {
+		if timestamp := objectMeta.FieldByName("CreationTimestamp"); timestamp.IsValid() && timestamp.CanSet() {
+			timestamp.Set(reflect.Zero(timestamp.Type()))
+		}
+	}
+	return nil
+}
+
+// GetIfNewer retrieves the named object only if it has changed since resourceVersion.
+// The known version is sent to the server as an If-None-Match precondition, and a
+// server that honors it replies with 304 Not Modified, in which case the body is
+// never decoded. Servers that ignore the precondition return the full object and
+// changed is computed by comparing its resource version to the one provided. When
+// changed is false the returned object is nil. A deleted object is reported as a
+// NotFound error, never as unchanged.
+func (m *Helper) GetIfNewer(namespace, name, resourceVersion string) (obj runtime.Object, changed bool, err error) {
+	req := m.RESTClient.Get().
 		NamespaceIfScoped(namespace, m.NamespaceScoped).
 		Resource(m.Resource).
-		LabelsSelectorParam(selector).
-		Do().
-		Get()
+		Name(name)
+	if len(resourceVersion) != 0 {
+		req.SetHeader("If-None-Match", fmt.Sprintf("%q", resourceVersion))
+	}
+	obj, err = req.Do().Get()
+	if err != nil {
+		if isNotModified(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	if len(resourceVersion) == 0 {
+		return obj, true, nil
+	}
+	serverVersion, err := m.Versioner.ResourceVersion(obj)
+	if err != nil {
+		return nil, false, err
+	}
+	if serverVersion == resourceVersion {
+		return nil, false, nil
+	}
+	return obj, true, nil
+}
+
+// isNotModified returns true if the server answered a conditional request with
+// 304 Not Modified.
+func isNotModified(err error) bool {
+	status, ok := err.(client.APIStatus)
+	return ok && status.Status().Code == http.StatusNotModified
+}
+
+var (
+	// warningPattern matches the code, agent, and quoted text of a Warning header.
+	warningPattern = regexp.MustCompile(`^[0-9]{3} \S+ "((?:[^"\\]|\\.)*)"`)
+	// removedInPattern matches the version a deprecated resource is removed in.
+	removedInPattern = regexp.MustCompile(`(?:unavailable|removed) in (v[0-9]+(?:\.[0-9]+)*)`)
+	// replacementPattern matches the suggested replacement for a deprecated resource.
+	replacementPattern = regexp.MustCompile(`;\s*use (.+)$`)
+)
+
+// DeprecationInfo reports whether the server considers the served version of this
+// resource deprecated. It lists at most one item of the resource collection and
+// inspects the standard Warning headers the server attaches to the response,
+// extracting the version the resource will be removed in and the suggested
+// replacement when the warning text includes them. The response body is discarded.
+func (m *Helper) DeprecationInfo() (deprecated bool, removedInVersion string, replacement string, err error) {
+	result := m.RESTClient.Get().
+		Resource(m.Resource).
+		Param("limit", "1").
+		Do()
+	if err := result.Error(); err != nil {
+		return false, "", "", err
+	}
+	for _, warning := range result.Warnings() {
+		text := warningText(warning)
+		if !strings.Contains(strings.ToLower(text), "deprecated") {
+			continue
+		}
+		deprecated = true
+		if match := removedInPattern.FindStringSubmatch(text); match != nil {
+			removedInVersion = match[1]
+		}
+		if match := replacementPattern.FindStringSubmatch(text); match != nil {
+			replacement = strings.TrimSpace(match[1])
+		}
+	}
+	return deprecated, removedInVersion, replacement, nil
+}
+
+// warningText returns the text of a Warning header value of the form
+// `299 - "text"`, or the value unchanged if it is not in that form.
+func warningText(value string) string {
+	match := warningPattern.FindStringSubmatch(value)
+	if match == nil {
+		return value
+	}
+	return strings.Replace(match[1], `\"`, `"`, -1)
+}
+
+// List returns the resources matching the selectors. If namespace is
+// api.NamespaceAll the resources in every namespace are returned in a single list,
+// and each item keeps the namespace it belongs to. Servers that cannot apply a field
+// selector across namespaces reject the request; in that case each namespace is
+// listed in turn and the results are merged.
+func (m *Helper) List(namespace, apiVersion string, labelSelector labels.Selector, fieldSelector fields.Selector) (runtime.Object, error) {
+	list, _, err := m.listPage(namespace, apiVersion, labelSelector, fieldSelector, 0, "")
+	if err != nil && namespace == api.NamespaceAll && m.NamespaceScoped && fieldSelector != nil && !fieldSelector.Empty() && errors.IsBadRequest(err) {
+		list, err = m.listEachNamespace(apiVersion, labelSelector, fieldSelector, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m.convertOutput(list)
+}
+
+// listEachNamespace lists the resource in every namespace and merges the results. The
+// returned list has the metadata of the first namespace's list. listErr is returned
+// if there are no namespaces to list.
+func (m *Helper) listEachNamespace(apiVersion string, labelSelector labels.Selector, fieldSelector fields.Selector, listErr error) (runtime.Object, error) {
+	namespaces, err := m.RESTClient.Get().Resource("namespaces").Do().Get()
+	if err != nil {
+		return nil, err
+	}
+	namespaceItems, err := runtime.ExtractList(namespaces)
+	if err != nil {
+		return nil, err
+	}
+	var list runtime.Object
+	var items []runtime.Object
+	for _, ns := range namespaceItems {
+		accessor, err := meta.Accessor(ns)
+		if err != nil {
+			return nil, err
+		}
+		namespace := accessor.Name()
+		nsList, _, err := m.listPage(namespace, apiVersion, labelSelector, fieldSelector, 0, "")
+		if err != nil {
+			return nil, err
+		}
+		nsItems, err := runtime.ExtractList(nsList)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range nsItems {
+			if itemAccessor, err := meta.Accessor(item); err == nil && len(itemAccessor.Namespace()) == 0 {
+				itemAccessor.SetNamespace(namespace)
+			}
+		}
+		if list == nil {
+			list = nsList
+		}
+		items = append(items, nsItems...)
+	}
+	if list == nil {
+		return nil, listErr
+	}
+	if err := runtime.SetList(list, items); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ListPage returns at most limit resources matching the selectors, starting at the
+// position described by continueToken, along with the token to pass to retrieve the
+// next page. An empty returned token means there are no more pages. A limit of zero
+// requests the whole collection. Servers that do not support paging ignore limit and
+// return the complete list with no continue token.
+func (m *Helper) ListPage(namespace, apiVersion string, labelSelector labels.Selector, fieldSelector fields.Selector, limit int64, continueToken string) (runtime.Object, string, error) {
+	list, next, err := m.listPage(namespace, apiVersion, labelSelector, fieldSelector, limit, continueToken)
+	if err != nil {
+		return nil, "", err
+	}
+	if list, err = m.convertOutput(list); err != nil {
+		return nil, "", err
+	}
+	return list, next, nil
+}
+
+func (m *Helper) listPage(namespace, apiVersion string, labelSelector labels.Selector, fieldSelector fields.Selector, limit int64, continueToken string) (runtime.Object, string, error) {
+	req := m.compress(m.RESTClient.Get()).
+		NamespaceIfScoped(namespace, m.NamespaceScoped).
+		Resource(m.Resource).
+		LabelsSelectorParam(labelSelector).
+		FieldsSelectorParam(fieldSelector)
+	if limit > 0 {
+		req.Param("limit", strconv.FormatInt(limit, 10))
+	}
+	if len(continueToken) > 0 {
+		// the continue token determines the version of the remaining pages
+		req.Param("continue", continueToken)
+	} else {
+		req = m.readAt(req)
+	}
+	result := req.Do()
+	list, err := result.Get()
+	if err != nil {
+		return nil, "", err
+	}
+	if limit == 0 && len(continueToken) == 0 {
+		return list, "", nil
+	}
+	body, err := result.Raw()
+	if err != nil {
+		return nil, "", err
+	}
+	next, err := continueTokenFrom(body)
+	if err != nil {
+		return nil, "", err
+	}
+	return list, next, nil
+}
+
+// continueTokenFrom reads the continue token from the metadata of a serialized list.
+// The token is not part of the list types known to this client, so it is lost when
+// the body is decoded.
+func continueTokenFrom(body []byte) (string, error) {
+	var list struct {
+		Metadata struct {
+			Continue string `json:"continue"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return "", err
+	}
+	return list.Metadata.Continue, nil
+}
+
+// ListEach lists the resources matching selector and invokes fn once for every item
+// in the returned list, in order. Iteration stops at the first error returned by fn,
+// and that error is returned. An empty list never invokes fn.
+func (m *Helper) ListEach(namespace, apiVersion string, selector labels.Selector, fn func(item runtime.Object) error) error {
+	list, err := m.List(namespace, apiVersion, selector, fields.Everything())
+	if err != nil {
+		return err
+	}
+	items, err := runtime.ExtractList(list)
+	if err != nil {
+		return fmt.Errorf("expected a list of %s, but the server returned %T: %v", m.Resource, list, err)
+	}
+	for _, item := range items {
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListStream lists the resources matching the selectors and invokes fn once for every
+// item in the list, in order. Items are decoded one at a time as they are read from
+// the response, so that at most one item is held in memory even for very large
+// collections. Iteration stops at the first error returned by fn, and that error is
+// returned.
+func (m *Helper) ListStream(namespace, apiVersion string, labelSelector labels.Selector, fieldSelector fields.Selector, fn func(item runtime.Object) error) error {
+	body, err := m.readAt(m.compress(m.RESTClient.Get()).
+		NamespaceIfScoped(namespace, m.NamespaceScoped).
+		Resource(m.Resource).
+		LabelsSelectorParam(labelSelector).
+		FieldsSelectorParam(fieldSelector)).
+		Stream()
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	return decodeListItems(body, m.Codec, func(item runtime.Object) error {
+		item, err := m.convertOutput(item)
+		if err != nil {
+			return err
+		}
+		return fn(item)
+	})
+}
+
+// decodeListItems reads a serialized list from r and invokes fn with each of its items
+// as soon as it is decoded. Serialized items do not carry their kind, so it is derived
+// from the kind of the list, which must precede the items as it does in lists encoded
+// by the server.
+func decodeListItems(r io.Reader, codec runtime.Codec, fn func(item runtime.Object) error) error {
+	scanner := &jsonScanner{r: bufio.NewReader(r)}
+	if c, err := scanner.next(); err != nil {
+		return err
+	} else if c != '{' {
+		return fmt.Errorf("expected a list, but the server returned %q", c)
+	}
+
+	var kind, version string
+	for first := true; ; first = false {
+		if more, err := scanner.more('}', first); err != nil {
+			return err
+		} else if !more {
+			break
+		}
+		var key string
+		if err := scanner.decode(&key); err != nil {
+			return err
+		}
+		if err := scanner.expect(':'); err != nil {
+			return err
+		}
+		var err error
+		switch key {
+		case "kind":
+			err = scanner.decode(&kind)
+		case "apiVersion":
+			err = scanner.decode(&version)
+		case "items":
+			if !strings.HasSuffix(kind, "List") || len(version) == 0 {
+				return fmt.Errorf("the kind and apiVersion of a list must precede its items, got %q and %q", kind, version)
+			}
+			err = decodeItems(scanner, codec, strings.TrimSuffix(kind, "List"), version, fn)
+		default:
+			_, err = scanner.value()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if !strings.HasSuffix(kind, "List") {
+		return fmt.Errorf("expected a list, but the server returned %q", kind)
+	}
+	return nil
+}
+
+// decodeItems decodes the array of items of a list from scanner, as objects of the
+// given kind and version.
+func decodeItems(scanner *jsonScanner, codec runtime.Codec, kind, version string, fn func(item runtime.Object) error) error {
+	if c, err := scanner.peek(); err != nil {
+		return err
+	} else if c == 'n' {
+		var items []interface{}
+		return scanner.decode(&items)
+	}
+	if err := scanner.expect('['); err != nil {
+		return fmt.Errorf("expected the items of the list to be an array: %v", err)
+	}
+	typeMeta, err := json.Marshal(map[string]string{"kind": kind, "apiVersion": version})
+	if err != nil {
+		return err
+	}
+	for first := true; ; first = false {
+		if more, err := scanner.more(']', first); err != nil {
+			return err
+		} else if !more {
+			break
+		}
+		item, err := scanner.value()
+		if err != nil {
+			return err
+		}
+		if item[0] != '{' {
+			return fmt.Errorf("expected the items of the list to be objects, got %s", string(item))
+		}
+		// Merge the type information into the item: {"kind":...,"apiVersion":...,<fields>}
+		data := make([]byte, 0, len(typeMeta)+len(item))
+		data = append(data, typeMeta[:len(typeMeta)-1]...)
+		if fields := bytes.TrimSpace(item[1:]); len(fields) > 0 && fields[0] != '}' {
+			data = append(data, ',')
+		}
+		data = append(data, item[1:]...)
+		obj, err := codec.Decode(data)
+		if err != nil {
+			return err
+		}
+		if err := fn(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonScanner reads a JSON document one value at a time, so that the members of an
+// object and the elements of an array can be decoded without reading them all first.
+type jsonScanner struct {
+	r *bufio.Reader
+}
+
+// peek returns the next byte that is not whitespace, without consuming it.
+func (s *jsonScanner) peek() (byte, error) {
+	for {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return c, s.r.UnreadByte()
+	}
+}
+
+// next consumes and returns the next byte that is not whitespace.
+func (s *jsonScanner) next() (byte, error) {
+	if _, err := s.peek(); err != nil {
+		return 0, err
+	}
+	return s.r.ReadByte()
+}
+
+// expect consumes the next byte that is not whitespace, which must be delim.
+func (s *jsonScanner) expect(delim byte) error {
+	c, err := s.next()
+	if err != nil {
+		return err
+	}
+	if c != delim {
+		return fmt.Errorf("expected %q, got %q", delim, c)
+	}
+	return nil
+}
+
+// more reports whether the object or array being read has another member or element,
+// consuming the separator before it unless first is true, or end, which closes the
+// object or array.
+func (s *jsonScanner) more(end byte, first bool) (bool, error) {
+	c, err := s.peek()
+	if err != nil {
+		return false, err
+	}
+	if c == end {
+		s.r.ReadByte()
+		return false, nil
+	}
+	if !first {
+		if err := s.expect(','); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// value consumes and returns the next complete value.
+func (s *jsonScanner) value() (json.RawMessage, error) {
+	c, err := s.peek()
+	if err != nil {
+		return nil, err
+	}
+	var value []byte
+	switch c {
+	case '{', '[', '"':
+		depth, inString, escaped := 0, false, false
+		for {
+			c, err := s.r.ReadByte()
+			if err != nil {
+				if err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				}
+				return nil, err
+			}
+			value = append(value, c)
+			switch {
+			case escaped:
+				escaped = false
+			case inString && c == '\\':
+				escaped = true
+			case c == '"':
+				inString = !inString
+			case inString:
+			case c == '{' || c == '[':
+				depth++
+			case c == '}' || c == ']':
+				depth--
+			}
+			if depth == 0 && !inString {
+				return value, nil
+			}
+		}
+	case '}', ']', ',', ':':
+		return nil, fmt.Errorf("expected a value, got %q", c)
+	}
+	// numbers, booleans and null end at the next delimiter
+	for {
+		c, err := s.r.ReadByte()
+		if err == io.EOF {
+			return value, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch c {
+		case ',', '}', ']', ' ', '\t', '\r', '\n':
+			return value, s.r.UnreadByte()
+		}
+		value = append(value, c)
+	}
+}
+
+// decode consumes the next value and unmarshals it into v.
+func (s *jsonScanner) decode(v interface{}) error {
+	value, err := s.value()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(value, v)
 }
 
 func (m *Helper) Watch(namespace, resourceVersion, apiVersion string, labelSelector labels.Selector, fieldSelector fields.Selector) (watch.Interface, error) {
-	return m.RESTClient.Get().
+	return m.watch(m.RESTClient.Get().
 		Prefix("watch").
 		NamespaceIfScoped(namespace, m.NamespaceScoped).
 		Resource(m.Resource).
 		Param("resourceVersion", resourceVersion).
 		LabelsSelectorParam(labelSelector).
-		FieldsSelectorParam(fieldSelector).
-		Watch()
+		FieldsSelectorParam(fieldSelector), m.openWatch)
 }
 
 func (m *Helper) WatchSingle(namespace, name, resourceVersion string) (watch.Interface, error) {
-	return m.RESTClient.Get().
+	return m.watch(m.RESTClient.Get().
 		Prefix("watch").
 		NamespaceIfScoped(namespace, m.NamespaceScoped).
 		Resource(m.Resource).
 		Name(name).
-		Param("resourceVersion", resourceVersion).
-		Watch()
+		Param("resourceVersion", resourceVersion), m.openWatch)
+}
+
+// watch opens the watch described by req with open, asking the server to close it
+// after WatchTimeout if one is set, and to send bookmarks if AllowWatchBookmarks is set.
+func (m *Helper) watch(req *client.Request, open func(*client.Request) (watch.Interface, error)) (watch.Interface, error) {
+	// A request timeout would cut the watch short; WatchTimeout bounds watches instead.
+	req.Timeout(0)
+	if m.AllowWatchBookmarks {
+		req.Param("allowWatchBookmarks", "true")
+	}
+	if m.WatchTimeout == 0 {
+		return open(req)
+	}
+	seconds := int64((m.WatchTimeout + time.Second - 1) / time.Second)
+	deadline := time.Now().Add(time.Duration(seconds) * time.Second)
+	w, err := open(req.Param("timeoutSeconds", strconv.FormatInt(seconds, 10)))
+	if err != nil {
+		return nil, err
+	}
+	return NewTimeoutWatcher(w, deadline), nil
 }
 
+// openWatch executes req, decoding events through ObjectPool if one is set.
+func (m *Helper) openWatch(req *client.Request) (watch.Interface, error) {
+	if m.ObjectPool == nil {
+		return req.Watch()
+	}
+	body, err := req.Stream()
+	if err != nil {
+		return nil, err
+	}
+	return watch.NewStreamWatcher(watchjson.NewDecoder(body, m.ObjectPool)), nil
+}
+
+// ReleaseObject hands an object received from a watch back to ObjectPool so that
+// it can be reused. It is a no-op if the Helper has no ObjectPool.
+func (m *Helper) ReleaseObject(obj runtime.Object) {
+	if m.ObjectPool != nil {
+		m.ObjectPool.Release(obj)
+	}
+}
+
+// Delete deletes the named object. If IgnoreNotFound is set, deleting an object that
+// does not exist is not an error.
 func (m *Helper) Delete(namespace, name string) error {
-	return m.RESTClient.Delete().
+	return m.DeleteWithOptions(namespace, name, nil)
+}
+
+// DeleteWithOptions deletes the named resource, sending options (for instance a
+// grace period) to the server in the request body. If options is nil no body is
+// sent, which is equivalent to Delete.
+func (m *Helper) DeleteWithOptions(namespace, name string, options *api.DeleteOptions) error {
+	req := m.RESTClient.Delete().
 		NamespaceIfScoped(namespace, m.NamespaceScoped).
 		Resource(m.Resource).
-		Name(name).
+		Name(name)
+	if options != nil {
+		req.Body(options)
+	}
+	err := req.Do().Error()
+	if err != nil && m.IgnoreNotFound && resourceerrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// Preconditions are checked against the live object before a change is made to it, so
+// that a change meant for the object a caller observed is not made to another object
+// of the same name, for example one that was recreated in the meantime.
+type Preconditions struct {
+	// If set, the live object must have this UID.
+	UID types.UID
+	// If set, the live object must be at this resource version.
+	ResourceVersion string
+}
+
+// check returns a Conflict error if obj, the named object of resource, does not meet
+// the preconditions.
+func (p Preconditions) check(resource, name string, obj runtime.Object) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	if len(p.UID) > 0 && accessor.UID() != p.UID {
+		return errors.NewConflict(resource, name, fmt.Errorf("the precondition on the UID failed: expected %s, found %s", p.UID, accessor.UID()))
+	}
+	if len(p.ResourceVersion) > 0 && accessor.ResourceVersion() != p.ResourceVersion {
+		return errors.NewConflict(resource, name, fmt.Errorf("the precondition on the resource version failed: expected %s, found %s", p.ResourceVersion, accessor.ResourceVersion()))
+	}
+	return nil
+}
+
+// DeleteWithPreconditions deletes the named object like DeleteWithOptions, but only if
+// the live object meets preconditions; otherwise a Conflict error is returned. The
+// server does not check the preconditions itself, so they are checked against the
+// object retrieved just before the delete is sent, which leaves a short window in
+// which the object can still be replaced unnoticed.
+func (m *Helper) DeleteWithPreconditions(namespace, name string, preconditions Preconditions, options *api.DeleteOptions) error {
+	obj, err := m.get(namespace, name)
+	if err != nil {
+		if m.IgnoreNotFound && resourceerrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if err := preconditions.check(m.Resource, name, obj); err != nil {
+		return err
+	}
+	return m.DeleteWithOptions(namespace, name, options)
+}
+
+// ReplaceWithPreconditions replaces the named object with the one in data, but only if
+// the live object meets preconditions; otherwise a Conflict error is returned. The
+// replace is made at the resource version of the object that was checked, so the
+// server rejects it with a Conflict if the object changes after the check.
+func (m *Helper) ReplaceWithPreconditions(namespace, name string, preconditions Preconditions, data []byte) (runtime.Object, error) {
+	current, err := m.get(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := preconditions.check(m.Resource, name, current); err != nil {
+		return nil, err
+	}
+	version, err := m.Versioner.ResourceVersion(current)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := m.Codec.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Versioner.SetResourceVersion(obj, version); err != nil {
+		return nil, err
+	}
+	if data, err = m.Codec.Encode(obj); err != nil {
+		return nil, err
+	}
+	return m.replaceResource(m.RESTClient, m.Resource, namespace, name, data)
+}
+
+// DeleteCollection deletes the resources in namespace matching the selectors with a
+// single request to the collection. Servers that do not support deleting collections
+// reject that request, in which case the matching resources are listed and deleted
+// one at a time. Resources that are already gone by the time they are deleted are
+// ignored, and the errors deleting the others are aggregated.
+func (m *Helper) DeleteCollection(namespace string, labelSelector labels.Selector, fieldSelector fields.Selector) error {
+	err := m.RESTClient.Delete().
+		NamespaceIfScoped(namespace, m.NamespaceScoped).
+		Resource(m.Resource).
+		LabelsSelectorParam(labelSelector).
+		FieldsSelectorParam(fieldSelector).
 		Do().
 		Error()
+	if !errors.IsMethodNotSupported(err) {
+		return err
+	}
+
+	list, err := m.List(namespace, "", labelSelector, fieldSelector)
+	if err != nil {
+		return err
+	}
+	items, err := runtime.ExtractList(list)
+	if err != nil {
+		return fmt.Errorf("expected a list of %s, but the server returned %T: %v", m.Resource, list, err)
+	}
+	errs := []error{}
+	for _, item := range items {
+		accessor, err := meta.Accessor(item)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := m.Delete(accessor.Namespace(), accessor.Name()); err != nil && !errors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
 }
 
 func (m *Helper) Create(namespace string, modify bool, data []byte) (runtime.Object, error) {
@@ -130,19 +1062,183 @@ func (m *Helper) Create(namespace string, modify bool, data []byte) (runtime.Obj
 	return m.createResource(m.RESTClient, m.Resource, namespace, data)
 }
 
+// CreateAndGetName creates the resource from data as is and returns the name the
+// server assigned to it. This is primarily useful for objects that set
+// metadata.generateName, whose final name is only known once the server responds,
+// so the name is always read back from the decoded result.
+func (m *Helper) CreateAndGetName(namespace string, data []byte) (string, runtime.Object, error) {
+	obj, err := m.Create(namespace, false, data)
+	if err != nil {
+		return "", nil, err
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return "", obj, fmt.Errorf("unable to read the name of the created %s: %v", m.Resource, err)
+	}
+	name := accessor.Name()
+	if len(name) == 0 {
+		return "", obj, fmt.Errorf("the server did not return a name for the created %s", m.Resource)
+	}
+	return name, obj, nil
+}
+
+// UpdateStrategy selects how CreateOrUpdate updates an object that already exists.
+type UpdateStrategy string
+
+const (
+	// UpdateByReplace replaces the existing object with the given one. The replace is
+	// made at the resource version of the given object or, if it has none, at the
+	// version of the existing object, so it fails with a conflict if the object was
+	// changed in the meantime.
+	UpdateByReplace UpdateStrategy = "Replace"
+	// UpdateByPatch sends the given object as a strategic merge patch, so the fields
+	// it does not set keep their current values. The patch is only checked against a
+	// resource version if the given object has one.
+	UpdateByPatch UpdateStrategy = "Patch"
+)
+
+// CreateOrUpdate creates the object in data or, if an object of the same name already
+// exists, updates that object with strategy instead. It returns the object stored by
+// the server and whether it was created. A conflict while updating is returned to the
+// caller rather than retried, and so is the object being deleted between the create
+// and the update.
+func (m *Helper) CreateOrUpdate(namespace string, data []byte, strategy UpdateStrategy) (runtime.Object, bool, error) {
+	if strategy != UpdateByReplace && strategy != UpdateByPatch {
+		return nil, false, fmt.Errorf("unknown update strategy %q", strategy)
+	}
+	obj, err := m.Create(namespace, true, data)
+	if err == nil {
+		return obj, true, nil
+	}
+	if !errors.IsAlreadyExists(err) {
+		return nil, false, err
+	}
+
+	desired, err := m.Codec.Decode(data)
+	if err != nil {
+		return nil, false, err
+	}
+	accessor, err := meta.Accessor(desired)
+	if err != nil {
+		return nil, false, err
+	}
+	if strategy == UpdateByReplace {
+		obj, err = m.Replace(namespace, accessor.Name(), true, data)
+	} else {
+		obj, err = m.Patch(namespace, accessor.Name(), api.StrategicMergePatchType, data)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return obj, false, nil
+}
+
 func (m *Helper) createResource(c RESTClient, resource, namespace string, data []byte) (runtime.Object, error) {
+	if err := ValidateSchema(data, m.Schema); err != nil {
+		return nil, err
+	}
 	return c.Post().NamespaceIfScoped(namespace, m.NamespaceScoped).Resource(resource).Body(data).Do().Get()
 }
+
 func (m *Helper) Patch(namespace, name string, pt api.PatchType, data []byte) (runtime.Object, error) {
 	return m.RESTClient.Patch(pt).
 		NamespaceIfScoped(namespace, m.NamespaceScoped).
 		Resource(m.Resource).
 		Name(name).
+		SubResource(m.Subresource).
 		Body(data).
 		Do().
 		Get()
 }
 
+// LastAppliedConfigAnnotation is the annotation in which Apply records the
+// configuration it last applied to an object.
+const LastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// Apply updates the named resource to match the configuration in data, creating it
+// if it does not exist. The configuration is recorded on the object, and the next
+// Apply sends a three-way strategic merge patch computed from the recorded
+// configuration, the live object, and the new configuration. Fields that were
+// removed from the configuration are removed from the object, while fields that
+// were never part of it, such as those set by the server or by other clients, are
+// preserved.
+func (m *Helper) Apply(namespace, name string, data []byte) (runtime.Object, error) {
+	obj, err := m.Codec.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	// The recorded configuration must not include a previously recorded one.
+	if err := setLastAppliedConfig(obj, ""); err != nil {
+		return nil, err
+	}
+	config, err := m.Codec.Encode(obj)
+	if err != nil {
+		return nil, err
+	}
+	if err := setLastAppliedConfig(obj, string(config)); err != nil {
+		return nil, err
+	}
+	modified, err := m.Codec.Encode(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := m.get(namespace, name)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return m.createResource(m.RESTClient, m.Resource, namespace, modified)
+		}
+		return nil, err
+	}
+	currentAccessor, err := meta.Accessor(current)
+	if err != nil {
+		return nil, err
+	}
+	original := []byte(currentAccessor.Annotations()[LastAppliedConfigAnnotation])
+	currentData, err := m.Codec.Encode(current)
+	if err != nil {
+		return nil, err
+	}
+
+	versioned, err := versionedType(modified)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := strategicpatch.CreateThreeWayMergePatch(original, modified, currentData, versioned)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compute the patch for %s %q: %v", m.Resource, name, err)
+	}
+	return m.Patch(namespace, name, api.StrategicMergePatchType, patch)
+}
+
+// setLastAppliedConfig records config as the last applied configuration of obj, or
+// removes the record if config is empty.
+func setLastAppliedConfig(obj runtime.Object, config string) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	annotations := accessor.Annotations()
+	if len(config) == 0 {
+		if _, found := annotations[LastAppliedConfigAnnotation]; !found {
+			return nil
+		}
+		// the annotations of some objects, such as runtime.Unstructured, are copies
+		delete(annotations, LastAppliedConfigAnnotation)
+		if len(annotations) == 0 {
+			annotations = nil
+		}
+		accessor.SetAnnotations(annotations)
+		return nil
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[LastAppliedConfigAnnotation] = config
+	accessor.SetAnnotations(annotations)
+	return nil
+}
+
 func (m *Helper) Replace(namespace, name string, overwrite bool, data []byte) (runtime.Object, error) {
 	c := m.RESTClient
 
@@ -160,7 +1256,7 @@ func (m *Helper) Replace(namespace, name string, overwrite bool, data []byte) (r
 	}
 	if version == "" && overwrite {
 		// Retrieve the current version of the object to overwrite the server object
-		serverObj, err := c.Get().Namespace(namespace).Resource(m.Resource).Name(name).Do().Get()
+		serverObj, err := c.Get().Namespace(namespace).Resource(m.Resource).Name(name).SubResource(m.Subresource).Do().Get()
 		if err != nil {
 			// The object does not exist, but we want it to be created
 			return m.replaceResource(c, m.Resource, namespace, name, data)
@@ -182,6 +1278,40 @@ func (m *Helper) Replace(namespace, name string, overwrite bool, data []byte) (r
 	return m.replaceResource(c, m.Resource, namespace, name, data)
 }
 
+// replaceRetryDelay is how long ReplaceWithRetry waits before its first retry. The
+// delay doubles after every subsequent conflict.
+var replaceRetryDelay = 10 * time.Millisecond
+
+// ReplaceWithRetry fetches the named resource, applies mutate to it, and replaces it
+// on the server. If the replace fails because the resource was changed since it was
+// fetched, the whole sequence is repeated with backoff, at most retries more times.
+// Errors from mutate are returned without retrying.
+func (m *Helper) ReplaceWithRetry(namespace, name string, mutate func(obj runtime.Object) error, retries int) (runtime.Object, error) {
+	delay := replaceRetryDelay
+	for attempt := 0; ; attempt++ {
+		obj, err := m.get(namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		if err := mutate(obj); err != nil {
+			return nil, err
+		}
+		data, err := m.Codec.Encode(obj)
+		if err != nil {
+			return nil, err
+		}
+		result, err := m.replaceResource(m.RESTClient, m.Resource, namespace, name, data)
+		if err == nil || !errors.IsConflict(err) || attempt >= retries {
+			return result, err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
 func (m *Helper) replaceResource(c RESTClient, resource, namespace, name string, data []byte) (runtime.Object, error) {
-	return c.Put().NamespaceIfScoped(namespace, m.NamespaceScoped).Resource(resource).Name(name).Body(data).Do().Get()
+	if err := ValidateSchema(data, m.Schema); err != nil {
+		return nil, err
+	}
+	return c.Put().NamespaceIfScoped(namespace, m.NamespaceScoped).Resource(resource).Name(name).SubResource(m.Subresource).Body(data).Do().Get()
 }
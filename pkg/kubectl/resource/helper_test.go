@@ -18,19 +18,35 @@ package resource
 
 import (
 	"bytes"
+	"compress/gzip"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
+
+	"golang.org/x/net/context"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	apierrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/meta"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/testapi"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/v1beta3"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/strategicpatch"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 )
 
 func objBody(obj runtime.Object) io.ReadCloser {
@@ -114,6 +130,153 @@ func TestHelperDelete(t *testing.T) {
 	}
 }
 
+func TestHelperIgnoreNotFound(t *testing.T) {
+	notFound := func() *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusNotFound,
+			Body:       objBody(&api.Status{Status: api.StatusFailure, Code: http.StatusNotFound}),
+		}
+	}
+	for _, ignore := range []bool{false, true} {
+		modifier := &Helper{
+			RESTClient:      &client.FakeRESTClient{Codec: testapi.Codec(), Resp: notFound()},
+			NamespaceScoped: true,
+			IgnoreNotFound:  ignore,
+		}
+		obj, err := modifier.Get("bar", "foo")
+		if obj != nil || (err != nil) == ignore {
+			t.Errorf("%t: unexpected Get response: %#v %v", ignore, obj, err)
+		}
+		modifier.RESTClient = &client.FakeRESTClient{Codec: testapi.Codec(), Resp: notFound()}
+		if err := modifier.Delete("bar", "foo"); (err != nil) == ignore {
+			t.Errorf("%t: unexpected Delete error: %v", ignore, err)
+		}
+	}
+
+	modifier := &Helper{
+		RESTClient: &client.FakeRESTClient{Codec: testapi.Codec(), Resp: &http.Response{
+			StatusCode: http.StatusForbidden,
+			Body:       objBody(&api.Status{Status: api.StatusFailure, Code: http.StatusForbidden}),
+		}},
+		NamespaceScoped: true,
+		IgnoreNotFound:  true,
+	}
+	if err := modifier.Delete("bar", "foo"); err == nil {
+		t.Errorf("expected errors other than not found to be returned")
+	}
+}
+
+func TestHelperDeleteWithOptions(t *testing.T) {
+	tests := []struct {
+		Options *api.DeleteOptions
+	}{
+		{},
+		{Options: api.NewDeleteOptions(0)},
+		{Options: api.NewDeleteOptions(30)},
+	}
+	for i, test := range tests {
+		client := &client.FakeRESTClient{
+			Codec: testapi.Codec(),
+			Resp: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       objBody(&api.Status{Status: api.StatusSuccess}),
+			},
+		}
+		modifier := &Helper{
+			RESTClient:      client,
+			NamespaceScoped: true,
+		}
+		if err := modifier.DeleteWithOptions("bar", "foo", test.Options); err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+		if client.Req.Method != "DELETE" {
+			t.Errorf("%d: unexpected method: %#v", i, client.Req)
+		}
+		if test.Options == nil {
+			if client.Req.Body != nil {
+				t.Errorf("%d: unexpected body: %#v", i, client.Req.Body)
+			}
+			continue
+		}
+		body, err := ioutil.ReadAll(client.Req.Body)
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+		options := &api.DeleteOptions{}
+		if err := testapi.Codec().DecodeInto(body, options); err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+		if !reflect.DeepEqual(options, test.Options) {
+			t.Errorf("%d: unexpected options: %#v", i, options)
+		}
+	}
+}
+
+func TestHelperDeleteCollection(t *testing.T) {
+	tests := []struct {
+		CollectionStatus int
+		Requests         []string
+		Err              bool
+	}{
+		{
+			CollectionStatus: http.StatusOK,
+			Requests:         []string{"DELETE /namespaces/bar/pods"},
+		},
+		{
+			CollectionStatus: http.StatusMethodNotAllowed,
+			Requests: []string{
+				"DELETE /namespaces/bar/pods",
+				"GET /namespaces/bar/pods",
+				"DELETE /namespaces/bar/pods/foo",
+				"DELETE /namespaces/bar/pods/gone",
+				"DELETE /namespaces/bar/pods/baz",
+			},
+		},
+		{
+			CollectionStatus: http.StatusForbidden,
+			Requests:         []string{"DELETE /namespaces/bar/pods"},
+			Err:              true,
+		},
+	}
+	for i, test := range tests {
+		requests := []string{}
+		client := &client.FakeRESTClient{
+			Codec: testapi.Codec(),
+			Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+				requests = append(requests, req.Method+" "+req.URL.Path)
+				switch {
+				case req.Method == "DELETE" && req.URL.Path == "/namespaces/bar/pods":
+					if req.URL.Query().Get(api.LabelSelectorQueryParam(testapi.Version())) != "app=foo" {
+						t.Errorf("%d: expected a label selector: %#v", i, req.URL)
+					}
+					return &http.Response{StatusCode: test.CollectionStatus, Body: objBody(&api.Status{Code: test.CollectionStatus})}, nil
+				case req.Method == "GET":
+					return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.PodList{Items: []api.Pod{
+						{ObjectMeta: api.ObjectMeta{Name: "foo", Namespace: "bar"}},
+						{ObjectMeta: api.ObjectMeta{Name: "gone", Namespace: "bar"}},
+						{ObjectMeta: api.ObjectMeta{Name: "baz", Namespace: "bar"}},
+					}})}, nil
+				case req.Method == "DELETE" && strings.HasSuffix(req.URL.Path, "/gone"):
+					return &http.Response{StatusCode: http.StatusNotFound, Body: objBody(&api.Status{Code: http.StatusNotFound})}, nil
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Status{Status: api.StatusSuccess})}, nil
+			}),
+		}
+		modifier := &Helper{
+			RESTClient:      client,
+			Resource:        "pods",
+			NamespaceScoped: true,
+		}
+		err := modifier.DeleteCollection("bar", labels.SelectorFromSet(labels.Set{"app": "foo"}), fields.Everything())
+		if test.Err != (err != nil) {
+			t.Errorf("%d: unexpected error: %v", i, err)
+		}
+		if !reflect.DeepEqual(requests, test.Requests) {
+			t.Errorf("%d: unexpected requests: %v", i, requests)
+		}
+	}
+}
+
 func TestHelperCreate(t *testing.T) {
 	expectPost := func(req *http.Request) bool {
 		if req.Method != "POST" {
@@ -225,74 +388,104 @@ func TestHelperCreate(t *testing.T) {
 	}
 }
 
-func TestHelperGet(t *testing.T) {
+func TestHelperCreateAndGetName(t *testing.T) {
 	tests := []struct {
-		Err     bool
-		Req     func(*http.Request) bool
-		Resp    *http.Response
-		HttpErr error
+		Resp *http.Response
+
+		ExpectName string
+		Err        bool
 	}{
 		{
-			HttpErr: errors.New("failure"),
-			Err:     true,
+			Resp: &http.Response{
+				StatusCode: http.StatusCreated,
+				Body:       objBody(&api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo-abcde", GenerateName: "foo-"}}),
+			},
+			ExpectName: "foo-abcde",
 		},
 		{
 			Resp: &http.Response{
-				StatusCode: http.StatusNotFound,
-				Body:       objBody(&api.Status{Status: api.StatusFailure}),
+				StatusCode: http.StatusCreated,
+				Body:       objBody(&api.Pod{ObjectMeta: api.ObjectMeta{GenerateName: "foo-"}}),
 			},
 			Err: true,
 		},
 		{
 			Resp: &http.Response{
-				StatusCode: http.StatusOK,
-				Body:       objBody(&api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}}),
-			},
-			Req: func(req *http.Request) bool {
-				if req.Method != "GET" {
-					t.Errorf("unexpected method: %#v", req)
-					return false
-				}
-				parts := splitPath(req.URL.Path)
-				if parts[1] != "bar" {
-					t.Errorf("url doesn't contain namespace: %#v", req)
-					return false
-				}
-				if parts[2] != "foo" {
-					t.Errorf("url doesn't contain name: %#v", req)
-					return false
-				}
-				return true
+				StatusCode: http.StatusConflict,
+				Body:       objBody(&api.Status{Status: api.StatusFailure, Reason: api.StatusReasonAlreadyExists}),
 			},
+			Err: true,
 		},
 	}
-	for _, test := range tests {
+	for i, test := range tests {
 		client := &client.FakeRESTClient{
 			Codec: testapi.Codec(),
 			Resp:  test.Resp,
-			Err:   test.HttpErr,
 		}
 		modifier := &Helper{
 			RESTClient:      client,
+			Codec:           testapi.Codec(),
+			Versioner:       testapi.MetadataAccessor(),
 			NamespaceScoped: true,
 		}
-		obj, err := modifier.Get("bar", "foo")
+		data := []byte(runtime.EncodeOrDie(testapi.Codec(), &api.Pod{ObjectMeta: api.ObjectMeta{GenerateName: "foo-"}}))
+		name, _, err := modifier.CreateAndGetName("bar", data)
 		if (err != nil) != test.Err {
-			t.Errorf("unexpected error: %t %v", test.Err, err)
-		}
-		if err != nil {
-			continue
+			t.Errorf("%d: unexpected error: %t %v", i, test.Err, err)
 		}
-		if obj.(*api.Pod).Name != "foo" {
-			t.Errorf("unexpected object: %#v", obj)
+		if name != test.ExpectName {
+			t.Errorf("%d: unexpected name: %q", i, name)
 		}
-		if test.Req != nil && !test.Req(client.Req) {
-			t.Errorf("unexpected request: %#v", client.Req)
+		if client.Req.Method != "POST" {
+			t.Errorf("%d: unexpected method: %#v", i, client.Req)
 		}
 	}
 }
 
-func TestHelperList(t *testing.T) {
+type rejectSchema struct{}
+
+func (rejectSchema) ValidateBytes(data []byte) error {
+	return errors.New("field spec.replica: unknown field")
+}
+
+func TestHelperSchemaValidation(t *testing.T) {
+	requests := 0
+	client := &client.FakeRESTClient{
+		Codec: testapi.Codec(),
+		Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			requests++
+			return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}})}, nil
+		}),
+	}
+	modifier := &Helper{
+		RESTClient:      client,
+		Codec:           testapi.Codec(),
+		Versioner:       testapi.MetadataAccessor(),
+		NamespaceScoped: true,
+		Schema:          rejectSchema{},
+	}
+	data := []byte(runtime.EncodeOrDie(testapi.Codec(), &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}}))
+
+	if _, err := modifier.Create("bar", false, data); err == nil || !strings.Contains(err.Error(), "spec.replica") {
+		t.Errorf("expected a validation error from Create, got %v", err)
+	}
+	if _, err := modifier.Replace("bar", "foo", false, data); err == nil || !strings.Contains(err.Error(), "spec.replica") {
+		t.Errorf("expected a validation error from Replace, got %v", err)
+	}
+	if requests != 0 {
+		t.Errorf("expected no requests to be sent, got %d", requests)
+	}
+
+	modifier.Schema = nil
+	if _, err := modifier.Create("bar", false, data); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected the object to be sent without a schema, got %d requests", requests)
+	}
+}
+
+func TestHelperGet(t *testing.T) {
 	tests := []struct {
 		Err     bool
 		Req     func(*http.Request) bool
@@ -313,24 +506,20 @@ func TestHelperList(t *testing.T) {
 		{
 			Resp: &http.Response{
 				StatusCode: http.StatusOK,
-				Body: objBody(&api.PodList{
-					Items: []api.Pod{{
-						ObjectMeta: api.ObjectMeta{Name: "foo"},
-					},
-					},
-				}),
+				Body:       objBody(&api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}}),
 			},
 			Req: func(req *http.Request) bool {
 				if req.Method != "GET" {
 					t.Errorf("unexpected method: %#v", req)
 					return false
 				}
-				if req.URL.Path != "/namespaces/bar" {
-					t.Errorf("url doesn't contain name: %#v", req.URL)
+				parts := splitPath(req.URL.Path)
+				if parts[1] != "bar" {
+					t.Errorf("url doesn't contain namespace: %#v", req)
 					return false
 				}
-				if req.URL.Query().Get(api.LabelSelectorQueryParam(testapi.Version())) != labels.SelectorFromSet(labels.Set{"foo": "baz"}).String() {
-					t.Errorf("url doesn't contain query parameters: %#v", req.URL)
+				if parts[2] != "foo" {
+					t.Errorf("url doesn't contain name: %#v", req)
 					return false
 				}
 				return true
@@ -347,14 +536,14 @@ func TestHelperList(t *testing.T) {
 			RESTClient:      client,
 			NamespaceScoped: true,
 		}
-		obj, err := modifier.List("bar", testapi.Version(), labels.SelectorFromSet(labels.Set{"foo": "baz"}))
+		obj, err := modifier.Get("bar", "foo")
 		if (err != nil) != test.Err {
 			t.Errorf("unexpected error: %t %v", test.Err, err)
 		}
 		if err != nil {
 			continue
 		}
-		if obj.(*api.PodList).Items[0].Name != "foo" {
+		if obj.(*api.Pod).Name != "foo" {
 			t.Errorf("unexpected object: %#v", obj)
 		}
 		if test.Req != nil && !test.Req(client.Req) {
@@ -363,121 +552,1798 @@ func TestHelperList(t *testing.T) {
 	}
 }
 
-func TestHelperReplace(t *testing.T) {
-	expectPut := func(req *http.Request) bool {
-		if req.Method != "PUT" {
-			t.Errorf("unexpected method: %#v", req)
-			return false
-		}
-		parts := splitPath(req.URL.Path)
-		if parts[1] != "bar" {
-			t.Errorf("url doesn't contain namespace: %#v", req.URL)
-			return false
-		}
-		if parts[2] != "foo" {
-			t.Errorf("url doesn't contain name: %#v", req)
-			return false
-		}
-		return true
+func TestHelperExport(t *testing.T) {
+	client := &client.FakeRESTClient{
+		Codec: testapi.Codec(),
+		Resp: &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Pod{
+			ObjectMeta: api.ObjectMeta{
+				Name:              "foo",
+				Namespace:         "bar",
+				Labels:            map[string]string{"app": "web"},
+				ResourceVersion:   "10",
+				UID:               "uid",
+				SelfLink:          "/api/v1/namespaces/bar/pods/foo",
+				CreationTimestamp: util.Now(),
+			},
+			Spec:   api.PodSpec{NodeName: "node"},
+			Status: api.PodStatus{Phase: api.PodRunning, PodIP: "10.0.0.1"},
+		})},
+	}
+	modifier := &Helper{
+		RESTClient:      client,
+		NamespaceScoped: true,
+	}
+	obj, err := modifier.Export("bar", "foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := &api.Pod{
+		ObjectMeta: api.ObjectMeta{
+			Name:      "foo",
+			Namespace: "bar",
+			Labels:    map[string]string{"app": "web"},
+		},
+		Spec: api.PodSpec{
+			NodeName:      "node",
+			RestartPolicy: api.RestartPolicyAlways,
+			DNSPolicy:     api.DNSClusterFirst,
+		},
+	}
+	if !api.Semantic.DeepEqual(expected, obj) {
+		t.Errorf("unexpected object: %#v", obj)
 	}
+}
 
+func TestHelperGetIfNewer(t *testing.T) {
 	tests := []struct {
-		Resp      *http.Response
-		RespFunc  client.HTTPClientFunc
-		HttpErr   error
-		Overwrite bool
-		Object    runtime.Object
+		ResourceVersion string
+		Resp            *http.Response
 
-		ExpectObject runtime.Object
-		Err          bool
-		Req          func(*http.Request) bool
+		Changed   bool
+		Object    bool
+		NotFound  bool
+		Err       bool
+		IfNoneTag string
 	}{
 		{
-			HttpErr: errors.New("failure"),
-			Err:     true,
+			ResourceVersion: "10",
+			Resp: &http.Response{
+				StatusCode: http.StatusNotModified,
+				Header:     http.Header{},
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte{})),
+			},
+			IfNoneTag: `"10"`,
 		},
 		{
-			Object: &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}},
+			ResourceVersion: "10",
 			Resp: &http.Response{
-				StatusCode: http.StatusNotFound,
-				Body:       objBody(&api.Status{Status: api.StatusFailure}),
+				StatusCode: http.StatusOK,
+				Body:       objBody(&api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", ResourceVersion: "10"}}),
 			},
-			Err: true,
+			IfNoneTag: `"10"`,
 		},
 		{
-			Object:       &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}},
-			ExpectObject: &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}},
+			ResourceVersion: "10",
 			Resp: &http.Response{
 				StatusCode: http.StatusOK,
-				Body:       objBody(&api.Status{Status: api.StatusSuccess}),
+				Body:       objBody(&api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", ResourceVersion: "11"}}),
 			},
-			Req: expectPut,
+			Changed:   true,
+			Object:    true,
+			IfNoneTag: `"10"`,
 		},
 		{
-			Object: &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}},
-			ExpectObject: &api.Pod{
-				ObjectMeta: api.ObjectMeta{Name: "foo", ResourceVersion: "10"},
-				Spec: api.PodSpec{
-					RestartPolicy: api.RestartPolicyAlways,
-					DNSPolicy:     api.DNSClusterFirst,
-				},
-			},
-			Overwrite: true,
-			RespFunc: func(req *http.Request) (*http.Response, error) {
-				if req.Method == "PUT" {
-					return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Status{Status: api.StatusSuccess})}, nil
-				}
-				return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", ResourceVersion: "10"}})}, nil
+			Resp: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       objBody(&api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", ResourceVersion: "11"}}),
 			},
-			Req: expectPut,
+			Changed: true,
+			Object:  true,
 		},
 		{
-			Object:       &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", ResourceVersion: "10"}},
-			ExpectObject: &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", ResourceVersion: "10"}},
-			Resp:         &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Status{Status: api.StatusSuccess})},
-			Req:          expectPut,
+			ResourceVersion: "10",
+			Resp: &http.Response{
+				StatusCode: http.StatusNotFound,
+				Body:       objBody(&api.Status{Status: api.StatusFailure, Reason: api.StatusReasonNotFound}),
+			},
+			Err:       true,
+			NotFound:  true,
+			IfNoneTag: `"10"`,
 		},
 	}
 	for i, test := range tests {
 		client := &client.FakeRESTClient{
 			Codec: testapi.Codec(),
 			Resp:  test.Resp,
-			Err:   test.HttpErr,
-		}
-		if test.RespFunc != nil {
-			client.Client = test.RespFunc
 		}
 		modifier := &Helper{
 			RESTClient:      client,
-			Codec:           testapi.Codec(),
 			Versioner:       testapi.MetadataAccessor(),
 			NamespaceScoped: true,
 		}
-		data := []byte{}
-		if test.Object != nil {
-			data = []byte(runtime.EncodeOrDie(testapi.Codec(), test.Object))
-		}
-		_, err := modifier.Replace("bar", "foo", test.Overwrite, data)
+		obj, changed, err := modifier.GetIfNewer("bar", "foo", test.ResourceVersion)
 		if (err != nil) != test.Err {
 			t.Errorf("%d: unexpected error: %t %v", i, test.Err, err)
 		}
-		if err != nil {
-			continue
-		}
-		if test.Req != nil && !test.Req(client.Req) {
-			t.Errorf("%d: unexpected request: %#v", i, client.Req)
+		if apierrors.IsNotFound(err) != test.NotFound {
+			t.Errorf("%d: unexpected not found error: %v", i, err)
 		}
-		body, err := ioutil.ReadAll(client.Req.Body)
-		if err != nil {
-			t.Fatalf("%d: unexpected error: %#v", i, err)
+		if changed != test.Changed {
+			t.Errorf("%d: expected changed %t", i, test.Changed)
 		}
-		t.Logf("got body: %s", string(body))
-		expect := []byte{}
-		if test.ExpectObject != nil {
-			expect = []byte(runtime.EncodeOrDie(testapi.Codec(), test.ExpectObject))
+		if (obj != nil) != test.Object {
+			t.Errorf("%d: unexpected object: %#v", i, obj)
 		}
-		if !reflect.DeepEqual(expect, body) {
-			t.Errorf("%d: unexpected body: %s", i, string(body))
+		if tag := client.Req.Header.Get("If-None-Match"); tag != test.IfNoneTag {
+			t.Errorf("%d: unexpected If-None-Match header: %s", i, tag)
 		}
 	}
 }
+
+func TestHelperDeprecationInfo(t *testing.T) {
+	tests := []struct {
+		Warnings []string
+		Resp     *http.Response
+
+		Deprecated  bool
+		RemovedIn   string
+		Replacement string
+		Err         bool
+	}{
+		{},
+		{
+			Warnings: []string{`299 - "unrelated warning"`},
+		},
+		{
+			Warnings:    []string{`299 - "v1beta3 Pod is deprecated in v0.20+, unavailable in v1.0+; use v1 Pod"`},
+			Deprecated:  true,
+			RemovedIn:   "v1.0",
+			Replacement: "v1 Pod",
+		},
+		{
+			Warnings:   []string{`299 - "this version is deprecated"`},
+			Deprecated: true,
+		},
+		{
+			Resp: &http.Response{
+				StatusCode: http.StatusNotFound,
+				Body:       objBody(&api.Status{Status: api.StatusFailure}),
+			},
+			Err: true,
+		},
+	}
+	for i, test := range tests {
+		resp := test.Resp
+		if resp == nil {
+			resp = &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Warning": test.Warnings},
+				Body:       objBody(&api.PodList{}),
+			}
+		}
+		client := &client.FakeRESTClient{
+			Codec: testapi.Codec(),
+			Resp:  resp,
+		}
+		modifier := &Helper{
+			RESTClient:      client,
+			Resource:        "pods",
+			NamespaceScoped: true,
+		}
+		deprecated, removedIn, replacement, err := modifier.DeprecationInfo()
+		if (err != nil) != test.Err {
+			t.Errorf("%d: unexpected error: %t %v", i, test.Err, err)
+		}
+		if limit := client.Req.URL.Query().Get("limit"); limit != "1" {
+			t.Errorf("%d: expected a single item to be requested, got limit %q", i, limit)
+		}
+		if deprecated != test.Deprecated || removedIn != test.RemovedIn || replacement != test.Replacement {
+			t.Errorf("%d: unexpected deprecation info: %t %q %q", i, deprecated, removedIn, replacement)
+		}
+	}
+}
+
+func TestHelperWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	client := &client.FakeRESTClient{
+		Codec: testapi.Codec(),
+		Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}})}, nil
+		}),
+	}
+	modifier := (&Helper{
+		RESTClient:      client,
+		NamespaceScoped: true,
+	}).WithContext(ctx)
+	if _, err := modifier.Get("bar", "foo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cancel()
+	client.Req = nil
+	if _, err := modifier.Get("bar", "foo"); err != context.Canceled {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := modifier.List("bar", testapi.Version(), labels.Everything(), fields.Everything()); err != context.Canceled {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := modifier.Watch("bar", "", testapi.Version(), labels.Everything(), fields.Everything()); err != context.Canceled {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if client.Req != nil {
+		t.Errorf("a cancelled request should not be sent: %#v", client.Req)
+	}
+}
+
+func TestHelperWithRetry(t *testing.T) {
+	attempts := 0
+	client := &client.FakeRESTClient{
+		Codec: testapi.Codec(),
+		Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			if attempts < 3 {
+				return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: objBody(&api.Status{Status: api.StatusFailure})}, nil
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}})}, nil
+		}),
+	}
+	modifier := (&Helper{
+		RESTClient:      client,
+		NamespaceScoped: true,
+	}).WithRetry(2, 0)
+	obj, err := modifier.Get("bar", "foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if obj.(*api.Pod).Name != "foo" || attempts != 3 {
+		t.Errorf("unexpected result after %d attempts: %#v", attempts, obj)
+	}
+
+	attempts = 0
+	if _, err := (&Helper{RESTClient: client, NamespaceScoped: true}).Get("bar", "foo"); err == nil || attempts != 1 {
+		t.Errorf("requests should not be retried by default: %d attempts, %v", attempts, err)
+	}
+}
+
+func TestHelperCompression(t *testing.T) {
+	list := &api.PodList{Items: []api.Pod{{ObjectMeta: api.ObjectMeta{Name: "foo"}}, {ObjectMeta: api.ObjectMeta{Name: "bar"}}}}
+	for _, disabled := range []bool{false, true} {
+		encodings := []string{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			encoding := req.Header.Get("Accept-Encoding")
+			encodings = append(encodings, encoding)
+			data := []byte(runtime.EncodeOrDie(testapi.Codec(), list))
+			if !strings.Contains(encoding, "gzip") {
+				w.Write(data)
+				return
+			}
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			gz.Write(data)
+			gz.Close()
+		}))
+		serverURL, _ := url.Parse(server.URL)
+		modifier := &Helper{
+			RESTClient:         client.NewRESTClient(serverURL, testapi.Version(), testapi.Codec(), 0, 0),
+			Resource:           "pods",
+			Codec:              testapi.Codec(),
+			NamespaceScoped:    true,
+			DisableCompression: disabled,
+		}
+
+		obj, err := modifier.List("bar", testapi.Version(), labels.Everything(), fields.Everything())
+		if err != nil {
+			t.Fatalf("%t: unexpected error: %v", disabled, err)
+		}
+		if items := obj.(*api.PodList).Items; len(items) != 2 || items[1].Name != "bar" {
+			t.Errorf("%t: unexpected list: %#v", disabled, obj)
+		}
+		names := []string{}
+		err = modifier.ListStream("bar", testapi.Version(), labels.Everything(), fields.Everything(), func(item runtime.Object) error {
+			names = append(names, item.(*api.Pod).Name)
+			return nil
+		})
+		if err != nil || !reflect.DeepEqual(names, []string{"foo", "bar"}) {
+			t.Errorf("%t: unexpected items: %v %v", disabled, names, err)
+		}
+		server.Close()
+
+		expected := []string{"gzip", "gzip"}
+		if disabled {
+			expected = []string{"identity", "identity"}
+		}
+		if !reflect.DeepEqual(encodings, expected) {
+			t.Errorf("%t: unexpected Accept-Encoding headers: %v", disabled, encodings)
+		}
+	}
+}
+
+func TestHelperReadResourceVersion(t *testing.T) {
+	pod := &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", Namespace: "bar"}}
+	list := &api.PodList{Items: []api.Pod{*pod}}
+	tests := []struct {
+		ReadResourceVersion string
+		Expected            []string
+	}{
+		{"", []string{"", "", ""}},
+		{AnyResourceVersion, []string{"0", "0", "0"}},
+		{"10", []string{"10", "10", "10"}},
+	}
+	for i, test := range tests {
+		versions := []string{}
+		client := &client.FakeRESTClient{
+			Codec: testapi.Codec(),
+			Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+				versions = append(versions, req.URL.Query().Get("resourceVersion"))
+				var obj runtime.Object = list
+				if strings.HasSuffix(req.URL.Path, "/foo") {
+					obj = pod
+				}
+				return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: objBody(obj)}, nil
+			}),
+		}
+		modifier := (&Helper{
+			RESTClient:      client,
+			Resource:        "pods",
+			Codec:           testapi.Codec(),
+			NamespaceScoped: true,
+		}).WithReadResourceVersion(test.ReadResourceVersion)
+
+		if _, err := modifier.Get("bar", "foo"); err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+		if _, err := modifier.List("bar", testapi.Version(), labels.Everything(), fields.Everything()); err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+		err := modifier.ListStream("bar", testapi.Version(), labels.Everything(), fields.Everything(), func(runtime.Object) error { return nil })
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+		if !reflect.DeepEqual(versions, test.Expected) {
+			t.Errorf("%d: expected resource versions %v, got %v", i, test.Expected, versions)
+		}
+	}
+}
+
+func TestHelperReadResourceVersionContinue(t *testing.T) {
+	versions := []string{}
+	client := &client.FakeRESTClient{
+		Codec: testapi.Codec(),
+		Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			versions = append(versions, req.URL.Query().Get("resourceVersion"))
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: objBody(&api.PodList{})}, nil
+		}),
+	}
+	modifier := &Helper{
+		RESTClient:          client,
+		Resource:            "pods",
+		Codec:               testapi.Codec(),
+		NamespaceScoped:     true,
+		ReadResourceVersion: AnyResourceVersion,
+	}
+	if _, _, err := modifier.ListPage("bar", testapi.Version(), labels.Everything(), fields.Everything(), 10, "token"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(versions, []string{""}) {
+		t.Errorf("expected no resource version with a continue token, got %v", versions)
+	}
+}
+
+func TestHelperOutputVersion(t *testing.T) {
+	pod := &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", Namespace: "bar"}}
+	list := &api.PodList{Items: []api.Pod{*pod}}
+	client := &client.FakeRESTClient{
+		Codec: testapi.Codec(),
+		Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			var obj runtime.Object = list
+			if strings.HasSuffix(req.URL.Path, "/foo") {
+				obj = pod
+			}
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: objBody(obj)}, nil
+		}),
+	}
+	modifier := (&Helper{
+		RESTClient:      client,
+		Resource:        "pods",
+		Codec:           testapi.Codec(),
+		NamespaceScoped: true,
+	}).WithOutputVersion("v1beta3")
+
+	obj, err := modifier.Get("bar", "foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if versioned, ok := obj.(*v1beta3.Pod); !ok || versioned.Name != "foo" || versioned.APIVersion != "v1beta3" {
+		t.Errorf("unexpected object: %#v", obj)
+	}
+	obj, err = modifier.List("bar", testapi.Version(), labels.Everything(), fields.Everything())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if versioned, ok := obj.(*v1beta3.PodList); !ok || len(versioned.Items) != 1 || versioned.Items[0].Name != "foo" {
+		t.Errorf("unexpected list: %#v", obj)
+	}
+	err = modifier.ListStream("bar", testapi.Version(), labels.Everything(), fields.Everything(), func(item runtime.Object) error {
+		if _, ok := item.(*v1beta3.Pod); !ok {
+			t.Errorf("unexpected item: %#v", item)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := modifier.WithOutputVersion("v0").Get("bar", "foo"); err == nil || !strings.Contains(err.Error(), `unable to convert pods to version "v0"`) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if obj, err := modifier.WithOutputVersion("").Get("bar", "foo"); err != nil || obj.(*api.Pod).Name != "foo" {
+		t.Errorf("unexpected object: %#v %v", obj, err)
+	}
+}
+
+func TestHelperWithMiddleware(t *testing.T) {
+	order := []string{}
+	trace := func(name string) Middleware {
+		return func(next client.HTTPClient) client.HTTPClient {
+			return client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next.Do(req)
+			})
+		}
+	}
+	client := &client.FakeRESTClient{
+		Codec: testapi.Codec(),
+		Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("X-Test") != "value" {
+				t.Errorf("expected the header to be set: %#v", req.Header)
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}})}, nil
+		}),
+	}
+	logged := []string{}
+	modifier := (&Helper{
+		RESTClient:      client,
+		Resource:        "pods",
+		NamespaceScoped: true,
+	}).WithMiddleware(
+		trace("outer"),
+		SetHeaders(http.Header{"X-Test": []string{"value"}}),
+		trace("inner"),
+		LogRequests(func(format string, args ...interface{}) {
+			logged = append(logged, fmt.Sprintf(format, args...))
+		}),
+	)
+	if _, err := modifier.Get("bar", "foo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(order, []string{"outer", "inner"}) {
+		t.Errorf("unexpected middleware order: %v", order)
+	}
+	if len(logged) != 1 || !strings.HasPrefix(logged[0], "GET ") || !strings.Contains(logged[0], "/namespaces/bar/pods/foo 200 in ") {
+		t.Errorf("unexpected log: %v", logged)
+	}
+}
+
+func TestHelperWithHeaders(t *testing.T) {
+	id := NewRequestID()
+	if len(id) == 0 || id == NewRequestID() {
+		t.Fatalf("expected unique request IDs, got %q", id)
+	}
+	tests := []struct {
+		Agent    string
+		Expected string
+	}{
+		// the agent the client is configured with is kept
+		{"", "configured/2.0 tool/1.0"},
+		{"custom", "custom tool/1.0"},
+		{"custom tool/1.0", "custom tool/1.0"},
+	}
+	for i, test := range tests {
+		headers := []http.Header{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			headers = append(headers, req.Header)
+			w.Write([]byte(runtime.EncodeOrDie(testapi.Codec(), &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}})))
+		}))
+		defer server.Close()
+		c, err := client.RESTClientFor(&client.Config{Host: server.URL, Version: testapi.Version(), Codec: testapi.Codec(), UserAgent: "configured/2.0"})
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+		modifier := &Helper{
+			RESTClient:      c,
+			Resource:        "pods",
+			Codec:           testapi.Codec(),
+			NamespaceScoped: true,
+		}
+		modifier = modifier.
+			WithUserAgent("tool/1.0").
+			WithRequestID(id).
+			WithHeaders(http.Header{"X-Team": []string{"platform"}})
+		if len(test.Agent) > 0 {
+			// the outermost middleware sets the header before the user agent is appended
+			modifier = modifier.WithHeaders(http.Header{"User-Agent": []string{test.Agent}})
+		}
+		if _, err := modifier.Get("bar", "foo"); err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+		if err := modifier.Delete("bar", "foo"); err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+		if len(headers) != 2 {
+			t.Fatalf("%d: unexpected requests: %v", i, headers)
+		}
+		for _, header := range headers {
+			if agent := header.Get("User-Agent"); agent != test.Expected {
+				t.Errorf("%d: expected user agent %q, got %q", i, test.Expected, agent)
+			}
+			if header.Get("X-Request-Id") != id || header.Get("X-Team") != "platform" {
+				t.Errorf("%d: unexpected headers: %v", i, header)
+			}
+		}
+	}
+}
+
+func TestHelperList(t *testing.T) {
+	tests := []struct {
+		Err     bool
+		Req     func(*http.Request) bool
+		Resp    *http.Response
+		HttpErr error
+	}{
+		{
+			HttpErr: errors.New("failure"),
+			Err:     true,
+		},
+		{
+			Resp: &http.Response{
+				StatusCode: http.StatusNotFound,
+				Body:       objBody(&api.Status{Status: api.StatusFailure}),
+			},
+			Err: true,
+		},
+		{
+			Resp: &http.Response{
+				StatusCode: http.StatusOK,
+				Body: objBody(&api.PodList{
+					Items: []api.Pod{{
+						ObjectMeta: api.ObjectMeta{Name: "foo"},
+					},
+					},
+				}),
+			},
+			Req: func(req *http.Request) bool {
+				if req.Method != "GET" {
+					t.Errorf("unexpected method: %#v", req)
+					return false
+				}
+				if req.URL.Path != "/namespaces/bar" {
+					t.Errorf("url doesn't contain name: %#v", req.URL)
+					return false
+				}
+				if req.URL.Query().Get(api.LabelSelectorQueryParam(testapi.Version())) != labels.SelectorFromSet(labels.Set{"foo": "baz"}).String() {
+					t.Errorf("url doesn't contain query parameters: %#v", req.URL)
+					return false
+				}
+				return true
+			},
+		},
+	}
+	for _, test := range tests {
+		client := &client.FakeRESTClient{
+			Codec: testapi.Codec(),
+			Resp:  test.Resp,
+			Err:   test.HttpErr,
+		}
+		modifier := &Helper{
+			RESTClient:      client,
+			NamespaceScoped: true,
+		}
+		obj, err := modifier.List("bar", testapi.Version(), labels.SelectorFromSet(labels.Set{"foo": "baz"}), fields.Everything())
+		if (err != nil) != test.Err {
+			t.Errorf("unexpected error: %t %v", test.Err, err)
+		}
+		if err != nil {
+			continue
+		}
+		if obj.(*api.PodList).Items[0].Name != "foo" {
+			t.Errorf("unexpected object: %#v", obj)
+		}
+		if test.Req != nil && !test.Req(client.Req) {
+			t.Errorf("unexpected request: %#v", client.Req)
+		}
+	}
+}
+
+func TestHelperListFieldSelector(t *testing.T) {
+	client := &client.FakeRESTClient{
+		Codec: testapi.Codec(),
+		Resp: &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       objBody(&api.PodList{Items: []api.Pod{{ObjectMeta: api.ObjectMeta{Name: "foo"}}}}),
+		},
+	}
+	modifier := &Helper{
+		RESTClient:      client,
+		Resource:        "pods",
+		NamespaceScoped: true,
+	}
+	obj, err := modifier.List("bar", testapi.Version(), labels.Everything(), fields.OneTermEqualSelector("spec.nodeName", "baz"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if obj.(*api.PodList).Items[0].Name != "foo" {
+		t.Errorf("unexpected object: %#v", obj)
+	}
+	if client.Req.URL.Path != "/namespaces/bar/pods" {
+		t.Errorf("unexpected path: %#v", client.Req.URL)
+	}
+	if selector := client.Req.URL.Query().Get(api.FieldSelectorQueryParam(testapi.Version())); selector != "spec.nodeName=baz" {
+		t.Errorf("url doesn't contain the field selector: %#v", client.Req.URL)
+	}
+}
+
+func TestHelperListAllNamespaces(t *testing.T) {
+	fooPod := api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", Namespace: "a"}}
+	barPod := api.Pod{ObjectMeta: api.ObjectMeta{Name: "bar", Namespace: "b"}}
+	tests := []struct {
+		// Fanout makes the cross-namespace request fail as servers do when they
+		// cannot apply a field selector across namespaces.
+		Fanout bool
+		Fields fields.Selector
+
+		ExpectPaths []string
+		ExpectNames []string
+		Err         bool
+	}{
+		{
+			Fields:      fields.Everything(),
+			ExpectPaths: []string{"/pods"},
+			ExpectNames: []string{"a/foo", "b/bar"},
+		},
+		{
+			Fanout:      true,
+			Fields:      fields.OneTermEqualSelector("spec.nodeName", "baz"),
+			ExpectPaths: []string{"/pods", "/namespaces", "/namespaces/a/pods", "/namespaces/b/pods"},
+			ExpectNames: []string{"a/foo", "b/bar"},
+		},
+		{
+			Fanout:      true,
+			Fields:      fields.Everything(),
+			ExpectPaths: []string{"/pods"},
+			Err:         true,
+		},
+	}
+	for i, test := range tests {
+		paths := []string{}
+		client := &client.FakeRESTClient{
+			Codec: testapi.Codec(),
+			Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+				paths = append(paths, req.URL.Path)
+				switch req.URL.Path {
+				case "/pods":
+					if test.Fanout {
+						return &http.Response{StatusCode: http.StatusBadRequest, Body: objBody(&api.Status{Status: api.StatusFailure, Code: http.StatusBadRequest, Reason: api.StatusReasonBadRequest})}, nil
+					}
+					return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.PodList{Items: []api.Pod{fooPod, barPod}})}, nil
+				case "/namespaces":
+					return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.NamespaceList{Items: []api.Namespace{
+						{ObjectMeta: api.ObjectMeta{Name: "a"}},
+						{ObjectMeta: api.ObjectMeta{Name: "b"}},
+					}})}, nil
+				case "/namespaces/a/pods":
+					return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.PodList{Items: []api.Pod{{ObjectMeta: api.ObjectMeta{Name: "foo"}}}})}, nil
+				case "/namespaces/b/pods":
+					return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.PodList{Items: []api.Pod{barPod}})}, nil
+				}
+				t.Fatalf("%d: unexpected request: %#v", i, req)
+				return nil, nil
+			}),
+		}
+		modifier := &Helper{
+			RESTClient:      client,
+			Resource:        "pods",
+			NamespaceScoped: true,
+		}
+		obj, err := modifier.List(api.NamespaceAll, testapi.Version(), labels.Everything(), test.Fields)
+		if (err != nil) != test.Err {
+			t.Errorf("%d: unexpected error: %t %v", i, test.Err, err)
+		}
+		if !reflect.DeepEqual(test.ExpectPaths, paths) {
+			t.Errorf("%d: unexpected requests: %v", i, paths)
+		}
+		if err != nil {
+			continue
+		}
+		names := []string{}
+		for _, pod := range obj.(*api.PodList).Items {
+			names = append(names, pod.Namespace+"/"+pod.Name)
+		}
+		if !reflect.DeepEqual(test.ExpectNames, names) {
+			t.Errorf("%d: unexpected items: %v", i, names)
+		}
+	}
+}
+
+func TestHelperListEach(t *testing.T) {
+	stop := errors.New("stop")
+	tests := []struct {
+		Body   runtime.Object
+		Return error
+
+		Expect []string
+		Err    error
+	}{
+		{
+			Body: &api.PodList{},
+		},
+		{
+			Body: &api.PodList{Items: []api.Pod{
+				{ObjectMeta: api.ObjectMeta{Name: "foo"}},
+				{ObjectMeta: api.ObjectMeta{Name: "bar"}},
+			}},
+			Expect: []string{"foo", "bar"},
+		},
+		{
+			Body: &api.PodList{Items: []api.Pod{
+				{ObjectMeta: api.ObjectMeta{Name: "foo"}},
+				{ObjectMeta: api.ObjectMeta{Name: "bar"}},
+			}},
+			Return: stop,
+			Expect: []string{"foo"},
+			Err:    stop,
+		},
+		{
+			Body: &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}},
+		},
+	}
+	for i, test := range tests {
+		client := &client.FakeRESTClient{
+			Codec: testapi.Codec(),
+			Resp:  &http.Response{StatusCode: http.StatusOK, Body: objBody(test.Body)},
+		}
+		modifier := &Helper{
+			RESTClient:      client,
+			Resource:        "pods",
+			NamespaceScoped: true,
+		}
+		names := []string{}
+		err := modifier.ListEach("bar", testapi.Version(), labels.Everything(), func(item runtime.Object) error {
+			names = append(names, item.(*api.Pod).Name)
+			return test.Return
+		})
+		if _, isList := test.Body.(*api.PodList); !isList {
+			if err == nil || !strings.Contains(err.Error(), "expected a list") {
+				t.Errorf("%d: unexpected error: %v", i, err)
+			}
+			continue
+		}
+		if err != test.Err {
+			t.Errorf("%d: unexpected error: %v", i, err)
+		}
+		if len(names) != len(test.Expect) || (len(names) > 0 && !reflect.DeepEqual(names, test.Expect)) {
+			t.Errorf("%d: unexpected items: %v", i, names)
+		}
+	}
+}
+
+func TestHelperListStream(t *testing.T) {
+	podList := func(names ...string) *api.PodList {
+		list := &api.PodList{}
+		for _, name := range names {
+			list.Items = append(list.Items, api.Pod{ObjectMeta: api.ObjectMeta{Name: name, Labels: map[string]string{"name": name}}})
+		}
+		return list
+	}
+	tests := []struct {
+		Resp  *http.Response
+		Names []string
+		Err   bool
+	}{
+		{
+			Resp:  &http.Response{StatusCode: http.StatusOK, Body: objBody(podList("a", "b", "c"))},
+			Names: []string{"a", "b", "c"},
+		},
+		{
+			Resp:  &http.Response{StatusCode: http.StatusOK, Body: objBody(podList())},
+			Names: []string{},
+		},
+		{
+			Resp:  &http.Response{StatusCode: http.StatusOK, Body: stringBody(`{"kind":"PodList","apiVersion":"` + testapi.Version() + `","items":[{}]}`)},
+			Names: []string{""},
+		},
+		{
+			Resp:  &http.Response{StatusCode: http.StatusOK, Body: stringBody(`{"kind":"PodList","apiVersion":"` + testapi.Version() + `","items":[],"metadata":{"resourceVersion":"1"}}`)},
+			Names: []string{},
+		},
+		{
+			Resp:  &http.Response{StatusCode: http.StatusOK, Body: stringBody(`{"kind":"PodList","apiVersion":"` + testapi.Version() + `","items":null}`)},
+			Names: []string{},
+		},
+		{
+			Resp:  &http.Response{StatusCode: http.StatusOK, Body: stringBody(` { "kind" : "PodList", "apiVersion" : "` + testapi.Version() + `", "items" : [ {"metadata":{"name":"a\\\"}]","labels":{"name":"a\\\"}]"}}} , {} ], "count" : -1.5e3 } `)},
+			Names: []string{`a\"}]`, ""},
+		},
+		{
+			Resp: &http.Response{StatusCode: http.StatusOK, Body: stringBody(`{"kind":"PodList","apiVersion":"` + testapi.Version() + `","items":[{}`)},
+			Err:  true,
+		},
+		{
+			Resp: &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Pod{ObjectMeta: api.ObjectMeta{Name: "a"}})},
+			Err:  true,
+		},
+		{
+			Resp: &http.Response{StatusCode: http.StatusOK, Body: stringBody(`{"items":[{}],"kind":"PodList"}`)},
+			Err:  true,
+		},
+		{
+			Resp: &http.Response{StatusCode: http.StatusNotFound, Body: objBody(&api.Status{Status: api.StatusFailure, Reason: api.StatusReasonNotFound, Code: http.StatusNotFound})},
+			Err:  true,
+		},
+	}
+	for i, test := range tests {
+		client := &client.FakeRESTClient{
+			Codec: testapi.Codec(),
+			Resp:  test.Resp,
+		}
+		modifier := &Helper{
+			RESTClient:      client,
+			Codec:           testapi.Codec(),
+			Resource:        "pods",
+			NamespaceScoped: true,
+		}
+		names := []string{}
+		err := modifier.ListStream("bar", testapi.Version(), labels.Everything(), fields.Everything(), func(item runtime.Object) error {
+			pod := item.(*api.Pod)
+			if len(pod.Name) > 0 && pod.Labels["name"] != pod.Name {
+				t.Errorf("%d: item was not fully decoded: %#v", i, pod)
+			}
+			names = append(names, pod.Name)
+			return nil
+		})
+		if test.Err != (err != nil) {
+			t.Errorf("%d: unexpected error: %v", i, err)
+		}
+		if !test.Err && !reflect.DeepEqual(names, test.Names) {
+			t.Errorf("%d: unexpected items: %v", i, names)
+		}
+	}
+}
+
+func TestHelperListStreamStops(t *testing.T) {
+	client := &client.FakeRESTClient{
+		Codec: testapi.Codec(),
+		Resp: &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.PodList{Items: []api.Pod{
+			{ObjectMeta: api.ObjectMeta{Name: "a"}},
+			{ObjectMeta: api.ObjectMeta{Name: "b"}},
+		}})},
+	}
+	modifier := &Helper{
+		RESTClient: client,
+		Codec:      testapi.Codec(),
+		Resource:   "pods",
+	}
+	stop := errors.New("stop")
+	count := 0
+	err := modifier.ListStream("", testapi.Version(), labels.Everything(), fields.Everything(), func(item runtime.Object) error {
+		count++
+		return stop
+	})
+	if err != stop || count != 1 {
+		t.Errorf("unexpected result: %d %v", count, err)
+	}
+}
+
+func TestHelperWatchTable(t *testing.T) {
+	pod := func(name string, labels map[string]string) *api.Pod {
+		return &api.Pod{ObjectMeta: api.ObjectMeta{Name: name, Namespace: "bar", Labels: labels}}
+	}
+	servedTable := func(columns bool, rows ...string) string {
+		definitions := ""
+		if columns {
+			definitions = `"columnDefinitions":[{"name":"Name","type":"string"},{"name":"Status","type":"string"}],`
+		}
+		return `{"kind":"Table","apiVersion":"meta.k8s.io/v1beta1",` + definitions + `"rows":[` + strings.Join(rows, ",") + `]}`
+	}
+	servedRow := func(obj runtime.Object, cells ...string) string {
+		return fmt.Sprintf(`{"cells":["%s"],"object":%s}`, strings.Join(cells, `","`), runtime.EncodeOrDie(testapi.Codec(), obj))
+	}
+	podEvents := watchBody(
+		watch.Event{Type: watch.Added, Object: pod("foo", map[string]string{"app": "web"})},
+		watch.Event{Type: watch.Deleted, Object: pod("bar", nil)},
+	)
+
+	tests := map[string]struct {
+		List    string
+		Watch   string
+		Get     map[string]string
+		Columns []string
+		Rows    []TableRowEvent
+	}{
+		"tables served for watches": {
+			List: servedTable(true, servedRow(pod("bar", nil), "bar", "Running")),
+			Watch: `{"type":"ADDED","object":` + servedTable(true, servedRow(pod("foo", nil), "foo", "Pending")) + "}\n" +
+				`{"type":"DELETED","object":` + servedTable(false, servedRow(pod("bar", nil), "bar", "Terminating")) + "}\n",
+			Columns: []string{"Name", "Status"},
+			Rows: []TableRowEvent{
+				{Type: watch.Added, Cells: []interface{}{"foo", "Pending"}},
+				{Type: watch.Deleted, Cells: []interface{}{"bar", "Terminating"}},
+			},
+		},
+		"tables served for lists only": {
+			List:    servedTable(true, servedRow(pod("bar", nil), "bar", "Running")),
+			Watch:   podEvents,
+			Get:     map[string]string{"foo": servedTable(true, servedRow(pod("foo", nil), "foo", "Pending"))},
+			Columns: []string{"Name", "Status"},
+			Rows: []TableRowEvent{
+				{Type: watch.Added, Cells: []interface{}{"foo", "Pending"}},
+				{Type: watch.Deleted, Cells: []interface{}{"bar", "Running"}},
+			},
+		},
+		"tables not served": {
+			List:    runtime.EncodeOrDie(testapi.Codec(), &api.PodList{Items: []api.Pod{*pod("bar", nil)}}),
+			Watch:   podEvents,
+			Columns: []string{"Name", "Labels"},
+			Rows: []TableRowEvent{
+				{Type: watch.Added, Cells: []interface{}{"foo", "app=web"}},
+				{Type: watch.Deleted, Cells: []interface{}{"bar", "<none>"}},
+			},
+		},
+	}
+	for k, test := range tests {
+		client := &client.FakeRESTClient{
+			Codec: testapi.Codec(),
+			Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+				if accept := req.Header.Get("Accept"); accept != tableAcceptHeader {
+					t.Errorf("%s: unexpected accept header: %s", k, accept)
+				}
+				parts := splitPath(req.URL.Path)
+				body, name := test.List, parts[len(parts)-1]
+				switch {
+				case parts[0] == "watch":
+					body = test.Watch
+				case name != "pods":
+					body = test.Get[name]
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: stringBody(body)}, nil
+			}),
+		}
+		modifier := &Helper{
+			RESTClient:      client,
+			Codec:           testapi.Codec(),
+			Resource:        "pods",
+			NamespaceScoped: true,
+		}
+		columns, events, err := modifier.WatchTable("bar", labels.Everything())
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", k, err)
+			continue
+		}
+		names := []string{}
+		for _, column := range columns {
+			names = append(names, column.Name)
+		}
+		if !reflect.DeepEqual(names, test.Columns) {
+			t.Errorf("%s: unexpected columns: %#v", k, columns)
+		}
+		rows := []TableRowEvent{}
+		for row := range events {
+			rows = append(rows, TableRowEvent{Type: row.Type, Cells: row.Cells})
+		}
+		if !reflect.DeepEqual(rows, test.Rows) {
+			t.Errorf("%s: unexpected rows: %#v", k, rows)
+		}
+	}
+}
+
+func TestHelperListTable(t *testing.T) {
+	pods, _ := testData()
+	served := `{"kind":"Table","apiVersion":"meta.k8s.io/v1beta1",
+		"columnDefinitions":[{"name":"Name","type":"string"},{"name":"Replicas","type":"integer"}],
+		"rows":[{"cells":["foo",3]},{"cells":["bar",null]}]}`
+	tests := []struct {
+		Resp    *http.Response
+		Columns []string
+		Cells   [][]interface{}
+		Printed string
+	}{
+		{
+			Resp:    &http.Response{StatusCode: http.StatusOK, Body: stringBody(served)},
+			Columns: []string{"Name", "Replicas"},
+			Cells:   [][]interface{}{{"foo", float64(3)}, {"bar", nil}},
+			Printed: "NAME      REPLICAS\nfoo       3\nbar       <none>\n",
+		},
+		{
+			Resp:    &http.Response{StatusCode: http.StatusOK, Body: objBody(pods)},
+			Columns: []string{"Name", "Labels"},
+			Cells:   [][]interface{}{{"foo", "<none>"}, {"bar", "<none>"}},
+			Printed: "NAME      LABELS\nfoo       <none>\nbar       <none>\n",
+		},
+	}
+	for i, test := range tests {
+		client := &client.FakeRESTClient{
+			Codec: testapi.Codec(),
+			Resp:  test.Resp,
+		}
+		modifier := &Helper{
+			RESTClient:      client,
+			Codec:           testapi.Codec(),
+			Resource:        "pods",
+			NamespaceScoped: true,
+		}
+		table, err := modifier.ListTable("bar", labels.Everything())
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+		if accept := client.Req.Header.Get("Accept"); !strings.Contains(accept, "as=Table") {
+			t.Errorf("%d: expected the tabular representation to be requested: %q", i, accept)
+		}
+		columns := []string{}
+		for _, column := range table.ColumnDefinitions {
+			columns = append(columns, column.Name)
+		}
+		cells := [][]interface{}{}
+		for _, row := range table.Rows {
+			cells = append(cells, row.Cells)
+		}
+		if !reflect.DeepEqual(test.Columns, columns) || !reflect.DeepEqual(test.Cells, cells) {
+			t.Errorf("%d: unexpected table: %v %v", i, columns, cells)
+		}
+		buf := &bytes.Buffer{}
+		if err := table.Print(buf, false); err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+		if buf.String() != test.Printed {
+			t.Errorf("%d: unexpected output:\n%s", i, buf.String())
+		}
+	}
+}
+
+func TestNewTable(t *testing.T) {
+	pods, _ := testData()
+	tests := []struct {
+		Object  runtime.Object
+		Printed string
+	}{
+		{&pods.Items[0], "NAME      LABELS\nfoo       <none>\n"},
+		{pods, "NAME      LABELS\nfoo       <none>\nbar       <none>\n"},
+	}
+	for i, test := range tests {
+		table, err := NewTable(test.Object)
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+		buf := &bytes.Buffer{}
+		if err := table.Print(buf, false); err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+		if buf.String() != test.Printed {
+			t.Errorf("%d: unexpected output:\n%s", i, buf.String())
+		}
+	}
+}
+
+func TestHelperWithTimeout(t *testing.T) {
+	pod := &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}}
+	client := &client.FakeRESTClient{
+		Codec: testapi.Codec(),
+		Resp:  &http.Response{StatusCode: http.StatusOK, Body: objBody(pod)},
+	}
+	modifier := &Helper{
+		RESTClient:      client,
+		NamespaceScoped: true,
+	}
+	if _, err := modifier.WithTimeout(10*time.Second).Get("bar", "foo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if query := client.Req.URL.Query(); query.Get("timeout") != "10s" {
+		t.Errorf("unexpected query: %v", query)
+	}
+
+	client.Resp = &http.Response{StatusCode: http.StatusOK, Body: objBody(pod)}
+	if _, err := modifier.Get("bar", "foo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if query := client.Req.URL.Query(); len(query.Get("timeout")) != 0 {
+		t.Errorf("the timeout should only apply to the copy: %v", query)
+	}
+
+	client.Resp = &http.Response{StatusCode: http.StatusOK, Body: stringBody("")}
+	w, err := modifier.WithTimeout(10*time.Second).Watch("bar", "1", testapi.Version(), labels.Everything(), fields.Everything())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Stop()
+	if query := client.Req.URL.Query(); len(query.Get("timeout")) != 0 {
+		t.Errorf("watches should not be sent a request timeout: %v", query)
+	}
+}
+
+func TestHelperWatchTimeout(t *testing.T) {
+	tests := []struct {
+		Timeout   time.Duration
+		Bookmarks bool
+
+		ExpectParam string
+		Wrapped     bool
+	}{
+		{},
+		{
+			Timeout:     30 * time.Second,
+			ExpectParam: "30",
+			Wrapped:     true,
+		},
+		{
+			Timeout:     1500 * time.Millisecond,
+			ExpectParam: "2",
+			Wrapped:     true,
+		},
+		{
+			Timeout:     5 * time.Minute,
+			Bookmarks:   true,
+			ExpectParam: "300",
+			Wrapped:     true,
+		},
+	}
+	for i, test := range tests {
+		client := &client.FakeRESTClient{
+			Codec: testapi.Codec(),
+			Resp:  &http.Response{StatusCode: http.StatusOK, Body: stringBody("")},
+		}
+		modifier := &Helper{
+			RESTClient:          client,
+			NamespaceScoped:     true,
+			WatchTimeout:        test.Timeout,
+			AllowWatchBookmarks: test.Bookmarks,
+		}
+		for _, fn := range []func() (watch.Interface, error){
+			func() (watch.Interface, error) {
+				return modifier.Watch("bar", "1", testapi.Version(), labels.Everything(), fields.Everything())
+			},
+			func() (watch.Interface, error) {
+				return modifier.WatchSingle("bar", "foo", "1")
+			},
+		} {
+			w, err := fn()
+			if err != nil {
+				t.Fatalf("%d: unexpected error: %v", i, err)
+			}
+			query := client.Req.URL.Query()
+			if _, ok := query["timeoutSeconds"]; ok != (len(test.ExpectParam) != 0) || query.Get("timeoutSeconds") != test.ExpectParam {
+				t.Errorf("%d: unexpected query: %v", i, query)
+			}
+			if _, ok := query["allowWatchBookmarks"]; ok != test.Bookmarks {
+				t.Errorf("%d: unexpected query: %v", i, query)
+			}
+			if _, ok := w.(*TimeoutWatcher); ok != test.Wrapped {
+				t.Errorf("%d: unexpected watcher: %#v", i, w)
+			}
+			w.Stop()
+		}
+	}
+}
+
+func TestHelperSubresource(t *testing.T) {
+	client := &client.FakeRESTClient{
+		Codec: testapi.Codec(),
+		Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", ResourceVersion: "10"}})}, nil
+		}),
+	}
+	helper := &Helper{
+		RESTClient:      client,
+		Resource:        "pods",
+		Codec:           testapi.Codec(),
+		Versioner:       testapi.MetadataAccessor(),
+		NamespaceScoped: true,
+	}
+	modifier := helper.WithSubresource("status")
+	if helper.Subresource != "" {
+		t.Errorf("the original helper should not be modified")
+	}
+	data := []byte(runtime.EncodeOrDie(testapi.Codec(), &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}}))
+	for _, fn := range []func() (runtime.Object, error){
+		func() (runtime.Object, error) { return modifier.Get("bar", "foo") },
+		func() (runtime.Object, error) { return modifier.Replace("bar", "foo", true, data) },
+		func() (runtime.Object, error) { return modifier.Patch("bar", "foo", api.MergePatchType, []byte("{}")) },
+	} {
+		if _, err := fn(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if client.Req.URL.Path != "/namespaces/bar/pods/foo/status" {
+			t.Errorf("unexpected path: %s %s", client.Req.Method, client.Req.URL.Path)
+		}
+	}
+}
+
+// normalizedConfig returns config as the Helper records it, with defaults applied.
+func normalizedConfig(t *testing.T, config []byte) string {
+	obj, err := testapi.Codec().Decode(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return runtime.EncodeOrDie(testapi.Codec(), obj)
+}
+
+func TestHelperApply(t *testing.T) {
+	config := func(labels map[string]string) []byte {
+		return []byte(runtime.EncodeOrDie(testapi.Codec(), &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", Namespace: "bar", Labels: labels}}))
+	}
+	current := &api.Pod{
+		ObjectMeta: api.ObjectMeta{
+			Name:            "foo",
+			Namespace:       "bar",
+			ResourceVersion: "10",
+			Labels:          map[string]string{"a": "b", "c": "d", "set-by": "others"},
+			Annotations:     map[string]string{LastAppliedConfigAnnotation: string(config(map[string]string{"a": "b", "c": "d"}))},
+		},
+		Spec: api.PodSpec{NodeName: "node"},
+	}
+	var patched *api.Pod
+	client := &client.FakeRESTClient{
+		Codec: testapi.Codec(),
+		Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			switch req.Method {
+			case "GET":
+				return &http.Response{StatusCode: http.StatusOK, Body: objBody(current)}, nil
+			case "PATCH":
+				patch, err := ioutil.ReadAll(req.Body)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				versioned, err := api.Scheme.New(testapi.Version(), "Pod")
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				data, err := strategicpatch.StrategicMergePatchData([]byte(runtime.EncodeOrDie(testapi.Codec(), current)), patch, versioned)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				obj, err := testapi.Codec().Decode(data)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				patched = obj.(*api.Pod)
+				return &http.Response{StatusCode: http.StatusOK, Body: objBody(patched)}, nil
+			}
+			t.Fatalf("unexpected request: %#v", req)
+			return nil, nil
+		}),
+	}
+	modifier := &Helper{
+		RESTClient:      client,
+		Codec:           testapi.Codec(),
+		Resource:        "pods",
+		NamespaceScoped: true,
+	}
+	newConfig := config(map[string]string{"a": "e"})
+	if _, err := modifier.Apply("bar", "foo", newConfig); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if patched == nil {
+		t.Fatalf("expected the object to be patched")
+	}
+	if expected := map[string]string{"a": "e", "set-by": "others"}; !reflect.DeepEqual(patched.Labels, expected) {
+		t.Errorf("unexpected labels: %v", patched.Labels)
+	}
+	if patched.Spec.NodeName != "node" {
+		t.Errorf("fields that were never configured should be preserved: %#v", patched.Spec)
+	}
+	if patched.Annotations[LastAppliedConfigAnnotation] != normalizedConfig(t, newConfig) {
+		t.Errorf("unexpected last applied configuration: %s", patched.Annotations[LastAppliedConfigAnnotation])
+	}
+}
+
+func TestHelperApplyCreates(t *testing.T) {
+	client := &client.FakeRESTClient{
+		Codec: testapi.Codec(),
+		Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			switch req.Method {
+			case "GET":
+				return &http.Response{StatusCode: http.StatusNotFound, Body: objBody(&api.Status{Status: api.StatusFailure, Reason: api.StatusReasonNotFound, Code: http.StatusNotFound})}, nil
+			case "POST":
+				return &http.Response{StatusCode: http.StatusCreated, Body: req.Body}, nil
+			}
+			t.Fatalf("unexpected request: %#v", req)
+			return nil, nil
+		}),
+	}
+	modifier := &Helper{
+		RESTClient:      client,
+		Codec:           testapi.Codec(),
+		Resource:        "pods",
+		NamespaceScoped: true,
+	}
+	config := []byte(runtime.EncodeOrDie(testapi.Codec(), &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", Namespace: "bar"}}))
+	obj, err := modifier.Apply("bar", "foo", config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.Req.Method != "POST" {
+		t.Errorf("expected the object to be created: %#v", client.Req)
+	}
+	if annotation := obj.(*api.Pod).Annotations[LastAppliedConfigAnnotation]; annotation != normalizedConfig(t, config) {
+		t.Errorf("unexpected last applied configuration: %s", annotation)
+	}
+}
+
+func TestSetLastAppliedConfigUnstructured(t *testing.T) {
+	obj := &runtime.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":        "foo",
+				"annotations": map[string]interface{}{LastAppliedConfigAnnotation: "{}", "a": "b"},
+			},
+		},
+	}
+	if err := setLastAppliedConfig(obj, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	metadata := obj.Object["metadata"].(map[string]interface{})
+	if e, a := map[string]interface{}{"a": "b"}, metadata["annotations"]; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	metadata["annotations"] = map[string]interface{}{LastAppliedConfigAnnotation: "{}"}
+	if err := setLastAppliedConfig(obj, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if annotations, found := metadata["annotations"]; found {
+		t.Errorf("expected the annotations to be removed, got %v", annotations)
+	}
+}
+
+func TestHelperReplaceWithRetry(t *testing.T) {
+	replaceRetryDelay = 0
+	conflict := apierrors.NewConflict("pod", "foo", errors.New("the object has been modified")).(*apierrors.StatusError).ErrStatus
+	tests := []struct {
+		Conflicts int
+		Retries   int
+		Err       bool
+		Puts      int
+	}{
+		{Conflicts: 0, Retries: 0, Puts: 1},
+		{Conflicts: 2, Retries: 3, Puts: 3},
+		{Conflicts: 2, Retries: 1, Puts: 2, Err: true},
+	}
+	for i, test := range tests {
+		gets, puts := 0, 0
+		client := &client.FakeRESTClient{
+			Codec: testapi.Codec(),
+			Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+				switch req.Method {
+				case "GET":
+					gets++
+					return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", ResourceVersion: strconv.Itoa(gets)}})}, nil
+				case "PUT":
+					puts++
+					data, err := ioutil.ReadAll(req.Body)
+					if err != nil {
+						t.Fatalf("%d: unexpected error: %v", i, err)
+					}
+					obj, err := testapi.Codec().Decode(data)
+					if err != nil {
+						t.Fatalf("%d: unexpected error: %v", i, err)
+					}
+					pod := obj.(*api.Pod)
+					if pod.ResourceVersion != strconv.Itoa(gets) || pod.Labels["mutated"] != "true" {
+						t.Errorf("%d: expected the mutation to be applied to the latest object: %#v", i, pod)
+					}
+					if puts <= test.Conflicts {
+						return &http.Response{StatusCode: http.StatusConflict, Body: objBody(&conflict)}, nil
+					}
+					return &http.Response{StatusCode: http.StatusOK, Body: objBody(pod)}, nil
+				}
+				t.Fatalf("%d: unexpected request: %#v", i, req)
+				return nil, nil
+			}),
+		}
+		modifier := &Helper{
+			RESTClient:      client,
+			Codec:           testapi.Codec(),
+			Resource:        "pods",
+			NamespaceScoped: true,
+		}
+		_, err := modifier.ReplaceWithRetry("bar", "foo", func(obj runtime.Object) error {
+			obj.(*api.Pod).Labels = map[string]string{"mutated": "true"}
+			return nil
+		}, test.Retries)
+		if test.Err != (err != nil) {
+			t.Errorf("%d: unexpected error: %v", i, err)
+		}
+		if test.Err && !apierrors.IsConflict(err) {
+			t.Errorf("%d: expected a conflict error: %v", i, err)
+		}
+		if puts != test.Puts || gets != test.Puts {
+			t.Errorf("%d: unexpected number of requests: %d gets, %d puts", i, gets, puts)
+		}
+	}
+}
+
+func TestHelperCreateOrUpdate(t *testing.T) {
+	exists := apierrors.NewAlreadyExists("pod", "foo").(*apierrors.StatusError).ErrStatus
+	conflict := apierrors.NewConflict("pod", "foo", errors.New("the object has been modified")).(*apierrors.StatusError).ErrStatus
+	tests := map[string]struct {
+		Exists          bool
+		ResourceVersion string
+		Strategy        UpdateStrategy
+		UpdateStatus    int
+
+		Requests []string
+		Created  bool
+		Err      func(error) bool
+	}{
+		"created": {
+			Strategy: UpdateByReplace,
+			Requests: []string{"POST"},
+			Created:  true,
+		},
+		"replaced at the current version": {
+			Exists:       true,
+			Strategy:     UpdateByReplace,
+			UpdateStatus: http.StatusOK,
+			Requests:     []string{"POST", "GET", "PUT 10"},
+		},
+		"replaced at the given version": {
+			Exists:          true,
+			ResourceVersion: "5",
+			Strategy:        UpdateByReplace,
+			UpdateStatus:    http.StatusOK,
+			Requests:        []string{"POST", "PUT 5"},
+		},
+		"replace conflict": {
+			Exists:          true,
+			ResourceVersion: "5",
+			Strategy:        UpdateByReplace,
+			UpdateStatus:    http.StatusConflict,
+			Requests:        []string{"POST", "PUT 5"},
+			Err:             apierrors.IsConflict,
+		},
+		"patched": {
+			Exists:       true,
+			Strategy:     UpdateByPatch,
+			UpdateStatus: http.StatusOK,
+			Requests:     []string{"POST", "PATCH"},
+		},
+		"unknown strategy": {
+			Exists:   true,
+			Strategy: "Merge",
+			Requests: []string{},
+			Err:      func(err error) bool { return strings.Contains(err.Error(), "unknown update strategy") },
+		},
+	}
+	for k, test := range tests {
+		requests := []string{}
+		client := &client.FakeRESTClient{
+			Codec: testapi.Codec(),
+			Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+				switch req.Method {
+				case "POST":
+					requests = append(requests, "POST")
+					if test.Exists {
+						return &http.Response{StatusCode: http.StatusConflict, Body: objBody(&exists)}, nil
+					}
+					return &http.Response{StatusCode: http.StatusCreated, Body: objBody(&api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", ResourceVersion: "1"}})}, nil
+				case "GET":
+					requests = append(requests, "GET")
+					return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", ResourceVersion: "10"}})}, nil
+				case "PUT", "PATCH":
+					data, err := ioutil.ReadAll(req.Body)
+					if err != nil {
+						t.Fatalf("%s: unexpected error: %v", k, err)
+					}
+					if req.Method == "PUT" {
+						obj, err := testapi.Codec().Decode(data)
+						if err != nil {
+							t.Fatalf("%s: unexpected error: %v", k, err)
+						}
+						requests = append(requests, "PUT "+obj.(*api.Pod).ResourceVersion)
+					} else {
+						requests = append(requests, "PATCH")
+					}
+					if test.UpdateStatus == http.StatusConflict {
+						return &http.Response{StatusCode: http.StatusConflict, Body: objBody(&conflict)}, nil
+					}
+					return &http.Response{StatusCode: test.UpdateStatus, Body: objBody(&api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", ResourceVersion: "11"}})}, nil
+				}
+				t.Fatalf("%s: unexpected request: %#v", k, req)
+				return nil, nil
+			}),
+		}
+		modifier := &Helper{
+			RESTClient:      client,
+			Codec:           testapi.Codec(),
+			Versioner:       testapi.MetadataAccessor(),
+			Resource:        "pods",
+			NamespaceScoped: true,
+		}
+		data := []byte(runtime.EncodeOrDie(testapi.Codec(), &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", ResourceVersion: test.ResourceVersion}}))
+		obj, created, err := modifier.CreateOrUpdate("bar", data, test.Strategy)
+		switch {
+		case test.Err != nil:
+			if err == nil || !test.Err(err) {
+				t.Errorf("%s: unexpected error: %v", k, err)
+			}
+		case err != nil:
+			t.Errorf("%s: unexpected error: %v", k, err)
+		case obj == nil:
+			t.Errorf("%s: expected the stored object to be returned", k)
+		}
+		if created != test.Created {
+			t.Errorf("%s: expected created to be %t", k, test.Created)
+		}
+		if !reflect.DeepEqual(test.Requests, requests) {
+			t.Errorf("%s: unexpected requests: %v", k, requests)
+		}
+	}
+}
+
+func TestHelperPreconditions(t *testing.T) {
+	live := &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", UID: "uid-2", ResourceVersion: "10"}}
+	tests := map[string]struct {
+		Preconditions Preconditions
+		Proceed       bool
+	}{
+		"none":                       {Proceed: true},
+		"matching uid":               {Preconditions: Preconditions{UID: "uid-2"}, Proceed: true},
+		"matching uid and version":   {Preconditions: Preconditions{UID: "uid-2", ResourceVersion: "10"}, Proceed: true},
+		"recreated object":           {Preconditions: Preconditions{UID: "uid-1"}},
+		"object modified since seen": {Preconditions: Preconditions{UID: "uid-2", ResourceVersion: "9"}},
+	}
+	for k, test := range tests {
+		requests := []string{}
+		client := &client.FakeRESTClient{
+			Codec: testapi.Codec(),
+			Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+				switch req.Method {
+				case "GET":
+					requests = append(requests, "GET")
+					return &http.Response{StatusCode: http.StatusOK, Body: objBody(live)}, nil
+				case "DELETE":
+					requests = append(requests, "DELETE")
+					return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Status{Status: api.StatusSuccess})}, nil
+				case "PUT":
+					data, err := ioutil.ReadAll(req.Body)
+					if err != nil {
+						t.Fatalf("%s: unexpected error: %v", k, err)
+					}
+					obj, err := testapi.Codec().Decode(data)
+					if err != nil {
+						t.Fatalf("%s: unexpected error: %v", k, err)
+					}
+					requests = append(requests, "PUT "+obj.(*api.Pod).ResourceVersion)
+					return &http.Response{StatusCode: http.StatusOK, Body: objBody(obj)}, nil
+				}
+				t.Fatalf("%s: unexpected request: %#v", k, req)
+				return nil, nil
+			}),
+		}
+		modifier := &Helper{
+			RESTClient:      client,
+			Codec:           testapi.Codec(),
+			Versioner:       testapi.MetadataAccessor(),
+			Resource:        "pods",
+			NamespaceScoped: true,
+		}
+
+		err := modifier.DeleteWithPreconditions("bar", "foo", test.Preconditions, nil)
+		data := []byte(runtime.EncodeOrDie(testapi.Codec(), &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}}))
+		_, replaceErr := modifier.ReplaceWithPreconditions("bar", "foo", test.Preconditions, data)
+		expected := []string{"GET", "GET"}
+		if test.Proceed {
+			expected = []string{"GET", "DELETE", "GET", "PUT 10"}
+			if err != nil || replaceErr != nil {
+				t.Errorf("%s: unexpected errors: %v %v", k, err, replaceErr)
+			}
+		} else if !apierrors.IsConflict(err) || !apierrors.IsConflict(replaceErr) {
+			t.Errorf("%s: expected conflicts: %v %v", k, err, replaceErr)
+		}
+		if !reflect.DeepEqual(expected, requests) {
+			t.Errorf("%s: unexpected requests: %v", k, requests)
+		}
+	}
+}
+
+func TestHelperReplaceWithRetryMutateError(t *testing.T) {
+	client := &client.FakeRESTClient{
+		Codec: testapi.Codec(),
+		Resp:  &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}})},
+	}
+	modifier := &Helper{
+		RESTClient:      client,
+		Codec:           testapi.Codec(),
+		Resource:        "pods",
+		NamespaceScoped: true,
+	}
+	mutateErr := errors.New("invalid")
+	_, err := modifier.ReplaceWithRetry("bar", "foo", func(obj runtime.Object) error {
+		return mutateErr
+	}, 3)
+	if err != mutateErr {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if client.Req.Method != "GET" {
+		t.Errorf("nothing should be sent after mutate fails: %#v", client.Req)
+	}
+}
+
+func TestHelperReplace(t *testing.T) {
+	expectPut := func(req *http.Request) bool {
+		if req.Method != "PUT" {
+			t.Errorf("unexpected method: %#v", req)
+			return false
+		}
+		parts := splitPath(req.URL.Path)
+		if parts[1] != "bar" {
+			t.Errorf("url doesn't contain namespace: %#v", req.URL)
+			return false
+		}
+		if parts[2] != "foo" {
+			t.Errorf("url doesn't contain name: %#v", req)
+			return false
+		}
+		return true
+	}
+
+	tests := []struct {
+		Resp      *http.Response
+		RespFunc  client.HTTPClientFunc
+		HttpErr   error
+		Overwrite bool
+		Object    runtime.Object
+
+		ExpectObject runtime.Object
+		Err          bool
+		Req          func(*http.Request) bool
+	}{
+		{
+			HttpErr: errors.New("failure"),
+			Err:     true,
+		},
+		{
+			Object: &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}},
+			Resp: &http.Response{
+				StatusCode: http.StatusNotFound,
+				Body:       objBody(&api.Status{Status: api.StatusFailure}),
+			},
+			Err: true,
+		},
+		{
+			Object:       &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}},
+			ExpectObject: &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}},
+			Resp: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       objBody(&api.Status{Status: api.StatusSuccess}),
+			},
+			Req: expectPut,
+		},
+		{
+			Object: &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}},
+			ExpectObject: &api.Pod{
+				ObjectMeta: api.ObjectMeta{Name: "foo", ResourceVersion: "10"},
+				Spec: api.PodSpec{
+					RestartPolicy: api.RestartPolicyAlways,
+					DNSPolicy:     api.DNSClusterFirst,
+				},
+			},
+			Overwrite: true,
+			RespFunc: func(req *http.Request) (*http.Response, error) {
+				if req.Method == "PUT" {
+					return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Status{Status: api.StatusSuccess})}, nil
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", ResourceVersion: "10"}})}, nil
+			},
+			Req: expectPut,
+		},
+		{
+			Object:       &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", ResourceVersion: "10"}},
+			ExpectObject: &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", ResourceVersion: "10"}},
+			Resp:         &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Status{Status: api.StatusSuccess})},
+			Req:          expectPut,
+		},
+	}
+	for i, test := range tests {
+		client := &client.FakeRESTClient{
+			Codec: testapi.Codec(),
+			Resp:  test.Resp,
+			Err:   test.HttpErr,
+		}
+		if test.RespFunc != nil {
+			client.Client = test.RespFunc
+		}
+		modifier := &Helper{
+			RESTClient:      client,
+			Codec:           testapi.Codec(),
+			Versioner:       testapi.MetadataAccessor(),
+			NamespaceScoped: true,
+		}
+		data := []byte{}
+		if test.Object != nil {
+			data = []byte(runtime.EncodeOrDie(testapi.Codec(), test.Object))
+		}
+		_, err := modifier.Replace("bar", "foo", test.Overwrite, data)
+		if (err != nil) != test.Err {
+			t.Errorf("%d: unexpected error: %t %v", i, test.Err, err)
+		}
+		if err != nil {
+			continue
+		}
+		if test.Req != nil && !test.Req(client.Req) {
+			t.Errorf("%d: unexpected request: %#v", i, client.Req)
+		}
+		body, err := ioutil.ReadAll(client.Req.Body)
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %#v", i, err)
+		}
+		t.Logf("got body: %s", string(body))
+		expect := []byte{}
+		if test.ExpectObject != nil {
+			expect = []byte(runtime.EncodeOrDie(testapi.Codec(), test.ExpectObject))
+		}
+		if !reflect.DeepEqual(expect, body) {
+			t.Errorf("%d: unexpected body: %s", i, string(body))
+		}
+	}
+}
+
+func TestUnstructuredHelper(t *testing.T) {
+	const widget = `{"kind":"Widget","apiVersion":"example.com/v1","metadata":{"name":"foo","namespace":"bar","resourceVersion":"5"},"spec":{"size":3}}`
+	var sent map[string]interface{}
+	client := &client.FakeRESTClient{
+		Codec: runtime.UnstructuredJSONCodec,
+		Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			switch {
+			case req.Method == "GET" && req.URL.Path == "/namespaces/bar/widgets/foo":
+				return &http.Response{StatusCode: http.StatusOK, Body: stringBody(widget)}, nil
+			case req.Method == "GET":
+				return &http.Response{StatusCode: http.StatusNotFound, Body: objBody(&api.Status{Status: api.StatusFailure, Code: http.StatusNotFound, Reason: api.StatusReasonNotFound})}, nil
+			}
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			sent = nil
+			if err := json.Unmarshal(body, &sent); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: stringBody(string(body))}, nil
+		}),
+	}
+	modifier := NewUnstructuredHelper(client, "widgets", true)
+
+	obj, err := modifier.Get("bar", "foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	unstructured, ok := obj.(*runtime.Unstructured)
+	if !ok || unstructured.Kind != "Widget" || unstructured.APIVersion != "example.com/v1" {
+		t.Fatalf("unexpected object: %#v", obj)
+	}
+	if accessor, err := meta.Accessor(obj); err != nil || accessor.Name() != "foo" {
+		t.Errorf("unexpected metadata: %v %#v", err, obj)
+	}
+
+	if _, err := modifier.Get("bar", "missing"); !apierrors.IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+
+	if _, err := modifier.Create("bar", true, []byte(widget)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	metadata := sent["metadata"].(map[string]interface{})
+	if _, ok := metadata["resourceVersion"]; ok && metadata["resourceVersion"] != "" {
+		t.Errorf("expected the resource version to be cleared: %v", sent)
+	}
+	if sent["spec"].(map[string]interface{})["size"] != float64(3) {
+		t.Errorf("expected unknown fields to be preserved: %v", sent)
+	}
+
+	data := []byte(`{"kind":"Widget","apiVersion":"example.com/v1","metadata":{"name":"foo"},"spec":{"size":4}}`)
+	if _, err := modifier.Replace("bar", "foo", true, data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version := sent["metadata"].(map[string]interface{})["resourceVersion"]; version != "5" {
+		t.Errorf("expected the server's resource version to be sent, got %v", version)
+	}
+	if sent["spec"].(map[string]interface{})["size"] != float64(4) {
+		t.Errorf("unexpected object sent: %v", sent)
+	}
+}
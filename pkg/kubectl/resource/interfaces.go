@@ -17,9 +17,17 @@ limitations under the License.
 package resource
 
 import (
+	"time"
+
+	"golang.org/x/net/context"
+
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/meta"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 )
 
 // RESTClient is a client helper for dealing with RESTful resources
@@ -32,6 +40,35 @@ type RESTClient interface {
 	Put() *client.Request
 }
 
+// ResourceHelper retrieves and changes the objects of a resource. It is implemented
+// by Helper, and by FakeHelper for tests that should not depend on a server.
+type ResourceHelper interface {
+	Get(namespace, name string) (runtime.Object, error)
+	List(namespace, apiVersion string, labelSelector labels.Selector, fieldSelector fields.Selector) (runtime.Object, error)
+	Watch(namespace, resourceVersion, apiVersion string, labelSelector labels.Selector, fieldSelector fields.Selector) (watch.Interface, error)
+	WatchSingle(namespace, name, resourceVersion string) (watch.Interface, error)
+	Create(namespace string, modify bool, data []byte) (runtime.Object, error)
+	Replace(namespace, name string, overwrite bool, data []byte) (runtime.Object, error)
+	Patch(namespace, name string, pt api.PatchType, data []byte) (runtime.Object, error)
+	Delete(namespace, name string) error
+	DeleteWithPreconditions(namespace, name string, preconditions Preconditions, options *api.DeleteOptions) error
+}
+
+var _ ResourceHelper = &Helper{}
+
+// HelperFunc returns the ResourceHelper for the objects of mapping, whose requests
+// are sent with client. A nil HelperFunc returns a Helper.
+type HelperFunc func(client RESTClient, mapping *meta.RESTMapping) ResourceHelper
+
+// helperFor returns the ResourceHelper fn returns for mapping, or a Helper if fn is
+// nil.
+func (fn HelperFunc) helperFor(client RESTClient, mapping *meta.RESTMapping) ResourceHelper {
+	if fn == nil {
+		return NewHelper(client, mapping)
+	}
+	return fn(client, mapping)
+}
+
 // ClientMapper retrieves a client object for a given mapping
 type ClientMapper interface {
 	ClientForMapping(mapping *meta.RESTMapping) (RESTClient, error)
@@ -44,3 +81,145 @@ type ClientMapperFunc func(mapping *meta.RESTMapping) (RESTClient, error)
 func (f ClientMapperFunc) ClientForMapping(mapping *meta.RESTMapping) (RESTClient, error) {
 	return f(mapping)
 }
+
+// contextClient binds every request created by a RESTClient to a context.
+type contextClient struct {
+	RESTClient
+	ctx context.Context
+}
+
+// NewContextClient returns a RESTClient whose requests are aborted when ctx is
+// cancelled or its deadline passes.
+func NewContextClient(c RESTClient, ctx context.Context) RESTClient {
+	return &contextClient{c, ctx}
+}
+
+func (c *contextClient) Get() *client.Request {
+	return c.RESTClient.Get().Context(c.ctx)
+}
+
+func (c *contextClient) Post() *client.Request {
+	return c.RESTClient.Post().Context(c.ctx)
+}
+
+func (c *contextClient) Patch(pt api.PatchType) *client.Request {
+	return c.RESTClient.Patch(pt).Context(c.ctx)
+}
+
+func (c *contextClient) Delete() *client.Request {
+	return c.RESTClient.Delete().Context(c.ctx)
+}
+
+func (c *contextClient) Put() *client.Request {
+	return c.RESTClient.Put().Context(c.ctx)
+}
+
+// timeoutClient bounds every request created by a RESTClient with a timeout.
+type timeoutClient struct {
+	RESTClient
+	timeout time.Duration
+}
+
+// NewTimeoutClient returns a RESTClient whose requests ask the server to give up
+// after timeout and are abandoned by the client if no response has been read by
+// then. See client.Request.Timeout.
+func NewTimeoutClient(c RESTClient, timeout time.Duration) RESTClient {
+	return &timeoutClient{c, timeout}
+}
+
+func (c *timeoutClient) Get() *client.Request {
+	return c.RESTClient.Get().Timeout(c.timeout)
+}
+
+func (c *timeoutClient) Post() *client.Request {
+	return c.RESTClient.Post().Timeout(c.timeout)
+}
+
+func (c *timeoutClient) Patch(pt api.PatchType) *client.Request {
+	return c.RESTClient.Patch(pt).Timeout(c.timeout)
+}
+
+func (c *timeoutClient) Delete() *client.Request {
+	return c.RESTClient.Delete().Timeout(c.timeout)
+}
+
+func (c *timeoutClient) Put() *client.Request {
+	return c.RESTClient.Put().Timeout(c.timeout)
+}
+
+// retryClient retries every request created by a RESTClient on transient errors.
+type retryClient struct {
+	RESTClient
+	maxRetries int
+	delay      time.Duration
+}
+
+// NewRetryClient returns a RESTClient whose requests are retried up to maxRetries
+// times when the server is throttling requests or unavailable, or, except for
+// creates and patches, fails with a 5xx error, waiting with exponential backoff
+// starting at delay. A Retry-After header sent by the server is honored. See
+// client.Request.Retry.
+func NewRetryClient(c RESTClient, maxRetries int, delay time.Duration) RESTClient {
+	return &retryClient{c, maxRetries, delay}
+}
+
+func (c *retryClient) Get() *client.Request {
+	return c.RESTClient.Get().Retry(c.maxRetries, c.delay)
+}
+
+func (c *retryClient) Post() *client.Request {
+	return c.RESTClient.Post().Retry(c.maxRetries, c.delay)
+}
+
+func (c *retryClient) Patch(pt api.PatchType) *client.Request {
+	return c.RESTClient.Patch(pt).Retry(c.maxRetries, c.delay)
+}
+
+func (c *retryClient) Delete() *client.Request {
+	return c.RESTClient.Delete().Retry(c.maxRetries, c.delay)
+}
+
+func (c *retryClient) Put() *client.Request {
+	return c.RESTClient.Put().Retry(c.maxRetries, c.delay)
+}
+
+// middlewareClient sends every request created by a RESTClient through a chain of
+// middleware.
+type middlewareClient struct {
+	RESTClient
+	middleware []Middleware
+}
+
+// NewMiddlewareClient returns a RESTClient whose requests are sent through
+// middleware. The first middleware is the outermost: it sees each request first
+// and each response last.
+func NewMiddlewareClient(c RESTClient, middleware ...Middleware) RESTClient {
+	return &middlewareClient{c, middleware}
+}
+
+func (c *middlewareClient) wrap(next client.HTTPClient) client.HTTPClient {
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		next = c.middleware[i](next)
+	}
+	return next
+}
+
+func (c *middlewareClient) Get() *client.Request {
+	return c.RESTClient.Get().WrapClient(c.wrap)
+}
+
+func (c *middlewareClient) Post() *client.Request {
+	return c.RESTClient.Post().WrapClient(c.wrap)
+}
+
+func (c *middlewareClient) Patch(pt api.PatchType) *client.Request {
+	return c.RESTClient.Patch(pt).WrapClient(c.wrap)
+}
+
+func (c *middlewareClient) Delete() *client.Request {
+	return c.RESTClient.Delete().WrapClient(c.wrap)
+}
+
+func (c *middlewareClient) Put() *client.Request {
+	return c.RESTClient.Put().WrapClient(c.wrap)
+}
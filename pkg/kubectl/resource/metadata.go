@@ -0,0 +1,101 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/meta"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+// SetLabels returns a VisitorFunc that labels each object as described by
+// LabelObject. Objects that have not been retrieved are left untouched.
+func SetLabels(labels map[string]string, remove []string, overwrite bool) VisitorFunc {
+	return func(info *Info) error {
+		if info.Object == nil {
+			return nil
+		}
+		return LabelObject(info.Object, labels, remove, overwrite)
+	}
+}
+
+// SetAnnotations returns a VisitorFunc that annotates each object as described by
+// AnnotateObject. Objects that have not been retrieved are left untouched.
+func SetAnnotations(annotations map[string]string, remove []string, overwrite bool) VisitorFunc {
+	return func(info *Info) error {
+		if info.Object == nil {
+			return nil
+		}
+		return AnnotateObject(info.Object, annotations, remove, overwrite)
+	}
+}
+
+// LabelObject adds labels to obj and removes the labels whose keys are in remove.
+// Unless overwrite is true, it is an error to set a label obj already has, in which
+// case obj is not changed.
+func LabelObject(obj runtime.Object, labels map[string]string, remove []string, overwrite bool) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	updated, err := updateMetadataMap("label", accessor.Labels(), labels, remove, overwrite)
+	if err != nil {
+		return err
+	}
+	accessor.SetLabels(updated)
+	return nil
+}
+
+// AnnotateObject adds annotations to obj and removes the annotations whose keys are
+// in remove. Unless overwrite is true, it is an error to set an annotation obj
+// already has, in which case obj is not changed.
+func AnnotateObject(obj runtime.Object, annotations map[string]string, remove []string, overwrite bool) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	updated, err := updateMetadataMap("annotation", accessor.Annotations(), annotations, remove, overwrite)
+	if err != nil {
+		return err
+	}
+	accessor.SetAnnotations(updated)
+	return nil
+}
+
+// updateMetadataMap returns a copy of current with set applied and the keys in remove
+// deleted.
+func updateMetadataMap(kind string, current, set map[string]string, remove []string, overwrite bool) (map[string]string, error) {
+	if !overwrite {
+		for key := range set {
+			if value, found := current[key]; found {
+				return nil, fmt.Errorf("%s %q already has a value (%s) and would be overwritten", kind, key, value)
+			}
+		}
+	}
+	updated := map[string]string{}
+	for key, value := range current {
+		updated[key] = value
+	}
+	for key, value := range set {
+		updated[key] = value
+	}
+	for _, key := range remove {
+		delete(updated, key)
+	}
+	return updated, nil
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+func TestSetLabels(t *testing.T) {
+	tests := map[string]struct {
+		Labels    map[string]string
+		Remove    []string
+		Overwrite bool
+		Expect    map[string]string
+		Err       bool
+	}{
+		"add": {
+			Labels: map[string]string{"c": "d"},
+			Expect: map[string]string{"a": "b", "c": "d"},
+		},
+		"conflict": {
+			Labels: map[string]string{"a": "c"},
+			Expect: map[string]string{"a": "b"},
+			Err:    true,
+		},
+		"overwrite": {
+			Labels:    map[string]string{"a": "c"},
+			Overwrite: true,
+			Expect:    map[string]string{"a": "c"},
+		},
+		"remove": {
+			Remove: []string{"a", "missing"},
+			Expect: map[string]string{},
+		},
+	}
+	for k, test := range tests {
+		pod := &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", Labels: map[string]string{"a": "b"}}}
+		err := SetLabels(test.Labels, test.Remove, test.Overwrite)(&Info{Object: pod})
+		if test.Err != (err != nil) {
+			t.Errorf("%s: unexpected error: %v", k, err)
+		}
+		if !reflect.DeepEqual(test.Expect, pod.Labels) {
+			t.Errorf("%s: unexpected labels: %v", k, pod.Labels)
+		}
+	}
+
+	if err := SetLabels(map[string]string{"a": "b"}, nil, false)(&Info{Name: "foo"}); err != nil {
+		t.Errorf("unexpected error for an object that has not been retrieved: %v", err)
+	}
+}
+
+func TestSetAnnotations(t *testing.T) {
+	svc := &api.Service{ObjectMeta: api.ObjectMeta{Name: "baz"}}
+	if err := SetAnnotations(map[string]string{"owner": "web"}, nil, false)(&Info{Object: svc}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(map[string]string{"owner": "web"}, svc.Annotations) {
+		t.Errorf("unexpected annotations: %v", svc.Annotations)
+	}
+	err := SetAnnotations(map[string]string{"owner": "api"}, nil, false)(&Info{Object: svc})
+	if err == nil || !strings.Contains(err.Error(), `annotation "owner"`) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestBuilderDecorate(t *testing.T) {
+	pods, _ := testData()
+	b := NewBuilder(latest.RESTMapper, api.Scheme, fakeClient()).
+		Stream(strings.NewReader(runtime.EncodeOrDie(latest.Codec, pods)), "STDIN").
+		Flatten().
+		Decorate(SetLabels(map[string]string{"env": "test"}, nil, false))
+
+	infos, err := b.Do().Infos()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("unexpected infos: %#v", infos)
+	}
+	for _, info := range infos {
+		if labels := info.Object.(*api.Pod).Labels; labels["env"] != "test" {
+			t.Errorf("%s: unexpected labels: %v", info.Name, labels)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics records the requests a Helper sends to the server.
+type Metrics interface {
+	// ObserveRequest is called after every attempt to send a request, including
+	// retries, with the resource and HTTP method of the request, the status code of
+	// the response or 0 if none was received, and how long the attempt took.
+	ObserveRequest(resource, verb string, code int, latency time.Duration)
+}
+
+// ObserveMetrics returns a Middleware that records every request for resource
+// into metrics.
+func ObserveMetrics(metrics Metrics, resource string) Middleware {
+	return ObserveLatency(func(req *http.Request, resp *http.Response, err error, latency time.Duration) {
+		code := 0
+		if err == nil {
+			code = resp.StatusCode
+		}
+		metrics.ObserveRequest(resource, req.Method, code, latency)
+	})
+}
+
+// PrometheusMetrics implements Metrics with a counter of requests and a histogram of
+// their latencies in microseconds, both labeled by resource, verb, and status code.
+// It is a prometheus.Collector, so it can be registered as is.
+type PrometheusMetrics struct {
+	Requests *prometheus.CounterVec
+	Latency  *prometheus.HistogramVec
+}
+
+// NewPrometheusMetrics creates a PrometheusMetrics whose metric names are prefixed
+// with namespace, if it is not empty.
+func NewPrometheusMetrics(namespace string) *PrometheusMetrics {
+	labels := []string{"resource", "verb", "code"}
+	return &PrometheusMetrics{
+		Requests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "resource_helper",
+				Name:      "request_count",
+				Help:      "Counter of requests sent by resource helpers broken out for each resource, verb, and HTTP response code.",
+			},
+			labels,
+		),
+		Latency: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: "resource_helper",
+				Name:      "request_latency_microseconds",
+				Help:      "Request latency distribution in microseconds for each resource, verb, and HTTP response code.",
+				// Use buckets ranging from 1 ms to 16 seconds.
+				Buckets: prometheus.ExponentialBuckets(1000, 2.0, 15),
+			},
+			labels,
+		),
+	}
+}
+
+// ObserveRequest implements Metrics.
+func (m *PrometheusMetrics) ObserveRequest(resource, verb string, code int, latency time.Duration) {
+	status := strconv.Itoa(code)
+	m.Requests.WithLabelValues(resource, verb, status).Inc()
+	m.Latency.WithLabelValues(resource, verb, status).Observe(float64(latency / time.Microsecond))
+}
+
+// Describe implements prometheus.Collector.
+func (m *PrometheusMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.Requests.Describe(ch)
+	m.Latency.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *PrometheusMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.Requests.Collect(ch)
+	m.Latency.Collect(ch)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/testapi"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+)
+
+type observedRequest struct {
+	Resource, Verb string
+	Code           int
+}
+
+type fakeMetrics []observedRequest
+
+func (m *fakeMetrics) ObserveRequest(resource, verb string, code int, latency time.Duration) {
+	*m = append(*m, observedRequest{resource, verb, code})
+}
+
+func TestHelperWithMetrics(t *testing.T) {
+	responses := []*http.Response{
+		{StatusCode: http.StatusOK, Body: objBody(&api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}})},
+		{StatusCode: http.StatusNotFound, Body: objBody(&api.Status{Status: api.StatusFailure, Code: http.StatusNotFound, Reason: api.StatusReasonNotFound})},
+	}
+	client := &client.FakeRESTClient{
+		Codec: testapi.Codec(),
+		Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			if len(responses) == 0 {
+				return nil, errors.New("connection refused")
+			}
+			resp := responses[0]
+			responses = responses[1:]
+			return resp, nil
+		}),
+	}
+	metrics := &fakeMetrics{}
+	modifier := (&Helper{
+		RESTClient:      client,
+		Resource:        "pods",
+		NamespaceScoped: true,
+	}).WithMetrics(metrics)
+
+	modifier.Get("bar", "foo")
+	modifier.Delete("bar", "foo")
+	modifier.Get("bar", "foo")
+
+	expected := fakeMetrics{
+		{"pods", "GET", http.StatusOK},
+		{"pods", "DELETE", http.StatusNotFound},
+		{"pods", "GET", 0},
+	}
+	if !reflect.DeepEqual(expected, *metrics) {
+		t.Errorf("unexpected requests: %v", *metrics)
+	}
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	metrics := NewPrometheusMetrics("test")
+	metrics.ObserveRequest("pods", "GET", http.StatusOK, time.Millisecond)
+	metrics.ObserveRequest("pods", "GET", http.StatusOK, 3*time.Millisecond)
+	metrics.ObserveRequest("services", "DELETE", http.StatusNotFound, time.Second)
+
+	m := &dto.Metric{}
+	if err := metrics.Requests.WithLabelValues("pods", "GET", "200").Write(m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.GetCounter().GetValue() != 2 {
+		t.Errorf("unexpected count: %v", m)
+	}
+	m = &dto.Metric{}
+	if err := metrics.Latency.WithLabelValues("services", "DELETE", "404").Write(m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h := m.GetHistogram(); h.GetSampleCount() != 1 || h.GetSampleSum() != 1000000 {
+		t.Errorf("unexpected latency: %v", m)
+	}
+
+	ch := make(chan prometheus.Metric, 10)
+	metrics.Collect(ch)
+	close(ch)
+	if len(ch) != 4 {
+		t.Errorf("expected a counter and a histogram for each label set, got %d metrics", len(ch))
+	}
+}
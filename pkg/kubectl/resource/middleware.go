@@ -0,0 +1,153 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+// Middleware wraps the HTTPClient that sends a request to the server, for example to
+// log or modify the request or to measure how long the server takes to respond. It
+// is invoked for every attempt to send a request, including retries.
+type Middleware func(next client.HTTPClient) client.HTTPClient
+
+// SetHeaders returns a Middleware that sets headers on every request.
+func SetHeaders(headers http.Header) Middleware {
+	return func(next client.HTTPClient) client.HTTPClient {
+		return client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header == nil {
+				req.Header = http.Header{}
+			}
+			for key, values := range headers {
+				req.Header[key] = values
+			}
+			return next.Do(req)
+		})
+	}
+}
+
+// AppendUserAgent returns a Middleware that appends suffix, such as the name and version
+// of the tool sending the requests, to the User-Agent header of every request, so that
+// the traffic of different tools can be told apart. A suffix the header already ends
+// with is not appended again. Clients created from a client.Config set the user agent
+// the config specifies before middleware is invoked.
+func AppendUserAgent(suffix string) Middleware {
+	return func(next client.HTTPClient) client.HTTPClient {
+		return client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header == nil {
+				req.Header = http.Header{}
+			}
+			agent := req.Header.Get("User-Agent")
+			switch {
+			case len(agent) == 0:
+				req.Header.Set("User-Agent", suffix)
+			case agent != suffix && !strings.HasSuffix(agent, " "+suffix):
+				req.Header.Set("User-Agent", agent+" "+suffix)
+			}
+			return next.Do(req)
+		})
+	}
+}
+
+// RequestIDHeader is the header that carries the ID of the operation a request is sent
+// for, so that the requests of one operation can be correlated in the server's logs.
+const RequestIDHeader = "X-Request-Id"
+
+// NewRequestID returns a new, random request ID.
+func NewRequestID() string {
+	return string(util.NewUUID())
+}
+
+// SetRequestID returns a Middleware that sets the RequestIDHeader of every request to id.
+func SetRequestID(id string) Middleware {
+	return SetHeaders(http.Header{RequestIDHeader: []string{id}})
+}
+
+// ObserveLatency returns a Middleware that calls observe with every request, its
+// response or error, and how long the server took to respond.
+func ObserveLatency(observe func(req *http.Request, resp *http.Response, err error, latency time.Duration)) Middleware {
+	return func(next client.HTTPClient) client.HTTPClient {
+		return client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.Do(req)
+			observe(req, resp, err, time.Since(start))
+			return resp, err
+		})
+	}
+}
+
+// LogRequests returns a Middleware that logs the method, URL, response status, and
+// latency of every request with logf, for example glog.Infof.
+func LogRequests(logf func(format string, args ...interface{})) Middleware {
+	return ObserveLatency(func(req *http.Request, resp *http.Response, err error, latency time.Duration) {
+		if err != nil {
+			logf("%s %s failed in %v: %v", req.Method, req.URL, latency, err)
+			return
+		}
+		logf("%s %s %d in %v", req.Method, req.URL, resp.StatusCode, latency)
+	})
+}
+
+// Gzip returns a Middleware that asks the server to compress responses with gzip
+// and decompresses them before they are decoded. Requests that already set an
+// Accept-Encoding header, and responses the server did not compress, are passed
+// through untouched.
+func Gzip() Middleware {
+	return func(next client.HTTPClient) client.HTTPClient {
+		return client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header == nil {
+				req.Header = http.Header{}
+			}
+			if len(req.Header.Get("Accept-Encoding")) != 0 {
+				return next.Do(req)
+			}
+			req.Header.Set("Accept-Encoding", "gzip")
+			resp, err := next.Do(req)
+			if err != nil || resp.Header.Get("Content-Encoding") != "gzip" {
+				return resp, err
+			}
+			reader, err := gzip.NewReader(resp.Body)
+			if err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+			resp.Body = &gzipBody{reader, resp.Body}
+			resp.Header.Del("Content-Encoding")
+			resp.Header.Del("Content-Length")
+			resp.ContentLength = -1
+			return resp, nil
+		})
+	}
+}
+
+// gzipBody reads the decompressed contents of a response body and closes both.
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
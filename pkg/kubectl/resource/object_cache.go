@@ -0,0 +1,287 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/meta"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client/cache"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+)
+
+// ObjectCache keeps a local copy of the objects of a resource, so that commands that
+// repeatedly look at the same objects, such as to follow the progress of a rolling
+// update, can read them without listing them from the server every time. The objects
+// are listed once when the cache starts and then kept up to date with a RetryWatcher.
+// If a resync period is set, the objects are also listed again at that interval and
+// the copy replaced, which repairs anything the watch may have missed.
+//
+// Objects returned by the cache are shared with it and must not be modified.
+type ObjectCache struct {
+	listFn       ListFunc
+	watchFn      WatchFunc
+	versioner    runtime.ResourceVersioner
+	resyncPeriod time.Duration
+
+	store cache.Indexer
+
+	lock            sync.Mutex
+	resourceVersion string
+	started         bool
+	stopOnce        sync.Once
+	stop            chan struct{}
+	done            chan struct{}
+}
+
+// NewObjectCache creates a cache of the objects listed by listFn and watched with
+// watchFn, tracking their resource versions with versioner. If resyncPeriod is not
+// zero, the objects are listed again at that interval.
+func NewObjectCache(listFn ListFunc, watchFn WatchFunc, versioner runtime.ResourceVersioner, resyncPeriod time.Duration) *ObjectCache {
+	return &ObjectCache{
+		listFn:       listFn,
+		watchFn:      watchFn,
+		versioner:    versioner,
+		resyncPeriod: resyncPeriod,
+
+		store: cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{"namespace": namespaceIndexFunc}),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
+
+// Start lists the objects and then keeps them up to date in the background until
+// Stop is called. It returns once the first list has been stored, or with its
+// error if it failed, in which case the cache is not started. A cache can only be
+// started once.
+func (c *ObjectCache) Start() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.started {
+		return fmt.Errorf("the cache has already been started")
+	}
+	if err := c.replace(); err != nil {
+		return err
+	}
+	c.started = true
+	go c.run()
+	return nil
+}
+
+// Stop stops keeping the objects up to date. The objects already in the cache can
+// still be read.
+func (c *ObjectCache) Stop() {
+	c.lock.Lock()
+	started := c.started
+	c.lock.Unlock()
+	if !started {
+		return
+	}
+	c.stopOnce.Do(func() { close(c.stop) })
+	<-c.done
+}
+
+// ResourceVersion returns the resource version of the newest change the cache holds.
+func (c *ObjectCache) ResourceVersion() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.resourceVersion
+}
+
+// Get returns the named object, and false if the cache does not hold it.
+func (c *ObjectCache) Get(namespace, name string) (runtime.Object, bool) {
+	key := name
+	if len(namespace) > 0 {
+		key = namespace + "/" + name
+	}
+	item, exists, err := c.store.GetByKey(key)
+	if err != nil || !exists {
+		return nil, false
+	}
+	return item.(runtime.Object), true
+}
+
+// List returns the objects in namespace, or in every namespace if namespace is
+// empty, whose labels match selector, ordered by namespace and name.
+func (c *ObjectCache) List(namespace string, selector labels.Selector) []runtime.Object {
+	var items []interface{}
+	if len(namespace) == 0 {
+		items = c.store.List()
+	} else {
+		items, _ = c.store.Index("namespace", namespaceKey(namespace))
+	}
+	keys := []string{}
+	byKey := map[string]runtime.Object{}
+	for _, item := range items {
+		obj := item.(runtime.Object)
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			continue
+		}
+		if selector != nil && !selector.Matches(labels.Set(accessor.Labels())) {
+			continue
+		}
+		key := accessor.Namespace() + "/" + accessor.Name()
+		keys = append(keys, key)
+		byKey[key] = obj
+	}
+	sort.Strings(keys)
+	objects := []runtime.Object{}
+	for _, key := range keys {
+		objects = append(objects, byKey[key])
+	}
+	return objects
+}
+
+// namespaceKey is passed to the namespace index of an ObjectCache to look up the
+// objects of a namespace.
+type namespaceKey string
+
+// namespaceIndexFunc indexes objects by namespace, and looks up a namespaceKey as
+// the namespace it names.
+func namespaceIndexFunc(obj interface{}) (string, error) {
+	if namespace, ok := obj.(namespaceKey); ok {
+		return string(namespace), nil
+	}
+	return cache.MetaNamespaceIndexFunc(obj)
+}
+
+// replace lists the objects and replaces the contents of the cache with them. It
+// must be called with the lock held.
+func (c *ObjectCache) replace() error {
+	list, err := c.listFn()
+	if err != nil {
+		return err
+	}
+	items, err := runtime.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	replacement := []interface{}{}
+	for _, item := range items {
+		replacement = append(replacement, item)
+	}
+	if err := c.store.Replace(replacement); err != nil {
+		return err
+	}
+	if version, err := c.versioner.ResourceVersion(list); err == nil {
+		c.resourceVersion = version
+	}
+	return nil
+}
+
+// run applies the changes reported by watches to the cache until the cache is
+// stopped, listing the objects again every resync period and whenever the server
+// no longer has the changes needed to resume watching.
+func (c *ObjectCache) run() {
+	defer close(c.done)
+	defer util.HandleCrash()
+
+	var resync <-chan time.Time
+	if c.resyncPeriod > 0 {
+		ticker := time.NewTicker(c.resyncPeriod)
+		defer ticker.Stop()
+		resync = ticker.C
+	}
+	w := NewRetryWatcher(c.ResourceVersion(), nil, c.watchFn, nil, c.versioner, 0)
+	for {
+		select {
+		case <-c.stop:
+			w.Stop()
+			return
+		case <-resync:
+			w.Stop()
+		case event, ok := <-w.ResultChan():
+			if ok {
+				c.apply(event)
+				continue
+			}
+		}
+		if !c.resync() {
+			return
+		}
+		w = NewRetryWatcher(c.ResourceVersion(), nil, c.watchFn, nil, c.versioner, 0)
+	}
+}
+
+// resync replaces the contents of the cache with a new list of the objects,
+// retrying until it succeeds. It returns false if the cache was stopped first.
+func (c *ObjectCache) resync() bool {
+	for {
+		c.lock.Lock()
+		err := c.replace()
+		c.lock.Unlock()
+		if err == nil {
+			return true
+		}
+		glog.V(4).Infof("Unable to list the cached objects, retrying in %v: %v", retryWatchDelay, err)
+		select {
+		case <-c.stop:
+			return false
+		case <-time.After(retryWatchDelay):
+		}
+	}
+}
+
+// apply records a change reported by a watch.
+func (c *ObjectCache) apply(event watch.Event) {
+	var err error
+	switch event.Type {
+	case watch.Added:
+		err = c.store.Add(event.Object)
+	case watch.Modified:
+		err = c.store.Update(event.Object)
+	case watch.Deleted:
+		err = c.store.Delete(event.Object)
+	case watch.Error:
+		glog.V(4).Infof("Error while watching the cached objects: %#v", event.Object)
+		return
+	default:
+		return
+	}
+	if err != nil {
+		glog.V(4).Infof("Unable to cache a watched object: %v", err)
+		return
+	}
+	if version, err := c.versioner.ResourceVersion(event.Object); err == nil && len(version) > 0 {
+		c.lock.Lock()
+		c.resourceVersion = version
+		c.lock.Unlock()
+	}
+}
+
+// Cache creates an ObjectCache of the resources matching the selectors, listing
+// them again every resyncPeriod if it is not zero. The cache must be started with
+// Start.
+func (m *Helper) Cache(namespace, apiVersion string, labelSelector labels.Selector, fieldSelector fields.Selector, resyncPeriod time.Duration) *ObjectCache {
+	listFn := func() (runtime.Object, error) {
+		return m.List(namespace, apiVersion, labelSelector, fieldSelector)
+	}
+	watchFn := func(resourceVersion string) (watch.Interface, error) {
+		return m.Watch(namespace, resourceVersion, apiVersion, labelSelector, fieldSelector)
+	}
+	return NewObjectCache(listFn, watchFn, m.Versioner, resyncPeriod)
+}
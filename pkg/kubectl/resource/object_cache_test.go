@@ -0,0 +1,188 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/testapi"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+)
+
+// fakeLists returns the lists it holds in turn, repeating the last one.
+type fakeLists struct {
+	lock  sync.Mutex
+	lists []*api.PodList
+	calls int
+}
+
+func (f *fakeLists) List() (runtime.Object, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.calls++
+	if len(f.lists) == 0 {
+		return nil, fmt.Errorf("unable to list")
+	}
+	list := f.lists[0]
+	if len(f.lists) > 1 {
+		f.lists = f.lists[1:]
+	}
+	return list, nil
+}
+
+func (f *fakeLists) Calls() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.calls
+}
+
+func cachedPod(namespace, name, resourceVersion string, labels map[string]string) api.Pod {
+	return api.Pod{ObjectMeta: api.ObjectMeta{Namespace: namespace, Name: name, ResourceVersion: resourceVersion, Labels: labels}}
+}
+
+func cachedNames(objs []runtime.Object) []string {
+	names := []string{}
+	for _, obj := range objs {
+		names = append(names, obj.(*api.Pod).Name)
+	}
+	return names
+}
+
+// eventually fails the test if condition does not become true within a second.
+func eventually(t *testing.T, description string, condition func() bool) {
+	for deadline := time.Now().Add(time.Second); !condition(); {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", description)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestObjectCache(t *testing.T) {
+	lists := &fakeLists{lists: []*api.PodList{{
+		ListMeta: api.ListMeta{ResourceVersion: "10"},
+		Items: []api.Pod{
+			cachedPod("test", "foo", "5", map[string]string{"app": "web"}),
+			cachedPod("test", "bar", "6", map[string]string{"app": "db"}),
+			cachedPod("other", "baz", "7", map[string]string{"app": "web"}),
+		},
+	}}}
+	watches := newFakeWatches()
+	c := NewObjectCache(lists.List, watches.Watch, testapi.MetadataAccessor(), 0)
+	if err := c.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer c.Stop()
+	if err := c.Start(); err == nil {
+		t.Errorf("expected an error when starting the cache twice")
+	}
+
+	if obj, found := c.Get("test", "foo"); !found || obj.(*api.Pod).Name != "foo" {
+		t.Errorf("unexpected object: %#v %t", obj, found)
+	}
+	if _, found := c.Get("other", "foo"); found {
+		t.Errorf("expected no object in another namespace")
+	}
+	selector := labels.SelectorFromSet(labels.Set{"app": "web"})
+	if names := cachedNames(c.List("", selector)); !reflect.DeepEqual(names, []string{"baz", "foo"}) {
+		t.Errorf("unexpected objects: %v", names)
+	}
+	if names := cachedNames(c.List("test", nil)); !reflect.DeepEqual(names, []string{"bar", "foo"}) {
+		t.Errorf("unexpected objects: %v", names)
+	}
+
+	w := <-watches.watches
+	if versions := watches.Versions(); !reflect.DeepEqual(versions, []string{"10"}) {
+		t.Errorf("expected the watch to start from the list: %v", versions)
+	}
+	added := cachedPod("test", "qux", "11", nil)
+	modified := cachedPod("test", "foo", "12", map[string]string{"app": "db"})
+	deleted := cachedPod("test", "bar", "13", nil)
+	w.Add(&added)
+	w.Modify(&modified)
+	w.Delete(&deleted)
+	eventually(t, "the watched changes", func() bool { return c.ResourceVersion() == "13" })
+	if names := cachedNames(c.List("test", nil)); !reflect.DeepEqual(names, []string{"foo", "qux"}) {
+		t.Errorf("unexpected objects: %v", names)
+	}
+	if names := cachedNames(c.List("", selector)); !reflect.DeepEqual(names, []string{"baz"}) {
+		t.Errorf("unexpected objects: %v", names)
+	}
+	if calls := lists.Calls(); calls != 1 {
+		t.Errorf("expected a single list, got %d", calls)
+	}
+}
+
+func TestObjectCacheExpired(t *testing.T) {
+	lists := &fakeLists{lists: []*api.PodList{
+		{ListMeta: api.ListMeta{ResourceVersion: "10"}, Items: []api.Pod{cachedPod("test", "foo", "5", nil)}},
+		{ListMeta: api.ListMeta{ResourceVersion: "20"}, Items: []api.Pod{cachedPod("test", "bar", "15", nil)}},
+	}}
+	watches := newFakeWatches()
+	c := NewObjectCache(lists.List, watches.Watch, testapi.MetadataAccessor(), 0)
+	if err := c.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer c.Stop()
+
+	w := <-watches.watches
+	w.Error(&api.Status{Status: api.StatusFailure, Code: http.StatusGone})
+	<-watches.watches
+	if names := cachedNames(c.List("", nil)); !reflect.DeepEqual(names, []string{"bar"}) {
+		t.Errorf("expected the objects to be listed again: %v", names)
+	}
+	if versions := watches.Versions(); !reflect.DeepEqual(versions, []string{"10", "20"}) {
+		t.Errorf("unexpected resource versions: %v", versions)
+	}
+}
+
+func TestObjectCacheResync(t *testing.T) {
+	lists := &fakeLists{lists: []*api.PodList{
+		{ListMeta: api.ListMeta{ResourceVersion: "10"}, Items: []api.Pod{cachedPod("test", "foo", "5", nil)}},
+		{ListMeta: api.ListMeta{ResourceVersion: "20"}, Items: []api.Pod{cachedPod("test", "bar", "15", nil)}},
+	}}
+	watchFn := func(string) (watch.Interface, error) {
+		return watch.NewFake(), nil
+	}
+	c := NewObjectCache(lists.List, watchFn, testapi.MetadataAccessor(), 10*time.Millisecond)
+	if err := c.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer c.Stop()
+
+	eventually(t, "a resync", func() bool { return lists.Calls() > 1 })
+	eventually(t, "the resynced objects", func() bool { return c.ResourceVersion() == "20" })
+	if names := cachedNames(c.List("", nil)); !reflect.DeepEqual(names, []string{"bar"}) {
+		t.Errorf("expected the objects to be replaced: %v", names)
+	}
+}
+
+func TestObjectCacheListFails(t *testing.T) {
+	c := NewObjectCache((&fakeLists{}).List, newFakeWatches().Watch, testapi.MetadataAccessor(), 0)
+	if err := c.Start(); err == nil {
+		t.Errorf("expected the list error to be returned")
+	}
+	c.Stop()
+}
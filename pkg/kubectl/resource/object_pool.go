@@ -0,0 +1,80 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+// ObjectPool is a runtime.Codec that decodes objects of a single type into
+// instances drawn from a sync.Pool, reducing allocations for consumers of high
+// rate watches. Objects of any other type are decoded by the wrapped codec as
+// usual.
+//
+// Pooled objects are owned by the caller only until they are handed back with
+// Release. After an object is released it may be overwritten by the next decode
+// at any time, so callers must not retain a released object or anything it
+// references (maps, slices, or nested structs). Consumers that store objects,
+// for instance in a cache, must not use a pool or must copy the objects first.
+type ObjectPool struct {
+	runtime.Codec
+
+	objType reflect.Type
+	pool    sync.Pool
+}
+
+// NewObjectPool creates a pool of objects with the same type as example that are
+// decoded with codec.
+func NewObjectPool(codec runtime.Codec, example runtime.Object) *ObjectPool {
+	objType := reflect.TypeOf(example).Elem()
+	p := &ObjectPool{
+		Codec:   codec,
+		objType: objType,
+	}
+	p.pool.New = func() interface{} {
+		return reflect.New(objType).Interface()
+	}
+	return p
+}
+
+// Decode implements runtime.Decoder, decoding into a pooled object when the data
+// holds an object of the pool's type.
+func (p *ObjectPool) Decode(data []byte) (runtime.Object, error) {
+	obj := p.pool.Get().(runtime.Object)
+	if err := p.Codec.DecodeInto(data, obj); err != nil {
+		p.Release(obj)
+		return p.Codec.Decode(data)
+	}
+	return obj, nil
+}
+
+// Release returns obj to the pool so that it can be reused by a later decode.
+// Objects of other types, and nil, are ignored.
+func (p *ObjectPool) Release(obj runtime.Object) {
+	if obj == nil {
+		return
+	}
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Type() != p.objType {
+		return
+	}
+	v.Elem().Set(reflect.Zero(p.objType))
+	p.pool.Put(obj)
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/testapi"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+	watchjson "github.com/GoogleCloudPlatform/kubernetes/pkg/watch/json"
+)
+
+func TestObjectPoolDecode(t *testing.T) {
+	pool := NewObjectPool(testapi.Codec(), &api.Pod{})
+
+	obj, err := pool.Decode([]byte(runtime.EncodeOrDie(testapi.Codec(), &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", Labels: map[string]string{"a": "b"}}})))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pod := obj.(*api.Pod)
+	if pod.Name != "foo" || pod.Labels["a"] != "b" {
+		t.Errorf("unexpected object: %#v", pod)
+	}
+	pool.Release(pod)
+	if pod.Name != "" || pod.Labels != nil {
+		t.Errorf("expected released object to be cleared: %#v", pod)
+	}
+
+	obj, err = pool.Decode([]byte(runtime.EncodeOrDie(testapi.Codec(), &api.Pod{ObjectMeta: api.ObjectMeta{Name: "bar"}})))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pod := obj.(*api.Pod); pod.Name != "bar" || pod.Labels != nil {
+		t.Errorf("unexpected object: %#v", pod)
+	}
+
+	obj, err = pool.Decode([]byte(runtime.EncodeOrDie(testapi.Codec(), &api.Status{Status: api.StatusFailure})))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status, ok := obj.(*api.Status); !ok || status.Status != api.StatusFailure {
+		t.Errorf("unexpected object: %#v", obj)
+	}
+	pool.Release(obj)
+}
+
+func TestHelperWatchObjectPool(t *testing.T) {
+	client := &client.FakeRESTClient{
+		Codec: testapi.Codec(),
+		Resp: &http.Response{
+			StatusCode: http.StatusOK,
+			Body: stringBody(watchBody(
+				watch.Event{Type: watch.Added, Object: &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}}},
+			)),
+		},
+	}
+	modifier := &Helper{
+		RESTClient:      client,
+		NamespaceScoped: true,
+		ObjectPool:      NewObjectPool(testapi.Codec(), &api.Pod{}),
+	}
+	w, err := modifier.Watch("bar", "1", testapi.Version(), labels.Everything(), fields.Everything())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	event := <-w.ResultChan()
+	if pod, ok := event.Object.(*api.Pod); !ok || pod.Name != "foo" {
+		t.Fatalf("unexpected event: %#v", event)
+	}
+	modifier.ReleaseObject(event.Object)
+	w.Stop()
+}
+
+func watchEvents(n int) []byte {
+	events := make([]watch.Event, n)
+	for i := range events {
+		events[i] = watch.Event{
+			Type: watch.Modified,
+			Object: &api.Pod{
+				ObjectMeta: api.ObjectMeta{Name: "foo", Namespace: "bar", ResourceVersion: "10"},
+				Spec:       api.PodSpec{RestartPolicy: api.RestartPolicyAlways, DNSPolicy: api.DNSClusterFirst},
+			},
+		}
+	}
+	return []byte(watchBody(events...))
+}
+
+func benchmarkWatchDecode(b *testing.B, codec runtime.Codec, release func(runtime.Object)) {
+	data := watchEvents(b.N)
+	d := watchjson.NewDecoder(ioutil.NopCloser(bytes.NewReader(data)), codec)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, obj, err := d.Decode()
+		if err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+		release(obj)
+	}
+}
+
+func BenchmarkWatchDecode(b *testing.B) {
+	benchmarkWatchDecode(b, testapi.Codec(), func(runtime.Object) {})
+}
+
+func BenchmarkWatchDecodeObjectPool(b *testing.B) {
+	pool := NewObjectPool(testapi.Codec(), &api.Pod{})
+	benchmarkWatchDecode(b, pool, pool.Release)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"sort"
+	"sync"
+)
+
+// KindOrder is the order in which kinds should be created so that the objects they
+// depend on already exist: namespaces first, then the policy and configuration their
+// contents use, then services, and finally the workloads that consume all of them.
+// Objects should be deleted in the reverse order.
+var KindOrder = []string{
+	"Namespace",
+	"ResourceQuota",
+	"LimitRange",
+	"ServiceAccount",
+	"Secret",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"Service",
+	"Endpoints",
+	"PodTemplate",
+	"ReplicationController",
+	"Pod",
+}
+
+// OrderedVisitor visits the items of a visitor sorted by the position of their kind in
+// Order, or in the reverse of that order if Reverse is set. Kinds missing from Order
+// are visited after all the listed kinds (before them when reversed), and items of the
+// same kind keep the order in which they were found. All the items are read before the
+// first one is visited.
+//
+// If Limit is greater than one, the items sharing a position in Order are visited
+// concurrently, as by a ParallelVisitor with that limit, and all of them are visited
+// before the items of the next position.
+type OrderedVisitor struct {
+	Visitor
+	Order   []string
+	Reverse bool
+	Limit   int
+}
+
+// NewOrderedVisitor creates a visitor that visits the items of v sorted by kind as
+// described by OrderedVisitor, with at most limit items of the same position in order
+// visited concurrently.
+func NewOrderedVisitor(v Visitor, order []string, reverse bool, limit int) Visitor {
+	return OrderedVisitor{v, order, reverse, limit}
+}
+
+// Visit implements Visitor. Items that were read before the underlying visitor
+// failed are still visited, and the failure is returned afterwards.
+func (v OrderedVisitor) Visit(fn VisitorFunc) error {
+	var lock sync.Mutex
+	infos := []*Info{}
+	err := v.Visitor.Visit(func(info *Info) error {
+		lock.Lock()
+		defer lock.Unlock()
+		infos = append(infos, info)
+		return nil
+	})
+
+	priority := map[string]int{}
+	for i, kind := range v.Order {
+		priority[kind] = i
+	}
+	rank := func(info *Info) int {
+		if info.Mapping != nil {
+			if i, ok := priority[info.Mapping.Kind]; ok {
+				return i
+			}
+		}
+		return len(v.Order)
+	}
+	sort.Stable(byRank{infos, rank, v.Reverse})
+
+	for start := 0; start < len(infos); {
+		group := VisitorList{}
+		end := start
+		for ; end < len(infos) && rank(infos[end]) == rank(infos[start]); end++ {
+			group = append(group, infos[end])
+		}
+		if err := NewParallelVisitor(group, v.Limit).Visit(fn); err != nil {
+			return err
+		}
+		start = end
+	}
+	return err
+}
+
+type byRank struct {
+	infos   []*Info
+	rank    func(*Info) int
+	reverse bool
+}
+
+func (s byRank) Len() int      { return len(s.infos) }
+func (s byRank) Swap(i, j int) { s.infos[i], s.infos[j] = s.infos[j], s.infos[i] }
+func (s byRank) Less(i, j int) bool {
+	if s.reverse {
+		return s.rank(s.infos[i]) > s.rank(s.infos[j])
+	}
+	return s.rank(s.infos[i]) < s.rank(s.infos[j])
+}
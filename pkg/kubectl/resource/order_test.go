@@ -0,0 +1,156 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/meta"
+)
+
+func TestOrderedVisitor(t *testing.T) {
+	info := func(kind, name string) *Info {
+		mapping, err := latest.RESTMapper.RESTMapping(kind)
+		if err != nil {
+			mapping = &meta.RESTMapping{Kind: kind}
+		}
+		return &Info{Name: name, Mapping: mapping}
+	}
+	infos := VisitorList{
+		info("ReplicationController", "rc"),
+		info("Widget", "widget"),
+		info("Service", "svc1"),
+		info("Namespace", "ns"),
+		info("Pod", "pod"),
+		info("Secret", "secret"),
+		info("Service", "svc2"),
+	}
+
+	tests := map[string]struct {
+		Reverse bool
+		Expect  []string
+	}{
+		"create": {
+			Expect: []string{"ns", "secret", "svc1", "svc2", "rc", "pod", "widget"},
+		},
+		"delete": {
+			Reverse: true,
+			Expect:  []string{"widget", "pod", "rc", "svc1", "svc2", "secret", "ns"},
+		},
+	}
+	for k, test := range tests {
+		names := []string{}
+		err := NewOrderedVisitor(infos, KindOrder, test.Reverse, 1).Visit(func(info *Info) error {
+			names = append(names, info.Name)
+			return nil
+		})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", k, err)
+		}
+		if !reflect.DeepEqual(test.Expect, names) {
+			t.Errorf("%s: unexpected order: %v", k, names)
+		}
+	}
+}
+
+func TestOrderedVisitorParallel(t *testing.T) {
+	infos := VisitorList{}
+	for _, kind := range []string{"Pod", "Service", "Pod", "Namespace", "Service", "Pod"} {
+		mapping, err := latest.RESTMapper.RESTMapping(kind)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		infos = append(infos, &Info{Name: strings.ToLower(kind), Mapping: mapping})
+	}
+
+	var lock sync.Mutex
+	running, maxRunning := 0, 0
+	kinds := []string{}
+	err := NewOrderedVisitor(infos, KindOrder, false, 3).Visit(func(info *Info) error {
+		lock.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		kinds = append(kinds, info.Mapping.Kind)
+		lock.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		lock.Lock()
+		defer lock.Unlock()
+		// every object of the previous kind has finished before this one started
+		for _, kind := range kinds {
+			if kind != info.Mapping.Kind && rankOf(kind) > rankOf(info.Mapping.Kind) {
+				t.Errorf("%s was visited before %s finished", kind, info.Mapping.Kind)
+			}
+		}
+		running--
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(kinds, []string{"Namespace", "Service", "Service", "Pod", "Pod", "Pod"}) {
+		t.Errorf("unexpected order: %v", kinds)
+	}
+	if maxRunning != 3 {
+		t.Errorf("expected the pods to be visited concurrently, at most %d visits were running", maxRunning)
+	}
+}
+
+// rankOf returns the position of kind in KindOrder.
+func rankOf(kind string) int {
+	for i, k := range KindOrder {
+		if k == kind {
+			return i
+		}
+	}
+	return len(KindOrder)
+}
+
+func TestOrderedVisitorError(t *testing.T) {
+	expected := errors.New("read failure")
+	v := NewOrderedVisitor(erroringVisitor{&Info{Name: "foo"}, expected}, KindOrder, false, 1)
+	visited := 0
+	err := v.Visit(func(*Info) error {
+		visited++
+		return nil
+	})
+	if err != expected || visited != 1 {
+		t.Errorf("unexpected result: %v %d", err, visited)
+	}
+}
+
+// erroringVisitor visits the items of its Visitor and then returns err.
+type erroringVisitor struct {
+	Visitor
+	err error
+}
+
+func (v erroringVisitor) Visit(fn VisitorFunc) error {
+	if err := v.Visitor.Visit(fn); err != nil {
+		return err
+	}
+	return v.err
+}
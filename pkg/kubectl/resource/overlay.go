@@ -0,0 +1,314 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/validation"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/strategicpatch"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/yaml"
+)
+
+// OverlayFile is the name of the optional file in an overlay directory that holds its
+// OverlayConfig. Every other manifest file in the directory holds patches.
+const OverlayFile = "overlay.yaml"
+
+// OverlayConfig lists the labels and annotations an overlay adds to every object.
+type OverlayConfig struct {
+	CommonLabels      map[string]string `json:"commonLabels,omitempty"`
+	CommonAnnotations map[string]string `json:"commonAnnotations,omitempty"`
+}
+
+// OverlayVisitor visits the objects in the manifest files of a base directory after
+// customizing them with a list of overlay directories, applied in order, so that
+// variants of the same manifests, such as one per environment, can be kept as small
+// sets of changes to a shared base.
+//
+// The manifest files of an overlay contain strategic merge patches: partial objects
+// that are merged into the base object with the same kind and name, and the same
+// namespace if the patch sets one. It is an error for a patch not to match any base
+// object. The labels and annotations in the overlay's OverlayFile are then added to
+// every object, replacing the values of existing keys. All the overlays and base
+// manifests are read, and every patch is matched against the base objects, before
+// the first object is visited.
+type OverlayVisitor struct {
+	*Mapper
+	Base      string
+	Overlays  []string
+	Recursive bool
+	Schema    validation.Schema
+}
+
+// overlay is the content of an overlay directory.
+type overlay struct {
+	config  OverlayConfig
+	patches []*overlayPatch
+}
+
+// overlayPatch is a patch read from an overlay and the object it applies to.
+type overlayPatch struct {
+	overlayKey
+	source   string
+	document int
+	data     []byte
+}
+
+// overlayKey identifies an object by the fields of its JSON encoding.
+type overlayKey struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+}
+
+// matches returns true if the patch applies to the object identified by key.
+func (p *overlayPatch) matches(key overlayKey) bool {
+	return p.Kind == key.Kind && p.Metadata.Name == key.Metadata.Name &&
+		(len(p.Metadata.Namespace) == 0 || p.Metadata.Namespace == key.Metadata.Namespace)
+}
+
+// baseDocument is a document read from a manifest file of the base directory.
+type baseDocument struct {
+	source   string
+	document int
+	data     []byte
+}
+
+// matchesAny returns true if the patch applies to any of the objects identified by keys.
+func (p *overlayPatch) matchesAny(keys []overlayKey) bool {
+	for _, key := range keys {
+		if p.matches(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Visit implements Visitor.
+func (v *OverlayVisitor) Visit(fn VisitorFunc) error {
+	overlays := []*overlay{}
+	for _, dir := range v.Overlays {
+		o, err := readOverlay(dir, v.Recursive)
+		if err != nil {
+			return err
+		}
+		overlays = append(overlays, o)
+	}
+
+	files, err := manifestFiles(v.Base, v.Recursive)
+	if err != nil {
+		return err
+	}
+	documents := []baseDocument{}
+	keys := []overlayKey{}
+	for _, path := range files {
+		err := readDocuments(path, func(document int, data []byte) error {
+			key := overlayKey{}
+			if err := json.Unmarshal(data, &key); err != nil {
+				return &DocumentError{path, document, err}
+			}
+			documents = append(documents, baseDocument{path, document, data})
+			keys = append(keys, key)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	// Patches keep the kind and name of the objects they apply to, so every patch
+	// can be matched against the base objects before any of them is visited.
+	for _, o := range overlays {
+		for _, p := range o.patches {
+			if !p.matchesAny(keys) {
+				return &DocumentError{p.source, p.document, fmt.Errorf("the patch for %s %q does not match any object in %q", p.Kind, p.Metadata.Name, v.Base)}
+			}
+		}
+	}
+
+	for _, d := range documents {
+		path, document, data := d.source, d.document, d.data
+		for _, o := range overlays {
+			patched, err := o.patch(data)
+			if err != nil {
+				return &DocumentError{path, document, err}
+			}
+			data = patched
+		}
+		if err := ValidateSchema(data, v.Schema); err != nil {
+			return &DocumentError{path, document, err}
+		}
+		info, err := v.InfoForData(data, path)
+		if err != nil {
+			return &DocumentError{path, document, err}
+		}
+		info.Document = document
+		for _, o := range overlays {
+			if len(o.config.CommonLabels) > 0 {
+				if err := LabelObject(info.Object, o.config.CommonLabels, nil, true); err != nil {
+					return &DocumentError{path, document, err}
+				}
+			}
+			if len(o.config.CommonAnnotations) > 0 {
+				if err := AnnotateObject(info.Object, o.config.CommonAnnotations, nil, true); err != nil {
+					return &DocumentError{path, document, err}
+				}
+			}
+		}
+		if err := fn(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// patch applies the patches of the overlay that match the object encoded in data.
+func (o *overlay) patch(data []byte) ([]byte, error) {
+	key := overlayKey{}
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, err
+	}
+	for _, p := range o.patches {
+		if !p.matches(key) {
+			continue
+		}
+		versioned, err := versionedType(data)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = strategicpatch.StrategicMergePatchData(data, p.data, versioned); err != nil {
+			return nil, fmt.Errorf("unable to apply the patch from %s (document %d): %v", p.source, p.document, err)
+		}
+	}
+	return data, nil
+}
+
+// readOverlay reads the OverlayFile and the patches in dir.
+func readOverlay(dir string, recursive bool) (*overlay, error) {
+	o := &overlay{}
+	configPath := filepath.Join(dir, OverlayFile)
+	data, err := ioutil.ReadFile(configPath)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	default:
+		if data, err = yaml.ToJSON(data); err == nil {
+			err = json.Unmarshal(data, &o.config)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %q: %v", configPath, err)
+		}
+	}
+
+	files, err := manifestFiles(dir, recursive)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range files {
+		if path == configPath {
+			continue
+		}
+		err := readDocuments(path, func(document int, data []byte) error {
+			patch := map[string]interface{}{}
+			if err := json.Unmarshal(data, &patch); err != nil {
+				return &DocumentError{path, document, err}
+			}
+			p := &overlayPatch{source: path, document: document}
+			if err := json.Unmarshal(data, &p.overlayKey); err != nil {
+				return &DocumentError{path, document, err}
+			}
+			if len(p.Kind) == 0 || len(p.Metadata.Name) == 0 {
+				return &DocumentError{path, document, fmt.Errorf("a patch must set the kind and name of the object it applies to")}
+			}
+			// The patch applies to the object in whatever version the base is written in.
+			delete(patch, "kind")
+			delete(patch, "apiVersion")
+			encoded, err := json.Marshal(patch)
+			if err != nil {
+				return err
+			}
+			p.data = encoded
+			o.patches = append(o.patches, p)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// manifestFiles returns the manifest files in dir, in lexical order, descending into
+// subdirectories if recursive is true.
+func manifestFiles(dir string, recursive bool) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !ignoreFile(path, []string{".json", ".yaml", ".yml"}) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading %q: %v", dir, err)
+	}
+	return files, nil
+}
+
+// readDocuments invokes fn with the JSON encoding of each non-empty document in the
+// file at path and its position in the file, starting at 1.
+func readDocuments(path string, fn func(document int, data []byte) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("unable to open %q: %v", path, err)
+	}
+	defer f.Close()
+	d := yaml.NewYAMLOrJSONDecoder(f, 4096)
+	for document := 1; ; document++ {
+		ext := runtime.RawExtension{}
+		if err := d.Decode(&ext); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return &DocumentError{path, document, err}
+		}
+		ext.RawJSON = bytes.TrimSpace(ext.RawJSON)
+		if len(ext.RawJSON) == 0 || bytes.Equal(ext.RawJSON, []byte("null")) {
+			continue
+		}
+		if err := fn(document, ext.RawJSON); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+)
+
+// writeFiles creates a directory holding files with the given relative paths and
+// contents, and returns the directory.
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "overlay")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for p, data := range files {
+		path := filepath.Join(dir, p)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return dir
+}
+
+const overlayBase = `apiVersion: v1
+kind: ReplicationController
+metadata:
+  name: web
+  labels:
+    app: web
+spec:
+  replicas: 1
+  selector:
+    app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: web:1.0
+      - name: proxy
+        image: proxy:1.0
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  selector:
+    app: web
+  ports:
+  - port: 80
+`
+
+func TestOverlayBuilder(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base/web.yaml": overlayBase,
+		"prod/overlay.yaml": `commonLabels:
+  env: prod
+commonAnnotations:
+  owner: web-team
+`,
+		"prod/replicas.yaml": `kind: ReplicationController
+metadata:
+  name: web
+spec:
+  replicas: 5
+  template:
+    spec:
+      containers:
+      - name: web
+        image: web:2.0
+`,
+		"canary/service.json": `{"kind": "Service", "metadata": {"name": "web", "labels": {"track": "canary"}}}`,
+	})
+	defer os.RemoveAll(dir)
+
+	infos, err := NewBuilder(latest.RESTMapper, api.Scheme, fakeClient()).
+		Overlay(filepath.Join(dir, "base"), filepath.Join(dir, "prod"), filepath.Join(dir, "canary")).
+		Do().Infos()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("unexpected infos: %#v", infos)
+	}
+
+	rc := infos[0].Object.(*api.ReplicationController)
+	if rc.Spec.Replicas != 5 {
+		t.Errorf("unexpected replicas: %d", rc.Spec.Replicas)
+	}
+	containers := rc.Spec.Template.Spec.Containers
+	if len(containers) != 2 || containers[0].Image != "web:2.0" || containers[1].Image != "proxy:1.0" {
+		t.Errorf("unexpected containers: %#v", containers)
+	}
+	if !reflect.DeepEqual(map[string]string{"app": "web", "env": "prod"}, rc.Labels) {
+		t.Errorf("unexpected labels: %v", rc.Labels)
+	}
+	if rc.Annotations["owner"] != "web-team" {
+		t.Errorf("unexpected annotations: %v", rc.Annotations)
+	}
+	if infos[0].Document != 1 || infos[1].Document != 2 {
+		t.Errorf("unexpected documents: %d %d", infos[0].Document, infos[1].Document)
+	}
+
+	svc := infos[1].Object.(*api.Service)
+	if !reflect.DeepEqual(map[string]string{"env": "prod", "track": "canary"}, svc.Labels) {
+		t.Errorf("unexpected labels: %v", svc.Labels)
+	}
+	if len(svc.Spec.Ports) != 1 || svc.Spec.Ports[0].Port != 80 {
+		t.Errorf("unexpected ports: %#v", svc.Spec.Ports)
+	}
+}
+
+func TestOverlayBuilderErrors(t *testing.T) {
+	tests := map[string]struct {
+		Overlay string
+		Err     string
+	}{
+		"unmatched patch": {
+			Overlay: `{"kind": "Service", "metadata": {"name": "db"}}`,
+			Err:     `the patch for Service "db" does not match any object`,
+		},
+		"patch without a name": {
+			Overlay: `{"kind": "Service", "spec": {"sessionAffinity": "ClientIP"}}`,
+			Err:     "must set the kind and name",
+		},
+		"patch for another namespace": {
+			Overlay: `{"kind": "Service", "metadata": {"name": "web", "namespace": "other"}}`,
+			Err:     `the patch for Service "web" does not match any object`,
+		},
+	}
+	for k, test := range tests {
+		dir := writeFiles(t, map[string]string{
+			"base/web.yaml":      overlayBase,
+			"overlay/patch.json": test.Overlay,
+		})
+		r := NewBuilder(latest.RESTMapper, api.Scheme, fakeClient()).
+			Overlay(filepath.Join(dir, "base"), filepath.Join(dir, "overlay")).
+			Do()
+		visited := 0
+		err := r.Visit(func(*Info) error {
+			visited++
+			return nil
+		})
+		if err == nil || !strings.Contains(err.Error(), test.Err) {
+			t.Errorf("%s: unexpected error: %v", k, err)
+		}
+		if visited != 0 {
+			t.Errorf("%s: expected no object to be visited, got %d", k, visited)
+		}
+		os.RemoveAll(dir)
+	}
+
+	err := NewBuilder(latest.RESTMapper, api.Scheme, fakeClient()).
+		Overlay("/does/not/exist").
+		Do().Err()
+	if err == nil {
+		t.Errorf("expected a missing base directory to be rejected")
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+// DefaultPageSize is the number of items requested per page by a ListPager
+// with no PageSize set.
+const DefaultPageSize = 500
+
+// ListPager retrieves a collection a page at a time, so that very large
+// collections do not have to be returned by the server in a single response.
+type ListPager struct {
+	Helper *Helper
+	// PageSize is the maximum number of items requested per page. Defaults
+	// to DefaultPageSize if zero.
+	PageSize int64
+}
+
+// NewListPager returns a ListPager that lists the resource of helper in pages of
+// pageSize items.
+func NewListPager(helper *Helper, pageSize int64) *ListPager {
+	return &ListPager{
+		Helper:   helper,
+		PageSize: pageSize,
+	}
+}
+
+// EachPage retrieves every page of the collection in order and invokes fn with
+// each one. Iteration stops at the first error returned by fn.
+func (p *ListPager) EachPage(namespace, apiVersion string, labelSelector labels.Selector, fieldSelector fields.Selector, fn func(page runtime.Object) error) error {
+	pageSize := p.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	continueToken := ""
+	for {
+		page, next, err := p.Helper.ListPage(namespace, apiVersion, labelSelector, fieldSelector, pageSize, continueToken)
+		if err != nil {
+			return err
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+		if len(next) == 0 {
+			return nil
+		}
+		continueToken = next
+	}
+}
+
+// EachListItem invokes fn once for every item in the collection, in order,
+// retrieving one page at a time. Iteration stops at the first error returned by fn.
+func (p *ListPager) EachListItem(namespace, apiVersion string, labelSelector labels.Selector, fieldSelector fields.Selector, fn func(item runtime.Object) error) error {
+	return p.EachPage(namespace, apiVersion, labelSelector, fieldSelector, func(page runtime.Object) error {
+		items, err := runtime.ExtractList(page)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// List retrieves every page of the collection and returns a single list holding
+// all of their items. The returned list is the first page with the items of the
+// remaining pages appended.
+func (p *ListPager) List(namespace, apiVersion string, labelSelector labels.Selector, fieldSelector fields.Selector) (runtime.Object, error) {
+	var list runtime.Object
+	var items []runtime.Object
+	err := p.EachPage(namespace, apiVersion, labelSelector, fieldSelector, func(page runtime.Object) error {
+		pageItems, err := runtime.ExtractList(page)
+		if err != nil {
+			return err
+		}
+		if list == nil {
+			list = page
+		}
+		items = append(items, pageItems...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := runtime.SetList(list, items); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
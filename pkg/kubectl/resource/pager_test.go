@@ -0,0 +1,191 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/testapi"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+// pagedPods serves pods from a fake server pageSize at a time, using the index
+// of the next pod as the continue token.
+func pagedPods(t *testing.T, names []string, requests *[]string) client.HTTPClientFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		query := req.URL.Query()
+		*requests = append(*requests, query.Get("continue"))
+		start := 0
+		if token := query.Get("continue"); len(token) > 0 {
+			start = int(token[0] - '0')
+		}
+		end := len(names)
+		if limit := query.Get("limit"); len(limit) > 0 && start+int(limit[0]-'0') < end {
+			end = start + int(limit[0]-'0')
+		}
+		list := &api.PodList{}
+		for _, name := range names[start:end] {
+			list.Items = append(list.Items, api.Pod{ObjectMeta: api.ObjectMeta{Name: name}})
+		}
+		data, err := testapi.Codec().Encode(list)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if end < len(names) {
+			obj := map[string]interface{}{}
+			if err := json.Unmarshal(data, &obj); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			obj["metadata"] = map[string]interface{}{"continue": string('0' + byte(end))}
+			if data, err = json.Marshal(obj); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewReader(data))}, nil
+	}
+}
+
+func podNames(t *testing.T, list runtime.Object) []string {
+	items, err := runtime.ExtractList(list)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	names := []string{}
+	for _, item := range items {
+		names = append(names, item.(*api.Pod).Name)
+	}
+	return names
+}
+
+func TestHelperListPage(t *testing.T) {
+	requests := []string{}
+	client := &client.FakeRESTClient{
+		Codec:  testapi.Codec(),
+		Client: pagedPods(t, []string{"a", "b", "c"}, &requests),
+	}
+	modifier := &Helper{
+		RESTClient:      client,
+		Resource:        "pods",
+		NamespaceScoped: true,
+	}
+
+	list, next, err := modifier.ListPage("bar", testapi.Version(), labels.Everything(), fields.Everything(), 2, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := podNames(t, list); !reflect.DeepEqual(names, []string{"a", "b"}) {
+		t.Errorf("unexpected items: %v", names)
+	}
+	if next != "2" {
+		t.Errorf("unexpected continue token: %q", next)
+	}
+	if client.Req.URL.Query().Get("limit") != "2" {
+		t.Errorf("expected a limit: %#v", client.Req.URL)
+	}
+
+	list, next, err = modifier.ListPage("bar", testapi.Version(), labels.Everything(), fields.Everything(), 2, next)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := podNames(t, list); !reflect.DeepEqual(names, []string{"c"}) {
+		t.Errorf("unexpected items: %v", names)
+	}
+	if next != "" {
+		t.Errorf("unexpected continue token: %q", next)
+	}
+
+	if _, err := modifier.List("bar", testapi.Version(), labels.Everything(), fields.Everything()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if query := client.Req.URL.Query(); len(query.Get("limit")) > 0 || len(query.Get("continue")) > 0 {
+		t.Errorf("List should not page: %#v", client.Req.URL)
+	}
+}
+
+func TestListPager(t *testing.T) {
+	tests := []struct {
+		PageSize int64
+		Requests []string
+	}{
+		{PageSize: 2, Requests: []string{"", "2", "4"}},
+		{PageSize: 5, Requests: []string{""}},
+		{PageSize: 0, Requests: []string{""}},
+	}
+	for i, test := range tests {
+		requests := []string{}
+		pager := NewListPager(&Helper{
+			RESTClient: &client.FakeRESTClient{
+				Codec:  testapi.Codec(),
+				Client: pagedPods(t, []string{"a", "b", "c", "d", "e"}, &requests),
+			},
+			Resource:        "pods",
+			NamespaceScoped: true,
+		}, test.PageSize)
+
+		list, err := pager.List("bar", testapi.Version(), labels.Everything(), fields.Everything())
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+		if names := podNames(t, list); !reflect.DeepEqual(names, []string{"a", "b", "c", "d", "e"}) {
+			t.Errorf("%d: unexpected items: %v", i, names)
+		}
+		if !reflect.DeepEqual(requests, test.Requests) {
+			t.Errorf("%d: unexpected requests: %v", i, requests)
+		}
+	}
+}
+
+func TestListPagerEachListItem(t *testing.T) {
+	requests := []string{}
+	pager := NewListPager(&Helper{
+		RESTClient: &client.FakeRESTClient{
+			Codec:  testapi.Codec(),
+			Client: pagedPods(t, []string{"a", "b", "c", "d", "e"}, &requests),
+		},
+		Resource:        "pods",
+		NamespaceScoped: true,
+	}, 2)
+
+	names := []string{}
+	stop := errors.New("stop")
+	err := pager.EachListItem("bar", testapi.Version(), labels.Everything(), fields.Everything(), func(item runtime.Object) error {
+		names = append(names, item.(*api.Pod).Name)
+		if len(names) == 3 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"a", "b", "c"}) {
+		t.Errorf("unexpected items: %v", names)
+	}
+	if !reflect.DeepEqual(requests, []string{"", "2"}) {
+		t.Errorf("no more pages should be requested after fn fails: %v", requests)
+	}
+}
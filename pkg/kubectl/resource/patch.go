@@ -0,0 +1,267 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/strategicpatch"
+)
+
+// CreateStrategicMergePatch returns the strategic merge patch that turns original
+// into modified, two objects of the same kind, as they are encoded by codec. The
+// patch only contains the fields that changed, and the elements of lists that are
+// merged by key, such as the containers of a pod, are matched by that key, so it
+// can be sent with Helper.Patch instead of replacing the whole object. A patch of
+// two identical objects is "{}".
+func CreateStrategicMergePatch(codec runtime.Codec, original, modified runtime.Object) ([]byte, error) {
+	originalData, err := codec.Encode(original)
+	if err != nil {
+		return nil, err
+	}
+	modifiedData, err := codec.Encode(modified)
+	if err != nil {
+		return nil, err
+	}
+	_, originalKind, err := api.Scheme.DataVersionAndKind(originalData)
+	if err != nil {
+		return nil, err
+	}
+	versioned, err := versionedType(modifiedData)
+	if err != nil {
+		return nil, err
+	}
+	if _, kind, _ := api.Scheme.DataVersionAndKind(modifiedData); kind != originalKind {
+		return nil, fmt.Errorf("cannot create a patch from a %s to a %s", originalKind, kind)
+	}
+	return strategicpatch.CreateTwoWayMergePatch(originalData, modifiedData, versioned)
+}
+
+// versionedType returns a new object of the versioned type data is encoded as. The
+// patch strategy of each field is described by the tags of that type.
+func versionedType(data []byte) (runtime.Object, error) {
+	version, kind, err := api.Scheme.DataVersionAndKind(data)
+	if err != nil {
+		return nil, err
+	}
+	return api.Scheme.New(version, kind)
+}
+
+// jsonPatchOperation is an operation of a JSON patch, as defined by RFC 6902.
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// withValue returns the operation op on path with the given value, which must have
+// been decoded from JSON.
+func withValue(op, path string, value interface{}) jsonPatchOperation {
+	data, _ := json.Marshal(value)
+	return jsonPatchOperation{Op: op, Path: path, Value: data}
+}
+
+// CreateJSONPatch returns the JSON patch (RFC 6902) that turns original into
+// modified, two objects as they are encoded by codec, for resources whose types do
+// not describe how their lists are merged. Changed fields are replaced, and lists
+// are compared element by element, with elements added or removed at their end.
+// Elements that are not objects or lists are replaced by removing them and adding
+// the new element at the same position, and objects in which a key containing "/"
+// or "~" changed, such as labels with a prefix, are replaced as a whole, since the
+// server does not apply those operations as RFC 6902 specifies.
+//
+// Each of guards is a JSON pointer, such as "/metadata/resourceVersion", to a field
+// whose value in original is tested before any change is made, so that the server
+// rejects the patch if the field has changed since original was retrieved. Guards
+// to fields original does not have are ignored. A patch of two identical objects
+// without guards is "[]".
+func CreateJSONPatch(codec runtime.Codec, original, modified runtime.Object, guards ...string) ([]byte, error) {
+	originalData, err := codec.Encode(original)
+	if err != nil {
+		return nil, err
+	}
+	modifiedData, err := codec.Encode(modified)
+	if err != nil {
+		return nil, err
+	}
+	var originalValue, modifiedValue interface{}
+	if err := json.Unmarshal(originalData, &originalValue); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(modifiedData, &modifiedValue); err != nil {
+		return nil, err
+	}
+
+	operations := []jsonPatchOperation{}
+	for _, guard := range guards {
+		if value, found := jsonPointerValue(originalValue, guard); found {
+			operations = append(operations, withValue("test", guard, value))
+		}
+	}
+	operations = appendJSONPatch(operations, "", originalValue, modifiedValue)
+	return json.Marshal(operations)
+}
+
+// appendJSONPatch appends the operations that turn the value at path from original
+// into modified to operations.
+func appendJSONPatch(operations []jsonPatchOperation, path string, original, modified interface{}) []jsonPatchOperation {
+	if reflect.DeepEqual(original, modified) {
+		return operations
+	}
+	switch originalValue := original.(type) {
+	case map[string]interface{}:
+		modifiedValue, ok := modified.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := []string{}
+		for key := range originalValue {
+			keys = append(keys, key)
+		}
+		for key := range modifiedValue {
+			if _, found := originalValue[key]; !found {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			// The server does not unescape the keys of JSON pointers, so an object
+			// with a changed key that needs escaping is replaced as a whole.
+			if strings.ContainsAny(key, "/~") && !reflect.DeepEqual(originalValue[key], modifiedValue[key]) && len(path) > 0 {
+				return append(operations, withValue("replace", path, modified))
+			}
+		}
+		for _, key := range keys {
+			child := path + "/" + escapeJSONPointer(key)
+			originalChild, inOriginal := originalValue[key]
+			modifiedChild, inModified := modifiedValue[key]
+			switch {
+			case !inModified:
+				operations = append(operations, jsonPatchOperation{Op: "remove", Path: child})
+			case !inOriginal:
+				operations = append(operations, withValue("add", child, modifiedChild))
+			default:
+				operations = appendJSONPatch(operations, child, originalChild, modifiedChild)
+			}
+		}
+		return operations
+	case []interface{}:
+		modifiedValue, ok := modified.([]interface{})
+		if !ok {
+			break
+		}
+		common := len(originalValue)
+		if len(modifiedValue) < common {
+			common = len(modifiedValue)
+		}
+		for i := 0; i < common; i++ {
+			child := path + "/" + strconv.Itoa(i)
+			switch {
+			case reflect.DeepEqual(originalValue[i], modifiedValue[i]):
+			case reflect.TypeOf(originalValue[i]) == reflect.TypeOf(modifiedValue[i]) && isJSONContainer(modifiedValue[i]):
+				operations = appendJSONPatch(operations, child, originalValue[i], modifiedValue[i])
+			default:
+				// The server inserts the value of a replace of a list element
+				// instead of replacing the element, so the element is removed
+				// and the new one added in its place.
+				operations = append(operations, jsonPatchOperation{Op: "remove", Path: child}, withValue("add", child, modifiedValue[i]))
+			}
+		}
+		for i := common; i < len(modifiedValue); i++ {
+			operations = append(operations, withValue("add", path+"/"+strconv.Itoa(i), modifiedValue[i]))
+		}
+		for i := len(originalValue) - 1; i >= common; i-- {
+			operations = append(operations, jsonPatchOperation{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+		}
+		return operations
+	}
+	return append(operations, withValue("replace", path, modified))
+}
+
+// isJSONContainer returns true if value is a decoded JSON object or array.
+func isJSONContainer(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	}
+	return false
+}
+
+// jsonPointerValue returns the value the JSON pointer path refers to in value.
+func jsonPointerValue(value interface{}, path string) (interface{}, bool) {
+	if len(path) == 0 {
+		return value, true
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, false
+	}
+	for _, token := range strings.Split(path[1:], "/") {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		switch v := value.(type) {
+		case map[string]interface{}:
+			child, found := v[token]
+			if !found {
+				return nil, false
+			}
+			value = child
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			value = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// escapeJSONPointer escapes key for use as a token of a JSON pointer.
+func escapeJSONPointer(key string) string {
+	return strings.Replace(strings.Replace(key, "~", "~0", -1), "/", "~1", -1)
+}
+
+// CreatePatch returns the patch of type pt that turns original into modified: a
+// strategic merge patch as created by CreateStrategicMergePatch, or a JSON patch as
+// created by CreateJSONPatch without guards.
+func CreatePatch(codec runtime.Codec, pt api.PatchType, original, modified runtime.Object) ([]byte, error) {
+	switch pt {
+	case api.StrategicMergePatchType:
+		return CreateStrategicMergePatch(codec, original, modified)
+	case api.JSONPatchType:
+		return CreateJSONPatch(codec, original, modified)
+	}
+	return nil, fmt.Errorf("unable to create a patch of type %q", pt)
+}
+
+// PatchChanges sends the changes that turn original into modified to the named object
+// as a patch of type pt, created by CreatePatch, and returns the patched object.
+func (m *Helper) PatchChanges(namespace, name string, pt api.PatchType, original, modified runtime.Object) (runtime.Object, error) {
+	patch, err := CreatePatch(m.Codec, pt, original, modified)
+	if err != nil {
+		return nil, err
+	}
+	return m.Patch(namespace, name, pt, patch)
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/testapi"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/strategicpatch"
+
+	jsonpatch "github.com/evanphx/json-patch"
+)
+
+func TestCreateStrategicMergePatch(t *testing.T) {
+	codec := testapi.Codec()
+	original := &api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "foo", Namespace: "test", Labels: map[string]string{"app": "web"}},
+		Spec: api.PodSpec{
+			Containers: []api.Container{
+				{Name: "a", Image: "nginx"},
+				{Name: "b", Image: "redis"},
+			},
+		},
+	}
+	modified := &api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "foo", Namespace: "test", Labels: map[string]string{"app": "web", "tier": "cache"}},
+		Spec: api.PodSpec{
+			Containers: []api.Container{
+				{Name: "b", Image: "redis:2.8"},
+				{Name: "c", Image: "memcached"},
+			},
+		},
+	}
+
+	patch, err := CreateStrategicMergePatch(codec, original, modified)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var patchMap map[string]interface{}
+	if err := json.Unmarshal(patch, &patchMap); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, found := patchMap["metadata"].(map[string]interface{})["name"]; found {
+		t.Errorf("expected unchanged fields to be left out of the patch: %s", string(patch))
+	}
+
+	// Applying the patch to the original object must produce the modified one.
+	originalData := []byte(runtime.EncodeOrDie(codec, original))
+	versioned, err := versionedType(originalData)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	patched, err := strategicpatch.StrategicMergePatchData(originalData, patch, versioned)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	obj, err := codec.Decode(patched)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected, err := codec.Decode([]byte(runtime.EncodeOrDie(codec, modified)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !api.Semantic.DeepEqual(expected, obj) {
+		t.Errorf("unexpected patched object for patch %s: %#v", string(patch), obj)
+	}
+
+	patch, err = CreateStrategicMergePatch(codec, original, original)
+	if err != nil || !reflect.DeepEqual(patch, []byte("{}")) {
+		t.Errorf("unexpected patch of identical objects: %s %v", string(patch), err)
+	}
+	if _, err := CreateStrategicMergePatch(codec, original, &api.Service{ObjectMeta: api.ObjectMeta{Name: "foo"}}); err == nil {
+		t.Errorf("expected an error for objects of different kinds")
+	}
+}
+
+func TestCreateJSONPatch(t *testing.T) {
+	codec := testapi.Codec()
+	pod := func(labels map[string]string, containers ...api.Container) *api.Pod {
+		return &api.Pod{
+			ObjectMeta: api.ObjectMeta{Name: "foo", Namespace: "test", ResourceVersion: "10", Labels: labels},
+			Spec:       api.PodSpec{Containers: containers},
+		}
+	}
+	nginx := api.Container{Name: "a", Image: "nginx", Args: []string{"-g", "daemon off;"}}
+	redis := api.Container{Name: "b", Image: "redis"}
+
+	tests := map[string]struct {
+		Original, Modified *api.Pod
+		Patch              string
+	}{
+		"identical": {
+			Original: pod(nil, nginx),
+			Modified: pod(nil, nginx),
+			Patch:    `[]`,
+		},
+		"label added": {
+			Original: pod(map[string]string{"app": "web"}, nginx),
+			Modified: pod(map[string]string{"app": "web", "example.com/tier": "front"}, nginx),
+			Patch:    `[{"op":"replace","path":"/metadata/labels","value":{"app":"web","example.com/tier":"front"}}]`,
+		},
+		"unprefixed label added": {
+			Original: pod(map[string]string{"app": "web"}, nginx),
+			Modified: pod(map[string]string{"app": "web", "tier": "front"}, nginx),
+			Patch:    `[{"op":"add","path":"/metadata/labels/tier","value":"front"}]`,
+		},
+		"labels removed": {
+			Original: pod(map[string]string{"app": "web"}, nginx),
+			Modified: pod(nil, nginx),
+			Patch:    `[{"op":"remove","path":"/metadata/labels"}]`,
+		},
+		"field changed": {
+			Original: pod(nil, nginx, redis),
+			Modified: pod(nil, nginx, api.Container{Name: "b", Image: "redis:2.8"}),
+			Patch:    `[{"op":"replace","path":"/spec/containers/1/image","value":"redis:2.8"}]`,
+		},
+		"list element changed": {
+			Original: pod(nil, nginx),
+			Modified: pod(nil, api.Container{Name: "a", Image: "nginx", Args: []string{"-g", "daemon on;"}}),
+			Patch:    `[{"op":"remove","path":"/spec/containers/0/args/1"},{"op":"add","path":"/spec/containers/0/args/1","value":"daemon on;"}]`,
+		},
+		"list element added": {
+			Original: pod(nil, nginx),
+			Modified: pod(nil, nginx, redis),
+		},
+		"list elements removed": {
+			Original: pod(nil, nginx, redis, api.Container{Name: "c", Image: "memcached"}),
+			Modified: pod(nil, nginx),
+			Patch:    `[{"op":"remove","path":"/spec/containers/2"},{"op":"remove","path":"/spec/containers/1"}]`,
+		},
+	}
+	for k, test := range tests {
+		patch, err := CreateJSONPatch(codec, test.Original, test.Modified)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", k, err)
+			continue
+		}
+		if len(test.Patch) > 0 && string(patch) != test.Patch {
+			t.Errorf("%s: unexpected patch: %s", k, string(patch))
+		}
+
+		// Applying the patch to the original object must produce the modified one.
+		decoded, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", k, err)
+			continue
+		}
+		patched, err := decoded.Apply([]byte(runtime.EncodeOrDie(codec, test.Original)))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", k, err)
+			continue
+		}
+		obj, err := codec.Decode(patched)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", k, err)
+			continue
+		}
+		expected, err := codec.Decode([]byte(runtime.EncodeOrDie(codec, test.Modified)))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", k, err)
+		}
+		if !api.Semantic.DeepEqual(expected, obj) {
+			t.Errorf("%s: unexpected patched object for patch %s: %#v", k, string(patch), obj)
+		}
+	}
+}
+
+func TestCreateJSONPatchGuards(t *testing.T) {
+	codec := testapi.Codec()
+	original := &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", ResourceVersion: "10"}}
+	modified := &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", ResourceVersion: "10", Labels: map[string]string{"app": "web"}}}
+	patch, err := CreateJSONPatch(codec, original, modified, "/metadata/resourceVersion", "/metadata/uid")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `[{"op":"test","path":"/metadata/resourceVersion","value":"10"},{"op":"add","path":"/metadata/labels","value":{"app":"web"}}]`
+	if string(patch) != expected {
+		t.Errorf("unexpected patch: %s", string(patch))
+	}
+
+	decoded, err := jsonpatch.DecodePatch(patch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	changed := &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", ResourceVersion: "11"}}
+	if _, err := decoded.Apply([]byte(runtime.EncodeOrDie(codec, changed))); err == nil {
+		t.Errorf("expected the guard to reject a changed object")
+	}
+}
+
+func TestHelperPatchChanges(t *testing.T) {
+	original := &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", Namespace: "test"}}
+	modified := &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", Namespace: "test", Labels: map[string]string{"app": "web"}}}
+	tests := map[api.PatchType]string{
+		api.JSONPatchType:           `[{"op":"add","path":"/metadata/labels","value":{"app":"web"}}]`,
+		api.StrategicMergePatchType: `{"metadata":{"labels":{"app":"web"}}}`,
+	}
+	for pt, expected := range tests {
+		client := &client.FakeRESTClient{
+			Codec: testapi.Codec(),
+			Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+				data, _ := ioutil.ReadAll(req.Body)
+				if req.Method != "PATCH" || req.URL.Path != "/namespaces/test/pods/foo" || string(data) != expected {
+					t.Errorf("%s: unexpected request: %s %s %s", pt, req.Method, req.URL.Path, string(data))
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: objBody(modified)}, nil
+			}),
+		}
+		helper := &Helper{RESTClient: client, Codec: testapi.Codec(), Resource: "pods", NamespaceScoped: true}
+		if _, err := helper.PatchChanges("test", "foo", pt, original, modified); err != nil {
+			t.Errorf("%s: unexpected error: %v", pt, err)
+		}
+	}
+
+	helper := &Helper{Codec: testapi.Codec(), Resource: "pods", NamespaceScoped: true}
+	if _, err := helper.PatchChanges("test", "foo", api.MergePatchType, original, modified); err == nil || !strings.Contains(err.Error(), "unable to create a patch") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/meta"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	utilerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/util/errors"
+)
+
+// PruneSetLabel is the label that records which set of declaratively managed objects
+// an object belongs to. Objects carrying it are deleted by a Pruner for the same set
+// once they are no longer part of the set's manifests.
+const PruneSetLabel = "kubectl.kubernetes.io/prune-set"
+
+// TagPruneSet returns a VisitorFunc that labels each object as a member of set, so
+// that a later Pruner can find it.
+func TagPruneSet(set string) VisitorFunc {
+	return func(info *Info) error {
+		if info.Object == nil {
+			return nil
+		}
+		objLabels, err := info.Mapping.MetadataAccessor.Labels(info.Object)
+		if err != nil {
+			return err
+		}
+		if objLabels == nil {
+			objLabels = map[string]string{}
+		}
+		objLabels[PruneSetLabel] = set
+		return info.Mapping.MetadataAccessor.SetLabels(info.Object, objLabels)
+	}
+}
+
+// Pruner deletes the objects of a set that have been removed from its manifests.
+type Pruner struct {
+	// The set whose members are pruned, as passed to TagPruneSet.
+	Set string
+	// The namespace to look for members in, or api.NamespaceAll.
+	Namespace string
+	// The types of resources to look for members of. If empty, the types of the
+	// current members passed to Prune are used, which means that the last object
+	// of a type removed from the manifests is not found.
+	Mappings     []*meta.RESTMapping
+	ClientMapper ClientMapper
+	// If set, returns the helper used to list and delete members; otherwise a Helper
+	// is used.
+	HelperFunc HelperFunc
+}
+
+// Prune deletes every member of the set that is not among current, which should hold
+// the objects the set's manifests describe now. The deleted objects are returned,
+// along with an aggregate of the errors encountered; an error for one object does
+// not prevent the others from being pruned.
+func (p *Pruner) Prune(current []*Info) ([]*Info, error) {
+	keep := map[string]bool{}
+	mappings := p.Mappings
+	seen := map[string]bool{}
+	for _, info := range current {
+		keep[pruneKey(info.Mapping, info.Namespace, info.Name)] = true
+		if len(p.Mappings) == 0 && !seen[info.Mapping.Resource] {
+			seen[info.Mapping.Resource] = true
+			mappings = append(mappings, info.Mapping)
+		}
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{PruneSetLabel: p.Set})
+	pruned := []*Info{}
+	errs := []error{}
+	for _, mapping := range mappings {
+		client, err := p.ClientMapper.ClientForMapping(mapping)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		helper := p.HelperFunc.helperFor(client, mapping)
+		list, err := helper.List(p.Namespace, mapping.APIVersion, selector, fields.Everything())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to list %s to prune: %v", mapping.Resource, err))
+			continue
+		}
+		items, err := runtime.ExtractList(list)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, item := range items {
+			name, err := mapping.MetadataAccessor.Name(item)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			namespace, err := mapping.MetadataAccessor.Namespace(item)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if keep[pruneKey(mapping, namespace, name)] {
+				continue
+			}
+			if err := helper.Delete(namespace, name); err != nil && !errors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("unable to prune %s %q: %v", mapping.Resource, name, err))
+				continue
+			}
+			info := NewInfo(client, mapping, namespace, name)
+			info.Object = item
+			pruned = append(pruned, info)
+		}
+	}
+	return pruned, utilerrors.NewAggregate(errs)
+}
+
+func pruneKey(mapping *meta.RESTMapping, namespace, name string) string {
+	return mapping.Resource + "/" + namespace + "/" + name
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/meta"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/testapi"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+)
+
+func TestBuilderPruneSet(t *testing.T) {
+	pods, _ := testData()
+	b := NewBuilder(latest.RESTMapper, api.Scheme, fakeClient()).
+		NamespaceParam("test").PruneSet("guestbook").
+		Stream(streamTestObject(&pods.Items[0]), "STDIN")
+
+	test := &testVisitor{}
+	if err := b.Do().Visit(test.Handle); err != nil || len(test.Infos) != 1 {
+		t.Fatalf("unexpected response: %v %#v", err, test.Infos)
+	}
+	if set := test.Infos[0].Object.(*api.Pod).Labels[PruneSetLabel]; set != "guestbook" {
+		t.Errorf("expected the object to be labeled with its set, got %q", set)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	mapping, err := latest.RESTMapper.RESTMapping("Pod", testapi.Version())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	requests := []string{}
+	fake := &client.FakeRESTClient{
+		Codec: testapi.Codec(),
+		Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			requests = append(requests, req.Method+" "+req.URL.Path)
+			if req.Method == "GET" {
+				if selector := req.URL.Query().Get(api.LabelSelectorQueryParam(testapi.Version())); selector != PruneSetLabel+"=guestbook" {
+					t.Errorf("unexpected selector: %s", selector)
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.PodList{Items: []api.Pod{
+					{ObjectMeta: api.ObjectMeta{Name: "foo", Namespace: "test"}},
+					{ObjectMeta: api.ObjectMeta{Name: "bar", Namespace: "test"}},
+					{ObjectMeta: api.ObjectMeta{Name: "gone", Namespace: "test"}},
+				}})}, nil
+			}
+			if req.URL.Path == "/namespaces/test/pods/gone" {
+				return &http.Response{StatusCode: http.StatusNotFound, Body: objBody(&api.Status{Status: api.StatusFailure, Code: http.StatusNotFound, Reason: api.StatusReasonNotFound})}, nil
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Status{Status: api.StatusSuccess})}, nil
+		}),
+	}
+	pruner := &Pruner{
+		Set:       "guestbook",
+		Namespace: "test",
+		ClientMapper: ClientMapperFunc(func(*meta.RESTMapping) (RESTClient, error) {
+			return fake, nil
+		}),
+	}
+
+	pruned, err := pruner.Prune([]*Info{NewInfo(fake, mapping, "test", "foo")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{
+		"GET /namespaces/test/pods",
+		"DELETE /namespaces/test/pods/bar",
+		"DELETE /namespaces/test/pods/gone",
+	}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("unexpected requests: %v", requests)
+	}
+	names := []string{}
+	for _, info := range pruned {
+		names = append(names, info.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"bar", "gone"}) {
+		t.Errorf("unexpected pruned objects: %v", names)
+	}
+}
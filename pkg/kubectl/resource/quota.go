@@ -0,0 +1,270 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/resource"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/errors"
+)
+
+// QuotaVisitor checks the objects of a visitor against the ResourceQuotas and
+// LimitRanges of their namespaces, as described by CheckQuota, before visiting any of
+// them. If an object would be rejected, none are visited and every violation is
+// reported, so that a create that the server would refuse part of the way through is
+// refused before anything is sent. All the items are read before the first one is
+// visited.
+type QuotaVisitor struct {
+	Visitor
+	Mapper *Mapper
+}
+
+// NewQuotaVisitor creates a visitor that checks the items of v against the quotas and
+// limit ranges it retrieves through mapper before visiting them.
+func NewQuotaVisitor(v Visitor, mapper *Mapper) Visitor {
+	return QuotaVisitor{v, mapper}
+}
+
+// Visit implements Visitor.
+func (v QuotaVisitor) Visit(fn VisitorFunc) error {
+	var lock sync.Mutex
+	infos := []*Info{}
+	err := v.Visitor.Visit(func(info *Info) error {
+		lock.Lock()
+		defer lock.Unlock()
+		infos = append(infos, info)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	namespaces := []string{}
+	byNamespace := map[string][]*Info{}
+	for _, info := range infos {
+		if len(info.Namespace) == 0 {
+			continue
+		}
+		if _, found := byNamespace[info.Namespace]; !found {
+			namespaces = append(namespaces, info.Namespace)
+		}
+		byNamespace[info.Namespace] = append(byNamespace[info.Namespace], info)
+	}
+	errs := []error{}
+	for _, namespace := range namespaces {
+		quotas, err := v.list("ResourceQuota", namespace)
+		if err != nil {
+			return err
+		}
+		limitRanges, err := v.list("LimitRange", namespace)
+		if err != nil {
+			return err
+		}
+		quotaList, ok := quotas.(*api.ResourceQuotaList)
+		if !ok {
+			return fmt.Errorf("unable to check the quotas of namespace %q: expected a resource quota list, got %T", namespace, quotas)
+		}
+		limitRangeList, ok := limitRanges.(*api.LimitRangeList)
+		if !ok {
+			return fmt.Errorf("unable to check the limit ranges of namespace %q: expected a limit range list, got %T", namespace, limitRanges)
+		}
+		errs = append(errs, CheckQuota(byNamespace[namespace], quotaList.Items, limitRangeList.Items)...)
+	}
+	if len(errs) > 0 {
+		return errors.NewAggregate(errs)
+	}
+
+	for _, info := range infos {
+		if err := fn(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// list retrieves the objects of kind in namespace.
+func (v QuotaVisitor) list(kind, namespace string) (runtime.Object, error) {
+	mapping, err := v.Mapper.RESTMapping(kind)
+	if err != nil {
+		return nil, err
+	}
+	client, err := v.Mapper.ClientForMapping(mapping)
+	if err != nil {
+		return nil, err
+	}
+	list, err := NewHelper(client, mapping).List(namespace, mapping.APIVersion, labels.Everything(), fields.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve the %s of namespace %q: %v", mapping.Resource, namespace, err)
+	}
+	return list, nil
+}
+
+// CheckQuota returns an error for each way in which creating the objects of infos, all
+// in the same namespace, would exceed the given quotas of that namespace or violate
+// its limit ranges, as the server would enforce them on creation. Every object counts
+// against a quota on the number of objects of its resource, such as "pods" or
+// "services". The CPU and memory limits of pods count against quotas on "cpu" and
+// "memory", which require every container to set those limits, and must lie within
+// the minimums and maximums that each limit range sets for pods and containers.
+//
+// The default limits of the limit ranges apply to containers that do not set their
+// own. Quotas whose usage the server has not computed yet cannot be checked, and the
+// pods that replication controllers will create are not counted.
+func CheckQuota(infos []*Info, quotas []api.ResourceQuota, limitRanges []api.LimitRange) []error {
+	errs := []error{}
+	used := make([]api.ResourceList, len(quotas))
+	for i := range quotas {
+		used[i] = api.ResourceList{}
+		for name, quantity := range quotas[i].Status.Used {
+			used[i][name] = quantity
+		}
+	}
+
+	for _, info := range infos {
+		if info.Mapping == nil {
+			continue
+		}
+		description := fmt.Sprintf("%s %q", info.Mapping.Resource, info.Name)
+		count := api.ResourceName(info.Mapping.Resource)
+		names := []api.ResourceName{count}
+		requested := map[api.ResourceName]int64{count: 1000}
+		unbounded := map[api.ResourceName]bool{}
+		if pod, ok := info.Object.(*api.Pod); ok {
+			limits := podLimits(pod, limitRanges)
+			for _, limitRange := range limitRanges {
+				errs = append(errs, checkLimitRange(description, limits, limitRange)...)
+			}
+			for _, name := range []api.ResourceName{api.ResourceCPU, api.ResourceMemory} {
+				names = append(names, name)
+				for _, container := range limits {
+					if container.limits[name] == 0 {
+						unbounded[name] = true
+					}
+					requested[name] += container.limits[name]
+				}
+			}
+		}
+
+		for i, quota := range quotas {
+			for _, name := range names {
+				hard, found := quota.Status.Hard[name]
+				if !found {
+					continue
+				}
+				if unbounded[name] {
+					errs = append(errs, fmt.Errorf("%s does not set a %s limit for every container, which quota %q requires", description, name, quota.Name))
+					continue
+				}
+				current, found := used[i][name]
+				if !found {
+					continue
+				}
+				total := current.MilliValue() + requested[name]
+				if total > hard.MilliValue() {
+					errs = append(errs, fmt.Errorf("%s would exceed quota %q: limited to %s %s", description, quota.Name, hard.String(), name))
+					continue
+				}
+				used[i][name] = *milliQuantity(name, total)
+			}
+		}
+	}
+	return errs
+}
+
+// containerLimits are the limits of a container, in thousandths of their unit, after
+// the defaults of the limit ranges have been applied.
+type containerLimits struct {
+	name   string
+	limits map[api.ResourceName]int64
+}
+
+// podLimits returns the CPU and memory limits of the containers of pod.
+func podLimits(pod *api.Pod, limitRanges []api.LimitRange) []containerLimits {
+	defaults := api.ResourceList{}
+	for _, limitRange := range limitRanges {
+		for _, item := range limitRange.Spec.Limits {
+			if item.Type != api.LimitTypeContainer {
+				continue
+			}
+			for name, quantity := range item.Default {
+				defaults[name] = quantity
+			}
+		}
+	}
+	result := []containerLimits{}
+	for _, container := range pod.Spec.Containers {
+		limits := map[api.ResourceName]int64{}
+		for _, name := range []api.ResourceName{api.ResourceCPU, api.ResourceMemory} {
+			quantity, found := container.Resources.Limits[name]
+			if !found || quantity.MilliValue() == 0 {
+				quantity = defaults[name]
+			}
+			limits[name] = quantity.MilliValue()
+		}
+		result = append(result, containerLimits{container.Name, limits})
+	}
+	return result
+}
+
+// checkLimitRange returns an error for each pod or container limit that lies outside
+// of the bounds of limitRange.
+func checkLimitRange(description string, containers []containerLimits, limitRange api.LimitRange) []error {
+	errs := []error{}
+	check := func(item api.LimitRangeItem, subject string, name api.ResourceName, observed int64) {
+		if min, found := item.Min[name]; found && observed < min.MilliValue() {
+			errs = append(errs, fmt.Errorf("%s: the minimum %s per %s in limit range %q is %s, but %s is requested", description, name, subject, limitRange.Name, min.String(), milliQuantity(name, observed).String()))
+		}
+		if max, found := item.Max[name]; found && observed > max.MilliValue() {
+			errs = append(errs, fmt.Errorf("%s: the maximum %s per %s in limit range %q is %s, but %s is requested", description, name, subject, limitRange.Name, max.String(), milliQuantity(name, observed).String()))
+		}
+	}
+	for _, item := range limitRange.Spec.Limits {
+		for _, name := range []api.ResourceName{api.ResourceCPU, api.ResourceMemory} {
+			switch item.Type {
+			case api.LimitTypeContainer:
+				for _, container := range containers {
+					check(item, fmt.Sprintf("container (%s)", container.name), name, container.limits[name])
+				}
+			case api.LimitTypePod:
+				total := int64(0)
+				for _, container := range containers {
+					total += container.limits[name]
+				}
+				check(item, "pod", name, total)
+			}
+		}
+	}
+	return errs
+}
+
+// milliQuantity returns amount thousandths of the unit of the named resource as a
+// quantity.
+func milliQuantity(name api.ResourceName, amount int64) *resource.Quantity {
+	if name == api.ResourceCPU {
+		return resource.NewMilliQuantity(amount, resource.DecimalSI)
+	}
+	if name == api.ResourceMemory {
+		return resource.NewQuantity(amount/1000, resource.BinarySI)
+	}
+	return resource.NewQuantity(amount/1000, resource.DecimalSI)
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/meta"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/resource"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+func quotaPod(name string, limits ...api.ResourceList) *Info {
+	mapping, _ := latest.RESTMapper.RESTMapping("Pod")
+	pod := &api.Pod{ObjectMeta: api.ObjectMeta{Name: name, Namespace: "test"}}
+	for i, l := range limits {
+		pod.Spec.Containers = append(pod.Spec.Containers, api.Container{
+			Name:      string(rune('a' + i)),
+			Resources: api.ResourceRequirements{Limits: l},
+		})
+	}
+	return &Info{Name: name, Namespace: "test", Mapping: mapping, Object: pod}
+}
+
+func quotaService(name string) *Info {
+	mapping, _ := latest.RESTMapper.RESTMapping("Service")
+	return &Info{Name: name, Namespace: "test", Mapping: mapping, Object: &api.Service{ObjectMeta: api.ObjectMeta{Name: name}}}
+}
+
+func resources(cpu, memory string) api.ResourceList {
+	list := api.ResourceList{}
+	if len(cpu) > 0 {
+		list[api.ResourceCPU] = resource.MustParse(cpu)
+	}
+	if len(memory) > 0 {
+		list[api.ResourceMemory] = resource.MustParse(memory)
+	}
+	return list
+}
+
+func quota(name string, hard, used map[api.ResourceName]string) api.ResourceQuota {
+	q := api.ResourceQuota{ObjectMeta: api.ObjectMeta{Name: name}}
+	q.Status.Hard = api.ResourceList{}
+	for k, v := range hard {
+		q.Status.Hard[k] = resource.MustParse(v)
+	}
+	q.Status.Used = api.ResourceList{}
+	for k, v := range used {
+		q.Status.Used[k] = resource.MustParse(v)
+	}
+	return q
+}
+
+func TestCheckQuota(t *testing.T) {
+	limitRange := api.LimitRange{
+		ObjectMeta: api.ObjectMeta{Name: "limits"},
+		Spec: api.LimitRangeSpec{Limits: []api.LimitRangeItem{
+			{Type: api.LimitTypeContainer, Min: resources("100m", ""), Max: resources("1", "1Gi"), Default: resources("200m", "256Mi")},
+			{Type: api.LimitTypePod, Max: resources("2", "")},
+		}},
+	}
+
+	tests := map[string]struct {
+		Infos       []*Info
+		Quotas      []api.ResourceQuota
+		LimitRanges []api.LimitRange
+		Errs        []string
+	}{
+		"within quota": {
+			Infos:  []*Info{quotaPod("foo"), quotaService("baz")},
+			Quotas: []api.ResourceQuota{quota("objects", map[api.ResourceName]string{"pods": "2", "services": "1"}, map[api.ResourceName]string{"pods": "1", "services": "0"})},
+		},
+		"object count exceeded by the second object": {
+			Infos:  []*Info{quotaPod("foo"), quotaPod("bar")},
+			Quotas: []api.ResourceQuota{quota("objects", map[api.ResourceName]string{"pods": "2"}, map[api.ResourceName]string{"pods": "1"})},
+			Errs:   []string{`pods "bar" would exceed quota "objects": limited to 2 pods`},
+		},
+		"usage not yet known": {
+			Infos:  []*Info{quotaPod("foo"), quotaPod("bar")},
+			Quotas: []api.ResourceQuota{quota("objects", map[api.ResourceName]string{"pods": "1"}, nil)},
+		},
+		"compute quota": {
+			Infos:  []*Info{quotaPod("foo", resources("500m", "128Mi")), quotaPod("bar", resources("600m", "128Mi"))},
+			Quotas: []api.ResourceQuota{quota("compute", map[api.ResourceName]string{"cpu": "1", "memory": "1Gi"}, map[api.ResourceName]string{"cpu": "0", "memory": "0"})},
+			Errs:   []string{`pods "bar" would exceed quota "compute": limited to 1 cpu`},
+		},
+		"compute quota requires limits": {
+			Infos:  []*Info{quotaPod("foo", resources("500m", ""))},
+			Quotas: []api.ResourceQuota{quota("compute", map[api.ResourceName]string{"memory": "1Gi"}, map[api.ResourceName]string{"memory": "0"})},
+			Errs:   []string{`pods "foo" does not set a memory limit for every container, which quota "compute" requires`},
+		},
+		"limit range defaults count against the quota": {
+			Infos:       []*Info{quotaPod("foo", nil, nil)},
+			Quotas:      []api.ResourceQuota{quota("compute", map[api.ResourceName]string{"memory": "500Mi"}, map[api.ResourceName]string{"memory": "0"})},
+			LimitRanges: []api.LimitRange{limitRange},
+			Errs:        []string{`pods "foo" would exceed quota "compute": limited to 500Mi memory`},
+		},
+		"limit range violations": {
+			Infos:       []*Info{quotaPod("foo", resources("50m", ""), resources("1", "2Gi"), resources("1", ""))},
+			LimitRanges: []api.LimitRange{limitRange},
+			Errs: []string{
+				`pods "foo": the minimum cpu per container (a) in limit range "limits" is 100m, but 50m is requested`,
+				`pods "foo": the maximum memory per container (b) in limit range "limits" is 1Gi, but 2Gi is requested`,
+				`pods "foo": the maximum cpu per pod in limit range "limits" is 2, but 2050m is requested`,
+			},
+		},
+	}
+	for k, test := range tests {
+		errs := CheckQuota(test.Infos, test.Quotas, test.LimitRanges)
+		if len(errs) != len(test.Errs) {
+			t.Errorf("%s: unexpected errors: %v", k, errs)
+			continue
+		}
+		for i := range errs {
+			if errs[i].Error() != test.Errs[i] {
+				t.Errorf("%s: expected %q, got %q", k, test.Errs[i], errs[i])
+			}
+		}
+	}
+}
+
+func TestBuilderCheckQuota(t *testing.T) {
+	pods, _ := testData()
+	quotas := &api.ResourceQuotaList{Items: []api.ResourceQuota{
+		quota("objects", map[api.ResourceName]string{"pods": "10"}, map[api.ResourceName]string{"pods": "9"}),
+	}}
+	b := NewBuilder(latest.RESTMapper, api.Scheme, fakeClientWith("", t, map[string]string{
+		"/namespaces/test/resourcequotas": runtime.EncodeOrDie(latest.Codec, quotas),
+		"/namespaces/test/limitranges":    runtime.EncodeOrDie(latest.Codec, &api.LimitRangeList{}),
+	})).
+		NamespaceParam("test").
+		Stream(strings.NewReader(runtime.EncodeOrDie(latest.Codec, pods)), "STDIN").
+		Flatten().
+		CheckQuota()
+
+	visited := 0
+	err := b.Do().Visit(func(*Info) error {
+		visited++
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), `pods "bar" would exceed quota "objects"`) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if visited != 0 {
+		t.Errorf("no object should be visited when the quota would be exceeded")
+	}
+}
+
+func TestQuotaVisitorUnexpectedList(t *testing.T) {
+	// a client that decodes the lists as runtime.Unstructured
+	clients := ClientMapperFunc(func(*meta.RESTMapping) (RESTClient, error) {
+		return &client.FakeRESTClient{
+			Codec: runtime.UnstructuredJSONCodec,
+			Resp:  &http.Response{StatusCode: http.StatusOK, Body: stringBody(`{"kind":"ResourceQuotaList","apiVersion":"v1","items":[]}`)},
+		}, nil
+	})
+	mapper := &Mapper{ObjectTyper: api.Scheme, RESTMapper: latest.RESTMapper, ClientMapper: clients}
+	err := NewQuotaVisitor(quotaPod("foo"), mapper).Visit(func(*Info) error {
+		t.Fatalf("no object should be visited")
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "expected a resource quota list") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
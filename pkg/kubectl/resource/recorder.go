@@ -0,0 +1,243 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/meta"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+// RecordedRequest is a change that a Recorder kept instead of sending it to the server.
+type RecordedRequest struct {
+	// The HTTP method: POST, PUT, PATCH, or DELETE.
+	Verb        string
+	APIVersion  string
+	Resource    string
+	Namespace   string
+	Name        string
+	Subresource string
+	// The query parameters of the request, such as a grace period.
+	Params url.Values
+	// The type of a patch, for PATCH requests.
+	ContentType string
+	Body        []byte
+
+	mapping *meta.RESTMapping
+}
+
+// Recorder is a ClientMapper whose clients record every request that would change an
+// object instead of sending it, so that commands and Helpers can run without a server,
+// for example to show what they would do or to test them. The recorded requests can
+// be sent to a server later with Replay.
+//
+// Reads are sent to the clients of Reads if it is set. Otherwise every read fails
+// with a NotFound error, as if the server held no objects. Creates and replaces
+// return the object that was sent, while patches and deletes return a successful
+// api.Status, since the object they would change is not known.
+type Recorder struct {
+	Reads ClientMapper
+
+	lock     sync.Mutex
+	requests []RecordedRequest
+}
+
+// NewRecorder creates a Recorder that sends reads to the clients of reads, if it is
+// not nil.
+func NewRecorder(reads ClientMapper) *Recorder {
+	return &Recorder{Reads: reads}
+}
+
+// ClientForMapping implements ClientMapper.
+func (r *Recorder) ClientForMapping(mapping *meta.RESTMapping) (RESTClient, error) {
+	c := &recordingClient{recorder: r, mapping: mapping}
+	if r.Reads != nil {
+		reads, err := r.Reads.ClientForMapping(mapping)
+		if err != nil {
+			return nil, err
+		}
+		c.reads = reads
+	}
+	return c, nil
+}
+
+// Requests returns the requests recorded so far, in the order they were made.
+func (r *Recorder) Requests() []RecordedRequest {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]RecordedRequest{}, r.requests...)
+}
+
+// Reset forgets the requests recorded so far.
+func (r *Recorder) Reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.requests = nil
+}
+
+// Replay sends the recorded requests, in order, through the clients of clients. It
+// stops at the first request that fails and returns its error.
+func (r *Recorder) Replay(clients ClientMapper) error {
+	for _, recorded := range r.Requests() {
+		c, err := clients.ClientForMapping(recorded.mapping)
+		if err != nil {
+			return err
+		}
+		var req *client.Request
+		switch recorded.Verb {
+		case "POST":
+			req = c.Post()
+		case "PUT":
+			req = c.Put()
+		case "PATCH":
+			req = c.Patch(api.PatchType(recorded.ContentType))
+		case "DELETE":
+			req = c.Delete()
+		default:
+			return fmt.Errorf("unable to replay a %s request", recorded.Verb)
+		}
+		if len(recorded.Namespace) > 0 {
+			req.Namespace(recorded.Namespace)
+		}
+		req.Resource(recorded.Resource)
+		if len(recorded.Name) > 0 {
+			req.Name(recorded.Name)
+		}
+		if len(recorded.Subresource) > 0 {
+			req.SubResource(recorded.Subresource)
+		}
+		for key, values := range recorded.Params {
+			for _, value := range values {
+				req.Param(key, value)
+			}
+		}
+		if len(recorded.Body) > 0 {
+			req.Body(recorded.Body)
+		}
+		if err := req.Do().Error(); err != nil {
+			return fmt.Errorf("unable to replay %s of %s %q: %v", recorded.Verb, recorded.Resource, recorded.Name, err)
+		}
+	}
+	return nil
+}
+
+// record keeps req and returns the response the server is assumed to have sent.
+func (r *Recorder) record(mapping *meta.RESTMapping, req *http.Request) (*http.Response, error) {
+	recorded := RecordedRequest{
+		Verb:        req.Method,
+		APIVersion:  mapping.APIVersion,
+		Params:      req.URL.Query(),
+		ContentType: req.Header.Get("Content-Type"),
+		mapping:     mapping,
+	}
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(segments) >= 3 && segments[0] == "namespaces" && mapping.Resource != "namespaces" {
+		recorded.Namespace, segments = segments[1], segments[2:]
+	}
+	recorded.Resource = segments[0]
+	if len(segments) > 1 {
+		recorded.Name = segments[1]
+	}
+	if len(segments) > 2 {
+		recorded.Subresource = strings.Join(segments[2:], "/")
+	}
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		recorded.Body = body
+	}
+
+	r.lock.Lock()
+	r.requests = append(r.requests, recorded)
+	r.lock.Unlock()
+
+	switch req.Method {
+	case "POST":
+		return recordedResponse(http.StatusCreated, recorded.Body), nil
+	case "PUT":
+		return recordedResponse(http.StatusOK, recorded.Body), nil
+	default:
+		status := &api.Status{Status: api.StatusSuccess, Code: http.StatusOK}
+		return recordedResponse(http.StatusOK, []byte(runtime.EncodeOrDie(mapping.Codec, status))), nil
+	}
+}
+
+// recordedResponse returns a response with the given status code and body.
+func recordedResponse(code int, body []byte) *http.Response {
+	return &http.Response{
+		StatusCode: code,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(bytes.NewReader(body)),
+	}
+}
+
+// recordingClient is the RESTClient a Recorder returns for a mapping.
+type recordingClient struct {
+	recorder *Recorder
+	mapping  *meta.RESTMapping
+	reads    RESTClient
+}
+
+func (c *recordingClient) request(verb string) *client.Request {
+	record := client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		return c.recorder.record(c.mapping, req)
+	})
+	return client.NewRequest(record, verb, &url.URL{Host: "localhost"}, c.mapping.APIVersion, c.mapping.Codec)
+}
+
+func (c *recordingClient) Get() *client.Request {
+	if c.reads != nil {
+		return c.reads.Get()
+	}
+	notFound := client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		status := &api.Status{
+			Status:  api.StatusFailure,
+			Code:    http.StatusNotFound,
+			Reason:  api.StatusReasonNotFound,
+			Message: fmt.Sprintf("%s not found", req.URL.Path),
+		}
+		return recordedResponse(http.StatusNotFound, []byte(runtime.EncodeOrDie(c.mapping.Codec, status))), nil
+	})
+	return client.NewRequest(notFound, "GET", &url.URL{Host: "localhost"}, c.mapping.APIVersion, c.mapping.Codec)
+}
+
+func (c *recordingClient) Post() *client.Request {
+	return c.request("POST")
+}
+
+func (c *recordingClient) Patch(pt api.PatchType) *client.Request {
+	return c.request("PATCH").SetHeader("Content-Type", string(pt))
+}
+
+func (c *recordingClient) Delete() *client.Request {
+	return c.request("DELETE")
+}
+
+func (c *recordingClient) Put() *client.Request {
+	return c.request("PUT")
+}
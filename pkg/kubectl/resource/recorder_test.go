@@ -0,0 +1,144 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/meta"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	resourceerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/kubectl/resource/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+func TestRecorder(t *testing.T) {
+	pods, _ := testData()
+	recorder := NewRecorder(nil)
+	infos, err := NewBuilder(latest.RESTMapper, api.Scheme, recorder).
+		Stream(strings.NewReader(runtime.EncodeOrDie(latest.Codec, pods)), "STDIN").
+		Flatten().
+		Do().Infos()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, info := range infos {
+		helper := NewHelper(info.Client, info.Mapping)
+		if _, err := helper.Get(info.Namespace, info.Name); !resourceerrors.IsNotFound(err) {
+			t.Errorf("expected reads to find nothing: %v", err)
+		}
+		data, err := info.Mapping.Codec.Encode(info.Object)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		obj, err := helper.Create(info.Namespace, true, data)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if obj.(*api.Pod).Name != info.Name {
+			t.Errorf("expected the created object to be returned: %#v", obj)
+		}
+	}
+	helper := NewHelper(infos[0].Client, infos[0].Mapping)
+	if _, err := helper.Patch("test", "foo", api.MergePatchType, []byte(`{"metadata":{"labels":{"a":"b"}}}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := helper.Delete("test", "bar"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	type request struct{ Verb, Namespace, Resource, Name string }
+	recorded := []request{}
+	for _, r := range recorder.Requests() {
+		recorded = append(recorded, request{r.Verb, r.Namespace, r.Resource, r.Name})
+	}
+	expected := []request{
+		{"POST", "test", "pods", ""},
+		{"POST", "test", "pods", ""},
+		{"PATCH", "test", "pods", "foo"},
+		{"DELETE", "test", "pods", "bar"},
+	}
+	if !reflect.DeepEqual(expected, recorded) {
+		t.Fatalf("unexpected requests: %v", recorded)
+	}
+	if patch := recorder.Requests()[2]; patch.ContentType != string(api.MergePatchType) || string(patch.Body) != `{"metadata":{"labels":{"a":"b"}}}` {
+		t.Errorf("unexpected patch: %#v", patch)
+	}
+
+	replayed := []string{}
+	err = recorder.Replay(ClientMapperFunc(func(mapping *meta.RESTMapping) (RESTClient, error) {
+		return &client.FakeRESTClient{
+			Codec: latest.Codec,
+			Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+				body := []byte{}
+				if req.Body != nil {
+					body, _ = ioutil.ReadAll(req.Body)
+				}
+				replayed = append(replayed, req.Method+" "+req.URL.Path)
+				if req.Method == "POST" {
+					return &http.Response{StatusCode: http.StatusCreated, Body: stringBody(string(body))}, nil
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Status{Status: api.StatusSuccess})}, nil
+			}),
+		}, nil
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedReplay := []string{
+		"POST /namespaces/test/pods",
+		"POST /namespaces/test/pods",
+		"PATCH /namespaces/test/pods/foo",
+		"DELETE /namespaces/test/pods/bar",
+	}
+	if !reflect.DeepEqual(expectedReplay, replayed) {
+		t.Errorf("unexpected replay: %v", replayed)
+	}
+
+	recorder.Reset()
+	if len(recorder.Requests()) != 0 {
+		t.Errorf("expected no requests after a reset")
+	}
+}
+
+func TestRecorderReads(t *testing.T) {
+	pods, _ := testData()
+	recorder := NewRecorder(fakeClientWith("", t, map[string]string{
+		"/namespaces/test/pods/foo": runtime.EncodeOrDie(latest.Codec, &pods.Items[0]),
+	}))
+	mapping, err := latest.RESTMapper.RESTMapping("Pod")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c, err := recorder.ClientForMapping(mapping)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	obj, err := NewHelper(c, mapping).Get("test", "foo")
+	if err != nil || obj.(*api.Pod).Name != "foo" {
+		t.Errorf("unexpected result: %#v %v", obj, err)
+	}
+	if len(recorder.Requests()) != 0 {
+		t.Errorf("reads should not be recorded: %v", recorder.Requests())
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+)
+
+// Reference describes a field of an object that holds the name of another object.
+type Reference struct {
+	// FieldPath is the dot separated path of JSON field names leading to the
+	// referenced name, for example "spec.volumes.secret.secretName". Lists found
+	// along the path are traversed element by element, so a single path may
+	// yield several names.
+	FieldPath string
+	// Kind is the kind of the referenced object. It selects the Helper used to
+	// resolve the reference.
+	Kind string
+}
+
+// BrokenReference is a reference to an object that does not exist.
+type BrokenReference struct {
+	Reference
+	// Name is the name of the missing object.
+	Name string
+}
+
+// CheckReferences retrieves the named object and verifies that every object it
+// names through refs exists, using the Helper registered in resolvers for the kind
+// of each reference. Referenced objects are looked up in the same namespace as the
+// object. References that do not resolve are returned, including those a resolver
+// with IgnoreNotFound set reports no object for; an error is returned only if the
+// object or one of its references could not be checked.
+func (m *Helper) CheckReferences(namespace, name string, refs []Reference, resolvers map[string]*Helper) ([]BrokenReference, error) {
+	obj, err := m.get(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := m.Codec.Encode(obj)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	broken := []BrokenReference{}
+	for _, ref := range refs {
+		resolver, ok := resolvers[ref.Kind]
+		if !ok {
+			return nil, fmt.Errorf("no resolver was provided for references to %q", ref.Kind)
+		}
+		for _, refName := range namesAtPath(fields, strings.Split(ref.FieldPath, ".")) {
+			obj, err := resolver.Get(namespace, refName)
+			switch {
+			// a resolver with IgnoreNotFound set returns no object for a missing one
+			case errors.IsNotFound(err), err == nil && obj == nil:
+				broken = append(broken, BrokenReference{Reference: ref, Name: refName})
+			case err != nil:
+				return nil, err
+			}
+		}
+	}
+	return broken, nil
+}
+
+// namesAtPath returns the non-empty strings found by following path from value,
+// descending into every element of any list encountered along the way.
+func namesAtPath(value interface{}, path []string) []string {
+	switch t := value.(type) {
+	case []interface{}:
+		names := []string{}
+		for _, item := range t {
+			names = append(names, namesAtPath(item, path)...)
+		}
+		return names
+	case map[string]interface{}:
+		if len(path) == 0 {
+			return nil
+		}
+		return namesAtPath(t[path[0]], path[1:])
+	case string:
+		if len(path) == 0 && len(t) != 0 {
+			return []string{t}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/testapi"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+)
+
+func TestHelperCheckReferences(t *testing.T) {
+	pod := &api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "foo", Namespace: "bar"},
+		Spec: api.PodSpec{
+			Volumes: []api.Volume{
+				{Name: "a", VolumeSource: api.VolumeSource{Secret: &api.SecretVolumeSource{SecretName: "exists"}}},
+				{Name: "b", VolumeSource: api.VolumeSource{Secret: &api.SecretVolumeSource{SecretName: "missing"}}},
+				{Name: "c", VolumeSource: api.VolumeSource{EmptyDir: &api.EmptyDirVolumeSource{}}},
+			},
+			ImagePullSecrets: []api.LocalObjectReference{{Name: "exists"}},
+		},
+	}
+	podHelper := &Helper{
+		RESTClient: &client.FakeRESTClient{
+			Codec: testapi.Codec(),
+			Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK, Body: objBody(pod)}, nil
+			}),
+		},
+		Resource:        "pods",
+		Codec:           testapi.Codec(),
+		NamespaceScoped: true,
+	}
+	secrets := []string{}
+	secretHelper := &Helper{
+		RESTClient: &client.FakeRESTClient{
+			Codec: testapi.Codec(),
+			Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+				parts := splitPath(req.URL.Path)
+				name := parts[len(parts)-1]
+				secrets = append(secrets, name)
+				if name == "exists" {
+					return &http.Response{StatusCode: http.StatusOK, Body: objBody(&api.Secret{ObjectMeta: api.ObjectMeta{Name: name}})}, nil
+				}
+				return &http.Response{StatusCode: http.StatusNotFound, Body: objBody(&api.Status{Status: api.StatusFailure, Reason: api.StatusReasonNotFound})}, nil
+			}),
+		},
+		Resource:        "secrets",
+		NamespaceScoped: true,
+	}
+	refs := []Reference{
+		{FieldPath: "spec.volumes.secret.secretName", Kind: "Secret"},
+		{FieldPath: "spec.imagePullSecrets.name", Kind: "Secret"},
+	}
+
+	broken, err := podHelper.CheckReferences("bar", "foo", refs, map[string]*Helper{"Secret": secretHelper})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []BrokenReference{{Reference: refs[0], Name: "missing"}}
+	if !reflect.DeepEqual(broken, expected) {
+		t.Errorf("unexpected broken references: %#v", broken)
+	}
+	if !reflect.DeepEqual(secrets, []string{"exists", "missing", "exists"}) {
+		t.Errorf("unexpected lookups: %v", secrets)
+	}
+
+	// a resolver that ignores missing objects still reports them
+	secrets = []string{}
+	secretHelper.IgnoreNotFound = true
+	broken, err = podHelper.CheckReferences("bar", "foo", refs, map[string]*Helper{"Secret": secretHelper})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(broken, expected) {
+		t.Errorf("unexpected broken references: %#v", broken)
+	}
+
+	if _, err := podHelper.CheckReferences("bar", "foo", refs, map[string]*Helper{}); err == nil || !strings.Contains(err.Error(), "no resolver") {
+		t.Errorf("expected an error for a missing resolver: %v", err)
+	}
+}
@@ -19,6 +19,7 @@ package resource
 import (
 	"fmt"
 	"reflect"
+	"sync"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
@@ -97,8 +98,11 @@ func (r *Result) Infos() ([]*Info, error) {
 		return r.info, nil
 	}
 
+	var lock sync.Mutex
 	infos := []*Info{}
 	err := r.visitor.Visit(func(info *Info) error {
+		lock.Lock()
+		defer lock.Unlock()
 		infos = append(infos, info)
 		return nil
 	})
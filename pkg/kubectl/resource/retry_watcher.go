@@ -0,0 +1,471 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/meta"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+)
+
+// retryWatchDelay is how long a RetryWatcher waits before reopening a watch or
+// repeating a list that failed.
+var retryWatchDelay = time.Second
+
+// WatchFunc opens a watch on the server starting after resourceVersion.
+type WatchFunc func(resourceVersion string) (watch.Interface, error)
+
+// ListFunc lists the objects being watched.
+type ListFunc func() (runtime.Object, error)
+
+// RetryWatcher presents a single watch.Interface over a series of server watches.
+// Whenever the server closes a watch, a new one is opened starting after the
+// resource version of the last event delivered or bookmark received, so the
+// consumer sees one uninterrupted stream. Events are buffered between the server
+// and the consumer.
+//
+// If the server no longer has the history needed to resume (410 Gone), the
+// objects are listed again, and the difference between the list and the objects
+// seen so far is delivered as Added, Modified, and Deleted events before watching
+// resumes from the version of the list. Without a ListFunc, the error is
+// delivered instead and the result channel is closed.
+type RetryWatcher struct {
+	watchFn   WatchFunc
+	listFn    ListFunc
+	versioner runtime.ResourceVersioner
+
+	// known holds the last version of every object that exists as far as the
+	// consumer knows, by namespace and name, starting with the items of the initial
+	// list. It is only kept with a ListFunc.
+	known map[string]runtime.Object
+
+	result chan watch.Event
+	// stop is closed when the consumer asks the watcher to stop or drain.
+	stop chan struct{}
+	// done is closed when no more events will be added to result.
+	done        chan struct{}
+	closeResult sync.Once
+
+	lock            sync.Mutex
+	resourceVersion string
+	current         watch.Interface
+	stopped         bool
+	draining        bool
+}
+
+// NewRetryWatcher creates a RetryWatcher that opens watches with watchFn starting
+// after resourceVersion, tracking the resource version of delivered objects with
+// versioner. If listFn is not nil, it is used to recover when the server has
+// expired resourceVersion. Up to bufferSize events are held for the consumer.
+//
+// If the consumer listed the objects before watching, the list should be passed as
+// initial, so that recovering does not deliver its items as Added again and does
+// deliver their deletion.
+func NewRetryWatcher(resourceVersion string, initial runtime.Object, watchFn WatchFunc, listFn ListFunc, versioner runtime.ResourceVersioner, bufferSize int) *RetryWatcher {
+	w := &RetryWatcher{
+		watchFn:   watchFn,
+		listFn:    listFn,
+		versioner: versioner,
+		known:     map[string]runtime.Object{},
+
+		result: make(chan watch.Event, bufferSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+
+		resourceVersion: resourceVersion,
+	}
+	if initial != nil && listFn != nil {
+		w.seed(initial)
+	}
+	go w.receive()
+	return w
+}
+
+// seed records the items of list as known to the consumer.
+func (w *RetryWatcher) seed(list runtime.Object) {
+	items, err := runtime.ExtractList(list)
+	if err != nil {
+		glog.V(4).Infof("Unable to track listed objects: %v", err)
+		return
+	}
+	for _, item := range items {
+		key, err := objectKey(item)
+		if err != nil {
+			glog.V(4).Infof("Unable to track listed object: %v", err)
+			continue
+		}
+		w.known[key] = item
+	}
+}
+
+// ResultChan implements watch.Interface.
+func (w *RetryWatcher) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+// ResourceVersion returns the resource version of the last event delivered, or of
+// the last bookmark received if it is newer.
+func (w *RetryWatcher) ResourceVersion() string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.resourceVersion
+}
+
+// Stop implements watch.Interface. Any buffered events are discarded and the
+// result channel is closed.
+func (w *RetryWatcher) Stop() {
+	if !w.halt(false) {
+		return
+	}
+	<-w.done
+	w.discardAndClose()
+}
+
+// Drain stops opening new watches and stops the current one, but lets events
+// that are already buffered flow to the consumer. The result channel is closed
+// once the buffer empties or timeout elapses, whichever comes first; events still
+// buffered at the timeout are discarded. Drain blocks until the channel is closed.
+func (w *RetryWatcher) Drain(timeout time.Duration) {
+	if !w.halt(true) {
+		return
+	}
+	<-w.done
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for len(w.result) > 0 {
+		select {
+		case <-deadline:
+			glog.V(4).Infof("Discarding %d buffered watch events after draining for %v", len(w.result), timeout)
+			w.discardAndClose()
+			return
+		case <-ticker.C:
+		}
+	}
+	w.discardAndClose()
+}
+
+// halt marks the watcher as stopped or draining and stops the current watch. It
+// returns false if the watcher was already halted.
+func (w *RetryWatcher) halt(drain bool) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.stopped || w.draining {
+		return false
+	}
+	w.stopped = !drain
+	w.draining = drain
+	close(w.stop)
+	if w.current != nil {
+		w.current.Stop()
+	}
+	return true
+}
+
+// discardAndClose empties the buffer and closes the result channel.
+func (w *RetryWatcher) discardAndClose() {
+	w.closeResult.Do(func() {
+		for {
+			select {
+			case <-w.result:
+			default:
+				close(w.result)
+				return
+			}
+		}
+	})
+}
+
+// halted returns true if Stop or Drain has been called.
+func (w *RetryWatcher) halted() bool {
+	select {
+	case <-w.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// receive opens watches until the watcher is halted, forwarding their events.
+func (w *RetryWatcher) receive() {
+	defer close(w.done)
+	defer util.HandleCrash()
+	for !w.halted() {
+		source, err := w.watchFn(w.ResourceVersion())
+		if err != nil {
+			if isGone(err) {
+				if !w.recover(errorEvent(err)) {
+					return
+				}
+				continue
+			}
+			glog.V(4).Infof("Unable to open watch, retrying in %v: %v", retryWatchDelay, err)
+			w.wait()
+			continue
+		}
+		if !w.setCurrent(source) {
+			source.Stop()
+			return
+		}
+		expired, received := w.forward(source)
+		w.setCurrent(nil)
+		if expired != nil {
+			source.Stop()
+			if !w.recover(*expired) {
+				return
+			}
+			continue
+		}
+		if !received {
+			// A watch the server ends without any event is likely to end again at once.
+			glog.V(4).Infof("Watch closed without events, reopening in %v", retryWatchDelay)
+			w.wait()
+		}
+	}
+}
+
+// wait sleeps before a retry, returning early if the watcher is halted.
+func (w *RetryWatcher) wait() {
+	select {
+	case <-w.stop:
+	case <-time.After(retryWatchDelay):
+	}
+}
+
+// setCurrent records the watch being read from so that it can be stopped. It
+// returns false if the watcher was halted in the meantime.
+func (w *RetryWatcher) setCurrent(source watch.Interface) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if source != nil && (w.stopped || w.draining) {
+		return false
+	}
+	w.current = source
+	return true
+}
+
+// send delivers event to the consumer, returning false if the watcher was halted
+// before it could be delivered.
+func (w *RetryWatcher) send(event watch.Event) bool {
+	select {
+	case w.result <- event:
+		return true
+	case <-w.stop:
+		return false
+	}
+}
+
+// forward delivers events from source until it closes or the watcher is halted,
+// and returns whether any event was received. If the server reports that the
+// history needed to continue has expired, the error event is returned instead of
+// being delivered. Bookmarks only advance the resource version the next watch
+// starts from and are not delivered.
+func (w *RetryWatcher) forward(source watch.Interface) (*watch.Event, bool) {
+	received := false
+	for event := range source.ResultChan() {
+		received = true
+		if event.Type == watch.Error && isGoneStatus(event.Object) {
+			return &event, true
+		}
+		if event.Type == watch.Bookmark {
+			w.bookmark(event.Object)
+			continue
+		}
+		if !w.send(event) {
+			return nil, true
+		}
+		if event.Type == watch.Error {
+			continue
+		}
+		w.observe(event)
+	}
+	return nil, received
+}
+
+// observe records the resource version of a delivered event and, when relisting
+// is possible, the object it describes.
+func (w *RetryWatcher) observe(event watch.Event) {
+	w.bookmark(event.Object)
+	if w.listFn == nil {
+		return
+	}
+	key, err := objectKey(event.Object)
+	if err != nil {
+		glog.V(4).Infof("Unable to track watched object: %v", err)
+		return
+	}
+	if event.Type == watch.Deleted {
+		delete(w.known, key)
+	} else {
+		w.known[key] = event.Object
+	}
+}
+
+// bookmark records the resource version of obj as the one the next watch starts
+// from.
+func (w *RetryWatcher) bookmark(obj runtime.Object) {
+	if version, err := w.versioner.ResourceVersion(obj); err == nil && len(version) != 0 {
+		w.lock.Lock()
+		w.resourceVersion = version
+		w.lock.Unlock()
+	}
+}
+
+// recover handles an expired resource version reported by expired. Without a
+// ListFunc the error is delivered and the result channel is closed. Otherwise the
+// objects are listed until it succeeds, and the changes since the last delivered
+// event are sent. It returns false if the watcher should not continue.
+func (w *RetryWatcher) recover(expired watch.Event) bool {
+	if w.listFn == nil {
+		w.fail(expired)
+		return false
+	}
+	glog.V(4).Infof("Resource version %s has expired, listing again", w.ResourceVersion())
+	for !w.halted() {
+		list, err := w.listFn()
+		if err == nil {
+			return w.relist(list)
+		}
+		glog.V(4).Infof("Unable to list, retrying in %v: %v", retryWatchDelay, err)
+		w.wait()
+	}
+	return false
+}
+
+// relist delivers the difference between list and the objects known to the
+// consumer, and resumes watching after the resource version of list.
+func (w *RetryWatcher) relist(list runtime.Object) bool {
+	items, err := runtime.ExtractList(list)
+	if err != nil {
+		w.fail(errorEvent(err))
+		return false
+	}
+	listed := map[string]bool{}
+	for _, item := range items {
+		key, err := objectKey(item)
+		if err != nil {
+			glog.V(4).Infof("Unable to track listed object: %v", err)
+			continue
+		}
+		listed[key] = true
+		eventType := watch.Added
+		if last, ok := w.known[key]; ok {
+			eventType = watch.Modified
+			if w.sameVersion(last, item) {
+				continue
+			}
+		}
+		if !w.send(watch.Event{Type: eventType, Object: item}) {
+			return false
+		}
+		w.known[key] = item
+	}
+
+	deleted := []string{}
+	for key := range w.known {
+		if !listed[key] {
+			deleted = append(deleted, key)
+		}
+	}
+	sort.Strings(deleted)
+	for _, key := range deleted {
+		if !w.send(watch.Event{Type: watch.Deleted, Object: w.known[key]}) {
+			return false
+		}
+		delete(w.known, key)
+	}
+
+	if version, err := w.versioner.ResourceVersion(list); err == nil {
+		w.lock.Lock()
+		w.resourceVersion = version
+		w.lock.Unlock()
+	}
+	return true
+}
+
+// fail delivers an error event that ends the watch and closes the result channel.
+func (w *RetryWatcher) fail(event watch.Event) {
+	w.send(event)
+	w.closeResult.Do(func() { close(w.result) })
+}
+
+// sameVersion returns true if a and b have the same, known resource version.
+func (w *RetryWatcher) sameVersion(a, b runtime.Object) bool {
+	aVersion, err := w.versioner.ResourceVersion(a)
+	if err != nil || len(aVersion) == 0 {
+		return false
+	}
+	bVersion, err := w.versioner.ResourceVersion(b)
+	return err == nil && aVersion == bVersion
+}
+
+// objectKey identifies obj by its namespace and name.
+func objectKey(obj runtime.Object) (string, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return "", err
+	}
+	return accessor.Namespace() + "/" + accessor.Name(), nil
+}
+
+// isGone returns true if err reports that a resource version has expired.
+func isGone(err error) bool {
+	status, ok := err.(client.APIStatus)
+	return ok && status.Status().Code == http.StatusGone
+}
+
+// isGoneStatus returns true if the object of an error event reports that a
+// resource version has expired.
+func isGoneStatus(obj runtime.Object) bool {
+	status, ok := obj.(*api.Status)
+	return ok && status.Code == http.StatusGone
+}
+
+// errorEvent describes err as a watch error event.
+func errorEvent(err error) watch.Event {
+	if status, ok := err.(client.APIStatus); ok {
+		s := status.Status()
+		return watch.Event{Type: watch.Error, Object: &s}
+	}
+	return watch.Event{Type: watch.Error, Object: &api.Status{Status: api.StatusFailure, Message: err.Error()}}
+}
+
+// RetryWatch returns a RetryWatcher over the resources matching the selectors,
+// starting after resourceVersion. If the server expires resourceVersion, the
+// resources are listed again with the same selectors. initial is the list the
+// watch continues from, if any. Up to bufferSize events are held for the consumer.
+func (m *Helper) RetryWatch(namespace, resourceVersion, apiVersion string, initial runtime.Object, labelSelector labels.Selector, fieldSelector fields.Selector, bufferSize int) *RetryWatcher {
+	watchFn := func(resourceVersion string) (watch.Interface, error) {
+		return m.Watch(namespace, resourceVersion, apiVersion, labelSelector, fieldSelector)
+	}
+	listFn := func() (runtime.Object, error) {
+		return m.List(namespace, apiVersion, labelSelector, fieldSelector)
+	}
+	return NewRetryWatcher(resourceVersion, initial, watchFn, listFn, m.Versioner, bufferSize)
+}
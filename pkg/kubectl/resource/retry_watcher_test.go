@@ -0,0 +1,318 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	apierrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/testapi"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+)
+
+// fakeWatches hands out a new FakeWatcher for every call, recording the
+// resource version each watch was opened from.
+type fakeWatches struct {
+	lock     sync.Mutex
+	versions []string
+	watches  chan *watch.FakeWatcher
+}
+
+func newFakeWatches() *fakeWatches {
+	return &fakeWatches{watches: make(chan *watch.FakeWatcher, 10)}
+}
+
+func (f *fakeWatches) Watch(resourceVersion string) (watch.Interface, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.versions = append(f.versions, resourceVersion)
+	w := watch.NewFake()
+	f.watches <- w
+	return w, nil
+}
+
+func (f *fakeWatches) Versions() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]string{}, f.versions...)
+}
+
+func podWithVersion(name, resourceVersion string) *api.Pod {
+	return &api.Pod{ObjectMeta: api.ObjectMeta{Name: name, ResourceVersion: resourceVersion}}
+}
+
+func TestRetryWatcherReconnects(t *testing.T) {
+	watches := newFakeWatches()
+	w := NewRetryWatcher("1", nil, watches.Watch, nil, testapi.MetadataAccessor(), 0)
+	defer w.Stop()
+
+	first := <-watches.watches
+	go func() {
+		first.Add(podWithVersion("foo", "2"))
+		first.Modify(podWithVersion("foo", "3"))
+		first.Stop()
+	}()
+	for _, expect := range []watch.EventType{watch.Added, watch.Modified} {
+		if event := <-w.ResultChan(); event.Type != expect {
+			t.Fatalf("unexpected event: %#v", event)
+		}
+	}
+
+	second := <-watches.watches
+	go second.Delete(podWithVersion("foo", "4"))
+	if event := <-w.ResultChan(); event.Type != watch.Deleted {
+		t.Fatalf("unexpected event: %#v", event)
+	}
+	if versions := watches.Versions(); !reflect.DeepEqual(versions, []string{"1", "3"}) {
+		t.Errorf("unexpected resource versions: %v", versions)
+	}
+}
+
+func TestRetryWatcherWaitsAfterEmptyWatch(t *testing.T) {
+	var lock sync.Mutex
+	opened := 0
+	watchFn := func(resourceVersion string) (watch.Interface, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		opened++
+		w := watch.NewFake()
+		w.Stop()
+		return w, nil
+	}
+	w := NewRetryWatcher("1", nil, watchFn, nil, testapi.MetadataAccessor(), 0)
+	time.Sleep(100 * time.Millisecond)
+	w.Stop()
+
+	lock.Lock()
+	defer lock.Unlock()
+	if opened != 1 {
+		t.Errorf("expected a watch closed without events not to be reopened at once, opened %d", opened)
+	}
+}
+
+func TestRetryWatcherBookmarks(t *testing.T) {
+	watches := newFakeWatches()
+	w := NewRetryWatcher("1", nil, watches.Watch, nil, testapi.MetadataAccessor(), 0)
+	defer w.Stop()
+
+	first := <-watches.watches
+	go func() {
+		first.Add(podWithVersion("foo", "2"))
+		first.Action(watch.Bookmark, podWithVersion("", "7"))
+		first.Stop()
+	}()
+	if event := <-w.ResultChan(); event.Type != watch.Added {
+		t.Fatalf("unexpected event: %#v", event)
+	}
+
+	second := <-watches.watches
+	go second.Modify(podWithVersion("foo", "8"))
+	if event := <-w.ResultChan(); event.Type != watch.Modified {
+		t.Fatalf("expected the bookmark not to be delivered: %#v", event)
+	}
+	if versions := watches.Versions(); !reflect.DeepEqual(versions, []string{"1", "7"}) {
+		t.Errorf("unexpected resource versions: %v", versions)
+	}
+}
+
+func TestRetryWatcherDrain(t *testing.T) {
+	watches := newFakeWatches()
+	w := NewRetryWatcher("1", nil, watches.Watch, nil, testapi.MetadataAccessor(), 10)
+
+	source := <-watches.watches
+	for i := 2; i < 5; i++ {
+		source.Add(podWithVersion("foo", strconv.Itoa(i)))
+	}
+	for len(w.result) != 3 {
+		time.Sleep(time.Millisecond)
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		w.Drain(time.Minute)
+		close(drained)
+	}()
+	count := 0
+	for range w.ResultChan() {
+		count++
+	}
+	<-drained
+	if count != 3 {
+		t.Errorf("expected all buffered events to be delivered, got %d", count)
+	}
+	if !source.Stopped {
+		t.Errorf("expected the source watch to be stopped")
+	}
+	if versions := watches.Versions(); len(versions) != 1 {
+		t.Errorf("unexpected reconnects: %v", versions)
+	}
+}
+
+func TestRetryWatcherDrainTimeout(t *testing.T) {
+	watches := newFakeWatches()
+	w := NewRetryWatcher("1", nil, watches.Watch, nil, testapi.MetadataAccessor(), 10)
+
+	source := <-watches.watches
+	source.Add(podWithVersion("foo", "2"))
+	for len(w.result) != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	w.Drain(10 * time.Millisecond)
+	if event, ok := <-w.ResultChan(); ok {
+		t.Errorf("expected buffered events to be discarded: %#v", event)
+	}
+}
+
+func TestRetryWatcherStop(t *testing.T) {
+	watches := newFakeWatches()
+	w := NewRetryWatcher("1", nil, watches.Watch, nil, testapi.MetadataAccessor(), 10)
+	source := <-watches.watches
+	w.Stop()
+	if _, ok := <-w.ResultChan(); ok {
+		t.Errorf("expected the result channel to be closed")
+	}
+	if !source.Stopped {
+		t.Errorf("expected the source watch to be stopped")
+	}
+	w.Drain(time.Second)
+}
+
+func TestRetryWatcherRelistsWhenExpired(t *testing.T) {
+	watches := newFakeWatches()
+	list := func() (runtime.Object, error) {
+		return &api.PodList{
+			ListMeta: api.ListMeta{ResourceVersion: "10"},
+			Items: []api.Pod{
+				*podWithVersion("foo", "5"),
+				*podWithVersion("bar", "6"),
+				*podWithVersion("unchanged", "4"),
+			},
+		}, nil
+	}
+	w := NewRetryWatcher("1", nil, watches.Watch, list, testapi.MetadataAccessor(), 0)
+	defer w.Stop()
+
+	first := <-watches.watches
+	go func() {
+		first.Add(podWithVersion("baz", "2"))
+		first.Add(podWithVersion("foo", "3"))
+		first.Add(podWithVersion("unchanged", "4"))
+		first.Error(&api.Status{Status: api.StatusFailure, Code: http.StatusGone})
+	}()
+	expected := []struct {
+		Type    watch.EventType
+		Name    string
+		Version string
+	}{
+		{watch.Added, "baz", "2"},
+		{watch.Added, "foo", "3"},
+		{watch.Added, "unchanged", "4"},
+		{watch.Modified, "foo", "5"},
+		{watch.Added, "bar", "6"},
+		{watch.Deleted, "baz", "2"},
+	}
+	for i, expect := range expected {
+		event := <-w.ResultChan()
+		pod, ok := event.Object.(*api.Pod)
+		if !ok || event.Type != expect.Type || pod.Name != expect.Name || pod.ResourceVersion != expect.Version {
+			t.Fatalf("%d: unexpected event: %#v", i, event)
+		}
+	}
+
+	<-watches.watches
+	if versions := watches.Versions(); !reflect.DeepEqual(versions, []string{"1", "10"}) {
+		t.Errorf("unexpected resource versions: %v", versions)
+	}
+}
+
+func TestRetryWatcherExpiredWithoutList(t *testing.T) {
+	gone := &apierrors.StatusError{ErrStatus: api.Status{Status: api.StatusFailure, Code: http.StatusGone}}
+	tests := []func(watches *fakeWatches) WatchFunc{
+		func(watches *fakeWatches) WatchFunc {
+			return func(resourceVersion string) (watch.Interface, error) {
+				return nil, gone
+			}
+		},
+		func(watches *fakeWatches) WatchFunc {
+			return func(resourceVersion string) (watch.Interface, error) {
+				w, _ := watches.Watch(resourceVersion)
+				go w.(*watch.FakeWatcher).Error(&gone.ErrStatus)
+				return w, nil
+			}
+		},
+	}
+	for i, watchFn := range tests {
+		w := NewRetryWatcher("1", nil, watchFn(newFakeWatches()), nil, testapi.MetadataAccessor(), 0)
+		event, ok := <-w.ResultChan()
+		if status, isStatus := event.Object.(*api.Status); !ok || event.Type != watch.Error || !isStatus || status.Code != http.StatusGone {
+			t.Errorf("%d: unexpected event: %#v", i, event)
+		}
+		if _, ok := <-w.ResultChan(); ok {
+			t.Errorf("%d: expected the result channel to be closed", i)
+		}
+		w.Stop()
+	}
+}
+
+func TestRetryWatcherRelistsFromInitialList(t *testing.T) {
+	watches := newFakeWatches()
+	initial := &api.PodList{
+		ListMeta: api.ListMeta{ResourceVersion: "10"},
+		Items:    []api.Pod{*podWithVersion("foo", "5"), *podWithVersion("bar", "6")},
+	}
+	list := func() (runtime.Object, error) {
+		return &api.PodList{
+			ListMeta: api.ListMeta{ResourceVersion: "12"},
+			Items:    []api.Pod{*podWithVersion("foo", "5"), *podWithVersion("baz", "11")},
+		}, nil
+	}
+	w := NewRetryWatcher("10", initial, watches.Watch, list, testapi.MetadataAccessor(), 0)
+	defer w.Stop()
+
+	first := <-watches.watches
+	go first.Error(&api.Status{Status: api.StatusFailure, Code: http.StatusGone})
+	// foo is unchanged since the initial list, and bar was deleted without the watch
+	// delivering an event for it.
+	expected := []struct {
+		Type    watch.EventType
+		Name    string
+		Version string
+	}{
+		{watch.Added, "baz", "11"},
+		{watch.Deleted, "bar", "6"},
+	}
+	for i, expect := range expected {
+		event := <-w.ResultChan()
+		pod, ok := event.Object.(*api.Pod)
+		if !ok || event.Type != expect.Type || pod.Name != expect.Name || pod.ResourceVersion != expect.Version {
+			t.Fatalf("%d: unexpected event: %#v", i, event)
+		}
+	}
+
+	<-watches.watches
+	if versions := watches.Versions(); !reflect.DeepEqual(versions, []string{"10", "12"}) {
+		t.Errorf("unexpected resource versions: %v", versions)
+	}
+}
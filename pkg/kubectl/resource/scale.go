@@ -0,0 +1,171 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/meta"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/conversion"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+// DefaultScaleRetries is how many times a ScaleHelper retries an update that
+// conflicts with another change to the object.
+const DefaultScaleRetries = 5
+
+// Scale is the desired and observed size of a scalable object.
+type Scale struct {
+	// The number of replicas the object asks for.
+	Replicas int
+	// The number of replicas last observed by the object's controller.
+	ObservedReplicas int
+	// The resource version of the object the scale was read from.
+	ResourceVersion string
+}
+
+// ScaleHelper reads and changes the size of scalable objects, such as replication
+// controllers: any kind with an integer Spec.Replicas and Status.Replicas. The size is
+// changed by patching only the replica count, so other changes made to the object
+// concurrently are never overwritten. The server does not yet serve a separate scale
+// subresource, so the scale is read from and written to the object itself.
+type ScaleHelper struct {
+	Helper ResourceHelper
+	// How many times an update that conflicts with another change is retried.
+	Retries int
+}
+
+// NewScaleHelper creates a ScaleHelper for objects of the resource helper operates on.
+func NewScaleHelper(helper ResourceHelper) *ScaleHelper {
+	return &ScaleHelper{
+		Helper:  helper,
+		Retries: DefaultScaleRetries,
+	}
+}
+
+// Get returns the scale of the named object.
+func (s *ScaleHelper) Get(namespace, name string) (*Scale, error) {
+	obj, err := getLatest(s.Helper, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return scaleOf(obj)
+}
+
+// Update sets the number of replicas of the named object. If precondition is not nil
+// it is checked against the current scale first, and its error is returned without
+// changing the object. The update only succeeds if the object was not changed since
+// the scale was read, and is retried with the fresh scale otherwise.
+func (s *ScaleHelper) Update(namespace, name string, replicas int, precondition func(*Scale) error) (*Scale, error) {
+	delay := replaceRetryDelay
+	for attempt := 0; ; attempt++ {
+		scale, err := s.Get(namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		if precondition != nil {
+			if err := precondition(scale); err != nil {
+				return nil, err
+			}
+		}
+		obj, err := s.patch(namespace, name, replicas, scale.ResourceVersion)
+		if err == nil {
+			return scaleOf(obj)
+		}
+		if !errors.IsConflict(err) || attempt >= s.Retries {
+			return nil, err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// WaitForReplicas blocks until the controller of the named object has observed the
+// number of replicas it asks for, or timeout passes.
+func (s *ScaleHelper) WaitForReplicas(namespace, name string, timeout time.Duration) (*Scale, error) {
+	var scale *Scale
+	_, err := NewWaiter(s.Helper).Wait(namespace, name, func(obj runtime.Object) (bool, error) {
+		if obj == nil {
+			return false, fmt.Errorf("%q was deleted while waiting for its replicas", name)
+		}
+		current, err := scaleOf(obj)
+		if err != nil {
+			return false, err
+		}
+		scale = current
+		return current.Replicas == current.ObservedReplicas, nil
+	}, timeout)
+	return scale, err
+}
+
+// patch sets the replica count of the named object if its resource version is still
+// resourceVersion.
+func (s *ScaleHelper) patch(namespace, name string, replicas int, resourceVersion string) (runtime.Object, error) {
+	data, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"resourceVersion": resourceVersion},
+		"spec":     map[string]interface{}{"replicas": replicas},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Helper.Patch(namespace, name, api.MergePatchType, data)
+}
+
+// scaleOf reads the scale of a scalable object.
+func scaleOf(obj runtime.Object) (*Scale, error) {
+	v, err := conversion.EnforcePtr(obj)
+	if err != nil {
+		return nil, err
+	}
+	replicas, ok := intField(v, "Spec", "Replicas")
+	if !ok {
+		return nil, fmt.Errorf("%v does not have a replica count", reflect.TypeOf(obj))
+	}
+	observed, _ := intField(v, "Status", "Replicas")
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	return &Scale{
+		Replicas:         replicas,
+		ObservedReplicas: observed,
+		ResourceVersion:  accessor.ResourceVersion(),
+	}, nil
+}
+
+// intField returns the integer at the given path of nested struct fields of v.
+func intField(v reflect.Value, path ...string) (int, bool) {
+	for _, name := range path {
+		if v.Kind() != reflect.Struct {
+			return 0, false
+		}
+		v = v.FieldByName(name)
+		if !v.IsValid() {
+			return 0, false
+		}
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return int(v.Int()), true
+	}
+	return 0, false
+}
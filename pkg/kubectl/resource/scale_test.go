@@ -0,0 +1,183 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/testapi"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+)
+
+func controllerWithReplicas(replicas, observed int, resourceVersion string) *api.ReplicationController {
+	return &api.ReplicationController{
+		ObjectMeta: api.ObjectMeta{Name: "foo", Namespace: "test", ResourceVersion: resourceVersion},
+		Spec:       api.ReplicationControllerSpec{Replicas: replicas},
+		Status:     api.ReplicationControllerStatus{Replicas: observed},
+	}
+}
+
+func TestScaleHelperUpdate(t *testing.T) {
+	replaceRetryDelay = 0
+	conflict := func() *http.Response {
+		return &http.Response{StatusCode: http.StatusConflict, Body: objBody(&api.Status{Status: api.StatusFailure, Code: http.StatusConflict, Reason: api.StatusReasonConflict})}
+	}
+	ok := func() *http.Response {
+		return &http.Response{StatusCode: http.StatusOK, Body: objBody(controllerWithReplicas(3, 1, "11"))}
+	}
+	errPrecondition := errors.New("precondition failed")
+	tests := map[string]struct {
+		// Patches returns the response to each successive PATCH of the object.
+		Patches      []func() *http.Response
+		Retries      int
+		Precondition func(*Scale) error
+
+		Expect  *Scale
+		Err     bool
+		Patched int
+	}{
+		"updated": {
+			Patches: []func() *http.Response{ok},
+			Retries: DefaultScaleRetries,
+			Expect:  &Scale{Replicas: 3, ObservedReplicas: 1, ResourceVersion: "11"},
+			Patched: 1,
+		},
+		"retried on conflict": {
+			Patches: []func() *http.Response{conflict, conflict, ok},
+			Retries: DefaultScaleRetries,
+			Expect:  &Scale{Replicas: 3, ObservedReplicas: 1, ResourceVersion: "11"},
+			Patched: 3,
+		},
+		"retries exhausted": {
+			Patches: []func() *http.Response{conflict, conflict, ok},
+			Retries: 1,
+			Err:     true,
+			Patched: 2,
+		},
+		"precondition not met": {
+			Retries: DefaultScaleRetries,
+			Precondition: func(scale *Scale) error {
+				if scale.Replicas != 1 || scale.ResourceVersion != "10" {
+					t.Errorf("unexpected scale passed to precondition: %#v", scale)
+				}
+				return errPrecondition
+			},
+			Err: true,
+		},
+	}
+	for k, test := range tests {
+		patched := 0
+		client := &client.FakeRESTClient{
+			Codec: testapi.Codec(),
+			Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+				if req.URL.Path != "/namespaces/test/replicationcontrollers/foo" {
+					t.Fatalf("%s: unexpected request: %#v", k, req.URL)
+				}
+				switch req.Method {
+				case "GET":
+					return &http.Response{StatusCode: http.StatusOK, Body: objBody(controllerWithReplicas(1, 1, "10"))}, nil
+				case "PATCH":
+					data, _ := ioutil.ReadAll(req.Body)
+					if e, a := `{"metadata":{"resourceVersion":"10"},"spec":{"replicas":3}}`, string(data); e != a {
+						t.Errorf("%s: expected patch %s, got %s", k, e, a)
+					}
+					resp := test.Patches[patched]
+					patched++
+					return resp(), nil
+				}
+				t.Fatalf("%s: unexpected request: %s %#v", k, req.Method, req.URL)
+				return nil, nil
+			}),
+		}
+		mapping, err := latest.RESTMapper.RESTMapping("ReplicationController", testapi.Version())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		scaler := NewScaleHelper(NewHelper(client, mapping))
+		scaler.Retries = test.Retries
+
+		scale, err := scaler.Update("test", "foo", 3, test.Precondition)
+		if test.Err != (err != nil) {
+			t.Errorf("%s: unexpected error: %v", k, err)
+		}
+		if test.Precondition != nil && err != errPrecondition {
+			t.Errorf("%s: expected the precondition error, got %v", k, err)
+		}
+		if !reflect.DeepEqual(test.Expect, scale) {
+			t.Errorf("%s: unexpected scale: %#v", k, scale)
+		}
+		if patched != test.Patched {
+			t.Errorf("%s: expected %d patches, got %d", k, test.Patched, patched)
+		}
+	}
+}
+
+func TestScaleHelperUnscalable(t *testing.T) {
+	client := &client.FakeRESTClient{
+		Codec: testapi.Codec(),
+		Resp:  &http.Response{StatusCode: http.StatusOK, Body: objBody(podInPhase(api.PodRunning))},
+	}
+	mapping, err := latest.RESTMapper.RESTMapping("Pod", testapi.Version())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := NewScaleHelper(NewHelper(client, mapping)).Get("test", "foo"); err == nil {
+		t.Errorf("expected an error for an object without a replica count")
+	}
+}
+
+func TestScaleHelperWaitForReplicas(t *testing.T) {
+	var lock sync.Mutex
+	client := &client.FakeRESTClient{
+		Codec: testapi.Codec(),
+		Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			switch req.URL.Path {
+			case "/namespaces/test/replicationcontrollers/foo":
+				return &http.Response{StatusCode: http.StatusOK, Body: objBody(controllerWithReplicas(3, 1, "10"))}, nil
+			case "/watch/namespaces/test/replicationcontrollers/foo":
+				return &http.Response{StatusCode: http.StatusOK, Body: stringBody(watchBody(
+					watch.Event{Type: watch.Modified, Object: controllerWithReplicas(3, 2, "11")},
+					watch.Event{Type: watch.Modified, Object: controllerWithReplicas(3, 3, "12")},
+				))}, nil
+			}
+			t.Fatalf("unexpected request: %#v", req.URL)
+			return nil, nil
+		}),
+	}
+	mapping, err := latest.RESTMapper.RESTMapping("ReplicationController", testapi.Version())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	scale, err := NewScaleHelper(NewHelper(client, mapping)).WaitForReplicas("test", "foo", 5*time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e := (&Scale{Replicas: 3, ObservedReplicas: 3, ResourceVersion: "12"}); !reflect.DeepEqual(e, scale) {
+		t.Errorf("unexpected scale: %#v", scale)
+	}
+}
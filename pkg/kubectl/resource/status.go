@@ -0,0 +1,201 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/errors"
+)
+
+// ObjectStatus describes whether an object is ready: whether the server has acted on
+// the latest changes to it and it is available for use.
+type ObjectStatus struct {
+	Ready bool
+	// Why the object is not ready, if it is not.
+	Message string
+}
+
+// StatusFunc evaluates the status of an object of the kind it is registered for.
+type StatusFunc func(obj runtime.Object) (ObjectStatus, error)
+
+// StatusEvaluator determines whether objects of any kind are ready. Pods are ready
+// once they are running and report that they are ready, or have succeeded;
+// replication controllers once their controller has observed their latest generation
+// and created the desired number of replicas; load balanced services once they have
+// been given an ingress point; and namespaces, nodes, persistent volumes, and
+// persistent volume claims once they are active, ready, or bound. Objects of other
+// kinds are ready as soon as they exist, unless a StatusFunc is registered for their
+// kind.
+type StatusEvaluator struct {
+	Typer runtime.ObjectTyper
+
+	funcs map[string]StatusFunc
+}
+
+// NewStatusEvaluator creates a StatusEvaluator that determines the kinds of objects
+// with typer.
+func NewStatusEvaluator(typer runtime.ObjectTyper) *StatusEvaluator {
+	return &StatusEvaluator{
+		Typer: typer,
+		funcs: map[string]StatusFunc{
+			"Pod":                   podStatus,
+			"ReplicationController": controllerStatus,
+			"Service":               serviceStatus,
+			"Namespace":             namespaceStatus,
+			"Node":                  nodeStatus,
+			"PersistentVolume":      persistentVolumeStatus,
+			"PersistentVolumeClaim": persistentVolumeClaimStatus,
+		},
+	}
+}
+
+// Register evaluates the status of objects of kind with fn, replacing the evaluation
+// of that kind by any previous StatusFunc.
+func (e *StatusEvaluator) Register(kind string, fn StatusFunc) {
+	e.funcs[kind] = fn
+}
+
+// Status returns the status of obj.
+func (e *StatusEvaluator) Status(obj runtime.Object) (ObjectStatus, error) {
+	_, kind, err := e.Typer.ObjectVersionAndKind(obj)
+	if err != nil {
+		return ObjectStatus{}, err
+	}
+	fn, found := e.funcs[kind]
+	if !found {
+		return ObjectStatus{Ready: true}, nil
+	}
+	return fn(obj)
+}
+
+// Ready is a WaitCondition that is satisfied once the object exists and is ready.
+func (e *StatusEvaluator) Ready(obj runtime.Object) (bool, error) {
+	if obj == nil {
+		return false, nil
+	}
+	status, err := e.Status(obj)
+	return status.Ready, err
+}
+
+// WaitForReady waits until every object of infos is ready or timeout passes, watching
+// the objects for changes with a Waiter. An error is returned for each object that
+// did not become ready in time, with the reason it was not ready.
+func (e *StatusEvaluator) WaitForReady(infos []*Info, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	errs := []error{}
+	for _, info := range infos {
+		remaining := deadline.Sub(time.Now())
+		if remaining < 0 {
+			remaining = 0
+		}
+		obj, err := NewWaiter(NewHelper(info.Client, info.Mapping)).Wait(info.Namespace, info.Name, e.Ready, remaining)
+		if err == nil {
+			info.Refresh(obj, true)
+			continue
+		}
+		reason := "it does not exist"
+		if obj != nil {
+			if status, statusErr := e.Status(obj); statusErr == nil {
+				reason = status.Message
+			}
+		}
+		errs = append(errs, fmt.Errorf("%s %q is not ready: %s (%v)", info.Mapping.Resource, info.Name, reason, err))
+	}
+	return errors.NewAggregate(errs)
+}
+
+func podStatus(obj runtime.Object) (ObjectStatus, error) {
+	pod := obj.(*api.Pod)
+	switch pod.Status.Phase {
+	case api.PodSucceeded:
+		return ObjectStatus{Ready: true}, nil
+	case api.PodRunning:
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == api.PodReady && condition.Status == api.ConditionTrue {
+				return ObjectStatus{Ready: true}, nil
+			}
+		}
+		return ObjectStatus{Message: "the pod is running but not ready"}, nil
+	}
+	return ObjectStatus{Message: fmt.Sprintf("the pod is %s", phaseOrUnknown(string(pod.Status.Phase)))}, nil
+}
+
+func controllerStatus(obj runtime.Object) (ObjectStatus, error) {
+	controller := obj.(*api.ReplicationController)
+	if controller.Generation > controller.Status.ObservedGeneration {
+		return ObjectStatus{Message: fmt.Sprintf("generation %d has not been observed yet", controller.Generation)}, nil
+	}
+	if controller.Status.Replicas != controller.Spec.Replicas {
+		return ObjectStatus{Message: fmt.Sprintf("%d of %d replicas exist", controller.Status.Replicas, controller.Spec.Replicas)}, nil
+	}
+	return ObjectStatus{Ready: true}, nil
+}
+
+func serviceStatus(obj runtime.Object) (ObjectStatus, error) {
+	service := obj.(*api.Service)
+	if service.Spec.Type == api.ServiceTypeLoadBalancer && len(service.Status.LoadBalancer.Ingress) == 0 {
+		return ObjectStatus{Message: "the load balancer has not been created yet"}, nil
+	}
+	return ObjectStatus{Ready: true}, nil
+}
+
+func namespaceStatus(obj runtime.Object) (ObjectStatus, error) {
+	namespace := obj.(*api.Namespace)
+	if namespace.Status.Phase != api.NamespaceActive {
+		return ObjectStatus{Message: fmt.Sprintf("the namespace is %s", phaseOrUnknown(string(namespace.Status.Phase)))}, nil
+	}
+	return ObjectStatus{Ready: true}, nil
+}
+
+func nodeStatus(obj runtime.Object) (ObjectStatus, error) {
+	node := obj.(*api.Node)
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == api.NodeReady && condition.Status == api.ConditionTrue {
+			return ObjectStatus{Ready: true}, nil
+		}
+	}
+	return ObjectStatus{Message: "the node is not ready"}, nil
+}
+
+func persistentVolumeStatus(obj runtime.Object) (ObjectStatus, error) {
+	volume := obj.(*api.PersistentVolume)
+	switch volume.Status.Phase {
+	case api.VolumeAvailable, api.VolumeBound:
+		return ObjectStatus{Ready: true}, nil
+	}
+	return ObjectStatus{Message: fmt.Sprintf("the volume is %s", phaseOrUnknown(string(volume.Status.Phase)))}, nil
+}
+
+func persistentVolumeClaimStatus(obj runtime.Object) (ObjectStatus, error) {
+	claim := obj.(*api.PersistentVolumeClaim)
+	if claim.Status.Phase != api.ClaimBound {
+		return ObjectStatus{Message: fmt.Sprintf("the claim is %s", phaseOrUnknown(string(claim.Status.Phase)))}, nil
+	}
+	return ObjectStatus{Ready: true}, nil
+}
+
+// phaseOrUnknown returns phase, or "in an unknown phase" if it is empty.
+func phaseOrUnknown(phase string) string {
+	if len(phase) == 0 {
+		return "in an unknown phase"
+	}
+	return phase
+}
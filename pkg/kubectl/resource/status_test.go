@@ -0,0 +1,132 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/testapi"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+func readyPod(name string) *api.Pod {
+	pod := podInPhase(api.PodRunning)
+	pod.Name = name
+	pod.Status.Conditions = []api.PodCondition{{Type: api.PodReady, Status: api.ConditionTrue}}
+	return pod
+}
+
+func TestStatusEvaluator(t *testing.T) {
+	controller := func(generation, observed int64, desired, replicas int) *api.ReplicationController {
+		return &api.ReplicationController{
+			ObjectMeta: api.ObjectMeta{Name: "foo", Generation: generation},
+			Spec:       api.ReplicationControllerSpec{Replicas: desired},
+			Status:     api.ReplicationControllerStatus{Replicas: replicas, ObservedGeneration: observed},
+		}
+	}
+	loadBalancer := &api.Service{Spec: api.ServiceSpec{Type: api.ServiceTypeLoadBalancer}}
+	balanced := &api.Service{Spec: api.ServiceSpec{Type: api.ServiceTypeLoadBalancer}}
+	balanced.Status.LoadBalancer.Ingress = []api.LoadBalancerIngress{{IP: "1.2.3.4"}}
+
+	tests := map[string]struct {
+		Obj     runtime.Object
+		Ready   bool
+		Message string
+	}{
+		"pending pod":            {Obj: podInPhase(api.PodPending), Message: "the pod is Pending"},
+		"running pod":            {Obj: podInPhase(api.PodRunning), Message: "the pod is running but not ready"},
+		"ready pod":              {Obj: readyPod("foo"), Ready: true},
+		"succeeded pod":          {Obj: podInPhase(api.PodSucceeded), Ready: true},
+		"unobserved generation":  {Obj: controller(2, 1, 3, 3), Message: "generation 2 has not been observed yet"},
+		"missing replicas":       {Obj: controller(2, 2, 3, 1), Message: "1 of 3 replicas exist"},
+		"controller ready":       {Obj: controller(2, 2, 3, 3), Ready: true},
+		"service":                {Obj: &api.Service{}, Ready: true},
+		"pending load balancer":  {Obj: loadBalancer, Message: "the load balancer has not been created yet"},
+		"load balancer":          {Obj: balanced, Ready: true},
+		"terminating namespace":  {Obj: &api.Namespace{Status: api.NamespaceStatus{Phase: api.NamespaceTerminating}}, Message: "the namespace is Terminating"},
+		"node not ready":         {Obj: &api.Node{}, Message: "the node is not ready"},
+		"pending claim":          {Obj: &api.PersistentVolumeClaim{}, Message: "the claim is in an unknown phase"},
+		"bound volume":           {Obj: &api.PersistentVolume{Status: api.PersistentVolumeStatus{Phase: api.VolumeBound}}, Ready: true},
+		"kind without a status":  {Obj: &api.Secret{}, Ready: true},
+		"registered kind":        {Obj: &api.Endpoints{}, Message: "no endpoints"},
+		"registered kind, ready": {Obj: &api.Endpoints{Subsets: []api.EndpointSubset{{}}}, Ready: true},
+	}
+	evaluator := NewStatusEvaluator(api.Scheme)
+	evaluator.Register("Endpoints", func(obj runtime.Object) (ObjectStatus, error) {
+		if len(obj.(*api.Endpoints).Subsets) == 0 {
+			return ObjectStatus{Message: "no endpoints"}, nil
+		}
+		return ObjectStatus{Ready: true}, nil
+	})
+	for k, test := range tests {
+		status, err := evaluator.Status(test.Obj)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", k, err)
+			continue
+		}
+		if status.Ready != test.Ready || status.Message != test.Message {
+			t.Errorf("%s: unexpected status: %#v", k, status)
+		}
+		if ready, _ := evaluator.Ready(test.Obj); ready != test.Ready {
+			t.Errorf("%s: expected the wait condition to agree with the status", k)
+		}
+	}
+	if ready, err := evaluator.Ready(nil); ready || err != nil {
+		t.Errorf("a missing object should not be ready: %t %v", ready, err)
+	}
+}
+
+func TestWaitForReady(t *testing.T) {
+	pending := podInPhase(api.PodPending)
+	pending.Name = "bar"
+	client := &client.FakeRESTClient{
+		Codec: testapi.Codec(),
+		Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			switch req.URL.Path {
+			case "/namespaces/test/pods/foo":
+				return &http.Response{StatusCode: http.StatusOK, Body: objBody(readyPod("foo"))}, nil
+			case "/namespaces/test/pods/bar":
+				return &http.Response{StatusCode: http.StatusOK, Body: objBody(pending)}, nil
+			case "/watch/namespaces/test/pods/foo", "/watch/namespaces/test/pods/bar":
+				return &http.Response{StatusCode: http.StatusOK, Body: stringBody("")}, nil
+			}
+			t.Fatalf("unexpected request: %#v", req)
+			return nil, nil
+		}),
+	}
+	mapping, err := latest.RESTMapper.RESTMapping("Pod")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	infos := []*Info{
+		{Client: client, Mapping: mapping, Namespace: "test", Name: "foo"},
+		{Client: client, Mapping: mapping, Namespace: "test", Name: "bar"},
+	}
+	err = NewStatusEvaluator(api.Scheme).WaitForReady(infos, 50*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), `pods "bar" is not ready: the pod is Pending`) || strings.Contains(err.Error(), `"foo"`) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if infos[0].Object == nil {
+		t.Errorf("expected the ready object to be recorded")
+	}
+}
@@ -0,0 +1,370 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/meta"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+)
+
+// TableColumnDefinition describes a single column in the tabular representation
+// of a resource.
+type TableColumnDefinition struct {
+	// Name is a human readable name for the column.
+	Name string `json:"name"`
+	// Type is the type of the cells in this column, for example "string".
+	Type string `json:"type"`
+	// Description is a human readable description of the column.
+	Description string `json:"description"`
+}
+
+// TableRow is a single object rendered as cells matching the column definitions of
+// the table it belongs to.
+type TableRow struct {
+	Cells []interface{}
+	// Object is the object the row describes, if it could be decoded.
+	Object runtime.Object
+}
+
+// Table is the tabular representation of one or more objects.
+type Table struct {
+	ColumnDefinitions []TableColumnDefinition
+	Rows              []TableRow
+}
+
+// IsAnAPIObject allows tables to be delivered as watch events.
+func (*Table) IsAnAPIObject() {}
+
+// tableAcceptHeader asks the server for the tabular representation of resources,
+// falling back to their regular JSON representation.
+const tableAcceptHeader = "application/json;as=Table;v=v1beta1;g=meta.k8s.io, application/json"
+
+// serverTable is the tabular representation of resources sent by the server.
+type serverTable struct {
+	Kind              string                  `json:"kind"`
+	ColumnDefinitions []TableColumnDefinition `json:"columnDefinitions"`
+	Rows              []struct {
+		Cells  []interface{}   `json:"cells"`
+		Object json.RawMessage `json:"object"`
+	} `json:"rows"`
+}
+
+// TableRowEvent is a watch event rendered as a single table row. Cells match the
+// column definitions returned alongside the event channel. Error events carry
+// the status object returned by the server and no cells, as do bookmarks.
+type TableRowEvent struct {
+	Type   watch.EventType
+	Cells  []interface{}
+	Object runtime.Object
+}
+
+// tableColumns are the columns rendered for any resource when the server does not
+// provide a tabular representation.
+var tableColumns = []TableColumnDefinition{
+	{Name: "Name", Type: "string", Description: "The name of the object."},
+	{Name: "Labels", Type: "string", Description: "The labels attached to the object."},
+}
+
+// GetTable retrieves the named object as a table. The server is asked for its
+// tabular representation, which describes the columns appropriate for the kind of
+// the object. A server that does not serve one returns the object, which is then
+// rendered on the client with a generic set of columns.
+func (m *Helper) GetTable(namespace, name string) (*Table, error) {
+	data, err := m.RESTClient.Get().
+		NamespaceIfScoped(namespace, m.NamespaceScoped).
+		Resource(m.Resource).
+		Name(name).
+		SetHeader("Accept", tableAcceptHeader).
+		Do().
+		Raw()
+	if err != nil {
+		return nil, err
+	}
+	table, _, err := m.decodeTable(data)
+	return table, err
+}
+
+// ListTable retrieves the resources matching selector as a table, in the same way as
+// GetTable.
+func (m *Helper) ListTable(namespace string, selector labels.Selector) (*Table, error) {
+	table, _, err := m.listTable(namespace, selector)
+	return table, err
+}
+
+// listTable is ListTable, also reporting whether the table was served.
+func (m *Helper) listTable(namespace string, selector labels.Selector) (*Table, bool, error) {
+	data, err := m.RESTClient.Get().
+		NamespaceIfScoped(namespace, m.NamespaceScoped).
+		Resource(m.Resource).
+		LabelsSelectorParam(selector).
+		SetHeader("Accept", tableAcceptHeader).
+		Do().
+		Raw()
+	if err != nil {
+		return nil, false, err
+	}
+	return m.decodeTable(data)
+}
+
+// decodeTable decodes a table sent by the server, or renders one from the object or
+// list of objects in data. It reports whether the table was sent by the server.
+func (m *Helper) decodeTable(data []byte) (*Table, bool, error) {
+	if table, ok := m.decodeServedTable(data); ok {
+		return table, true, nil
+	}
+	obj, err := m.Codec.Decode(data)
+	if err != nil {
+		return nil, false, err
+	}
+	table, err := NewTable(obj)
+	return table, false, err
+}
+
+// decodeServedTable decodes data if it is a table sent by the server.
+func (m *Helper) decodeServedTable(data []byte) (*Table, bool) {
+	served := serverTable{}
+	if err := json.Unmarshal(data, &served); err != nil || served.Kind != "Table" {
+		return nil, false
+	}
+	table := &Table{ColumnDefinitions: served.ColumnDefinitions}
+	for _, row := range served.Rows {
+		tableRow := TableRow{Cells: row.Cells}
+		if len(row.Object) != 0 {
+			// The object of a row may be of a kind the client does not know.
+			if obj, err := m.Codec.Decode(row.Object); err == nil {
+				tableRow.Object = obj
+			}
+		}
+		table.Rows = append(table.Rows, tableRow)
+	}
+	return table, true
+}
+
+// NewTable renders obj, or each item of obj if it is a list, as a table with a
+// generic set of columns, without contacting the server.
+func NewTable(obj runtime.Object) (*Table, error) {
+	items := []runtime.Object{obj}
+	if runtime.IsListType(obj) {
+		var err error
+		if items, err = runtime.ExtractList(obj); err != nil {
+			return nil, err
+		}
+	}
+	table := &Table{ColumnDefinitions: tableColumns}
+	for _, item := range items {
+		table.Rows = append(table.Rows, TableRow{Cells: tableCells(item), Object: item})
+	}
+	return table, nil
+}
+
+// Print writes the table to w as aligned columns, preceded by a line of column
+// names unless noHeaders is true. Empty cells are printed as "<none>".
+func (t *Table) Print(w io.Writer, noHeaders bool) error {
+	tw := tabwriter.NewWriter(w, 10, 4, 3, ' ', 0)
+	if !noHeaders {
+		names := []string{}
+		for _, column := range t.ColumnDefinitions {
+			names = append(names, strings.ToUpper(column.Name))
+		}
+		if _, err := fmt.Fprintln(tw, strings.Join(names, "\t")); err != nil {
+			return err
+		}
+	}
+	for _, row := range t.Rows {
+		cells := []string{}
+		for _, cell := range row.Cells {
+			value := fmt.Sprintf("%v", cell)
+			if cell == nil || len(value) == 0 {
+				value = "<none>"
+			}
+			cells = append(cells, value)
+		}
+		if _, err := fmt.Fprintln(tw, strings.Join(cells, "\t")); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+// WatchTable watches the resources matching selector and delivers each event as a
+// table row. The server is asked for tabular events, and the column definitions are
+// taken from an initial ListTable, as a watch only describes its columns once an
+// event arrives. If the server sends the objects themselves, their rows are
+// retrieved with GetTable, or rendered on the client when the server does not serve
+// tables at all. The returned channel is closed when the server ends the watch,
+// which WatchTimeout bounds; callers must drain it until then.
+func (m *Helper) WatchTable(namespace string, selector labels.Selector) ([]TableColumnDefinition, <-chan TableRowEvent, error) {
+	w, err := m.watch(m.RESTClient.Get().
+		Prefix("watch").
+		NamespaceIfScoped(namespace, m.NamespaceScoped).
+		Resource(m.Resource).
+		LabelsSelectorParam(selector).
+		SetHeader("Accept", tableAcceptHeader), m.openTableWatch)
+	if err != nil {
+		return nil, nil, err
+	}
+	list, served, err := m.listTable(namespace, selector)
+	if err != nil {
+		w.Stop()
+		return nil, nil, err
+	}
+	// the rows of deleted objects can no longer be retrieved, so keep those seen
+	known := map[string][]interface{}{}
+	for _, row := range list.Rows {
+		if key, err := tableRowKey(row.Object); err == nil {
+			known[key] = row.Cells
+		}
+	}
+	events := make(chan TableRowEvent)
+	go func() {
+		defer close(events)
+		for event := range w.ResultChan() {
+			for _, row := range m.tableRowEvents(event, served, known) {
+				events <- row
+			}
+		}
+	}()
+	return list.ColumnDefinitions, events, nil
+}
+
+// tableRowEvents renders the rows changed by event. If served is true, rows of
+// objects are retrieved from the server, and known holds those retrieved so far.
+func (m *Helper) tableRowEvents(event watch.Event, served bool, known map[string][]interface{}) []TableRowEvent {
+	if table, ok := event.Object.(*Table); ok {
+		rows := []TableRowEvent{}
+		for _, row := range table.Rows {
+			rows = append(rows, TableRowEvent{Type: event.Type, Cells: row.Cells, Object: row.Object})
+		}
+		return rows
+	}
+	row := TableRowEvent{Type: event.Type, Object: event.Object}
+	if event.Type == watch.Error || event.Type == watch.Bookmark {
+		return []TableRowEvent{row}
+	}
+	if !served {
+		row.Cells = tableCells(event.Object)
+		return []TableRowEvent{row}
+	}
+	key, err := tableRowKey(event.Object)
+	if err != nil {
+		return []TableRowEvent{tableErrorEvent(err)}
+	}
+	if event.Type == watch.Deleted {
+		row.Cells = known[key]
+		delete(known, key)
+		return []TableRowEvent{row}
+	}
+	accessor, _ := meta.Accessor(event.Object)
+	table, err := m.GetTable(accessor.Namespace(), accessor.Name())
+	if err != nil {
+		return []TableRowEvent{tableErrorEvent(err)}
+	}
+	if len(table.Rows) != 0 {
+		row.Cells = table.Rows[0].Cells
+		known[key] = row.Cells
+	}
+	return []TableRowEvent{row}
+}
+
+// tableRowKey identifies the row of obj by its namespace and name.
+func tableRowKey(obj runtime.Object) (string, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return "", err
+	}
+	return accessor.Namespace() + "/" + accessor.Name(), nil
+}
+
+// tableErrorEvent reports err as an error event.
+func tableErrorEvent(err error) TableRowEvent {
+	statusErr, ok := err.(*errors.StatusError)
+	if !ok {
+		statusErr = errors.NewInternalError(err).(*errors.StatusError)
+	}
+	status := statusErr.Status()
+	return TableRowEvent{Type: watch.Error, Object: &status}
+}
+
+// openTableWatch executes req, decoding the events sent as tables.
+func (m *Helper) openTableWatch(req *client.Request) (watch.Interface, error) {
+	body, err := req.Stream()
+	if err != nil {
+		return nil, err
+	}
+	return watch.NewStreamWatcher(&tableWatchDecoder{body: body, decoder: json.NewDecoder(body), helper: m}), nil
+}
+
+// tableWatchDecoder decodes the events of a watch asking for tables, which carry a
+// table of the changed object, or the object itself if the server does not serve
+// tables for watches.
+type tableWatchDecoder struct {
+	body    io.ReadCloser
+	decoder *json.Decoder
+	helper  *Helper
+}
+
+// Decode implements watch.Decoder.
+func (d *tableWatchDecoder) Decode() (watch.EventType, runtime.Object, error) {
+	var got struct {
+		Type   watch.EventType `json:"type"`
+		Object json.RawMessage `json:"object"`
+	}
+	if err := d.decoder.Decode(&got); err != nil {
+		return "", nil, err
+	}
+	switch got.Type {
+	case watch.Added, watch.Modified, watch.Deleted, watch.Error, watch.Bookmark:
+	default:
+		return "", nil, fmt.Errorf("got invalid watch event type: %v", got.Type)
+	}
+	if table, ok := d.helper.decodeServedTable(got.Object); ok {
+		return got.Type, table, nil
+	}
+	obj, err := d.helper.Codec.Decode(got.Object)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to decode watch event: %v", err)
+	}
+	return got.Type, obj, nil
+}
+
+// Close implements watch.Decoder.
+func (d *tableWatchDecoder) Close() {
+	d.body.Close()
+}
+
+// tableCells renders the cells of obj for the columns in tableColumns.
+func tableCells(obj runtime.Object) []interface{} {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return []interface{}{"<unknown>", "<unknown>"}
+	}
+	objLabels := labels.Set(accessor.Labels()).String()
+	if len(objLabels) == 0 {
+		objLabels = "<none>"
+	}
+	return []interface{}{accessor.Name(), objLabels}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+)
+
+// TimeoutWatcher wraps a watch that the server was asked to close after a timeout,
+// and records whether the watch ended because that timeout elapsed. Callers that
+// reconnect when the result channel closes can use Expired to tell a clean
+// server side timeout apart from a watch that failed.
+type TimeoutWatcher struct {
+	source   watch.Interface
+	deadline time.Time
+	result   chan watch.Event
+	stop     chan struct{}
+
+	lock    sync.Mutex
+	stopped bool
+	expired bool
+}
+
+// NewTimeoutWatcher wraps source, which the server is expected to close at deadline.
+func NewTimeoutWatcher(source watch.Interface, deadline time.Time) *TimeoutWatcher {
+	w := &TimeoutWatcher{
+		source:   source,
+		deadline: deadline,
+		result:   make(chan watch.Event),
+		stop:     make(chan struct{}),
+	}
+	go w.receive()
+	return w
+}
+
+// ResultChan implements watch.Interface.
+func (w *TimeoutWatcher) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+// Stop implements watch.Interface.
+func (w *TimeoutWatcher) Stop() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if !w.stopped {
+		w.stopped = true
+		close(w.stop)
+		w.source.Stop()
+	}
+}
+
+// Expired returns true if the server closed the watch once its timeout elapsed.
+// It returns false while the watch is open, and if the watch was stopped by the
+// caller or ended early.
+func (w *TimeoutWatcher) Expired() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.expired
+}
+
+// receive forwards events from the source until it closes or Stop is called.
+func (w *TimeoutWatcher) receive() {
+	defer close(w.result)
+	for event := range w.source.ResultChan() {
+		select {
+		case w.result <- event:
+		case <-w.stop:
+			return
+		}
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.expired = !w.stopped && !time.Now().Before(w.deadline)
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+)
+
+func TestTimeoutWatcherExpired(t *testing.T) {
+	fake := watch.NewFake()
+	w := NewTimeoutWatcher(fake, time.Now().Add(-time.Second))
+	go func() {
+		fake.Add(&api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo"}})
+		fake.Stop()
+	}()
+	event, ok := <-w.ResultChan()
+	if !ok || event.Type != watch.Added {
+		t.Fatalf("unexpected event: %#v", event)
+	}
+	if _, ok := <-w.ResultChan(); ok {
+		t.Fatalf("expected the channel to be closed")
+	}
+	if !w.Expired() {
+		t.Errorf("expected the watch to be expired")
+	}
+}
+
+func TestTimeoutWatcherClosedEarly(t *testing.T) {
+	fake := watch.NewFake()
+	w := NewTimeoutWatcher(fake, time.Now().Add(time.Hour))
+	fake.Stop()
+	if _, ok := <-w.ResultChan(); ok {
+		t.Fatalf("expected the channel to be closed")
+	}
+	if w.Expired() {
+		t.Errorf("a watch closed before its deadline should not be expired")
+	}
+}
+
+func TestTimeoutWatcherStopped(t *testing.T) {
+	fake := watch.NewFake()
+	w := NewTimeoutWatcher(fake, time.Now().Add(-time.Second))
+	w.Stop()
+	if _, ok := <-w.ResultChan(); ok {
+		t.Fatalf("expected the channel to be closed")
+	}
+	if w.Expired() {
+		t.Errorf("a watch stopped by the caller should not be expired")
+	}
+}
@@ -18,6 +18,8 @@ package resource
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -25,6 +27,9 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/golang/glog"
 
@@ -68,9 +73,15 @@ type Info struct {
 	Namespace string
 	Name      string
 
+	// Optional, Cluster is the name of the target cluster Client belongs to, when the
+	// object is sent to several clusters. See ClusterVisitor.
+	Cluster string
 	// Optional, Source is the filename or URL to template file (.json or .yaml),
 	// or stdin to use to handle the resource
 	Source string
+	// Optional, Document is the position of the object among the YAML documents or
+	// JSON objects read from Source, starting at 1.
+	Document int
 	// Optional, this is the provided object in a versioned type before defaulting
 	// and conversions into its corresponding internal type. This is useful for
 	// reflecting on user intent which may be lost after defaulting and conversions.
@@ -99,6 +110,15 @@ func (i *Info) Visit(fn VisitorFunc) error {
 	return fn(i)
 }
 
+// Location describes where the object was read from for use in messages: Source,
+// followed by the position of the object within it if known.
+func (i *Info) Location() string {
+	if i.Document > 0 {
+		return fmt.Sprintf("%s (document %d)", i.Source, i.Document)
+	}
+	return i.Source
+}
+
 // Get retrieves the object from the Namespace and Name fields
 func (i *Info) Get() error {
 	obj, err := NewHelper(i.Client, i.Mapping).Get(i.Namespace, i.Name)
@@ -192,6 +212,68 @@ func (l EagerVisitorList) Visit(fn VisitorFunc) error {
 	return errors.NewAggregate(errs)
 }
 
+// ParallelVisitor invokes the visitor function on the items of the visitor it wraps
+// concurrently, with at most Limit invocations in flight at once. Items are still
+// produced in order, but the visitor function may be called in any order and from
+// multiple goroutines, so visitor functions that mutate shared state must guard it
+// themselves. The first error returned by the visitor function stops new items from
+// being dispatched; Visit waits for the invocations already running to complete and
+// returns that error.
+type ParallelVisitor struct {
+	Visitor
+	Limit int
+}
+
+// NewParallelVisitor creates a visitor that visits the items of v with at most limit
+// concurrent invocations of the visitor function. A limit of one or less visits
+// sequentially.
+func NewParallelVisitor(v Visitor, limit int) Visitor {
+	if limit <= 1 {
+		return v
+	}
+	return ParallelVisitor{v, limit}
+}
+
+// Visit implements Visitor
+func (v ParallelVisitor) Visit(fn VisitorFunc) error {
+	var (
+		wg       sync.WaitGroup
+		lock     sync.Mutex
+		firstErr error
+	)
+	failed := func() error {
+		lock.Lock()
+		defer lock.Unlock()
+		return firstErr
+	}
+	tokens := make(chan struct{}, v.Limit)
+	err := v.Visitor.Visit(func(info *Info) error {
+		tokens <- struct{}{}
+		if err := failed(); err != nil {
+			<-tokens
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-tokens }()
+			if err := fn(info); err != nil {
+				lock.Lock()
+				defer lock.Unlock()
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+		}()
+		return nil
+	})
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return err
+}
+
 func ValidateSchema(data []byte, schema validation.Schema) error {
 	if schema == nil {
 		return nil
@@ -206,35 +288,105 @@ func ValidateSchema(data []byte, schema validation.Schema) error {
 	return nil
 }
 
-// URLVisitor downloads the contents of a URL, and if successful, returns
-// an info object representing the downloaded object.
+const (
+	// DefaultURLMaxRedirects is the number of redirects a URLVisitor follows if
+	// URLOptions.MaxRedirects is not set.
+	DefaultURLMaxRedirects = 10
+	// DefaultURLMaxBytes is the size of the largest manifest a URLVisitor downloads
+	// if URLOptions.MaxBytes is not set.
+	DefaultURLMaxBytes = 10 << 20
+)
+
+// URLOptions control how a URLVisitor downloads manifests.
+type URLOptions struct {
+	// Header is sent with every request, for instance to authenticate to a private
+	// repository with "Authorization: Bearer <token>". An Authorization header is
+	// only sent to https URLs.
+	Header http.Header
+	// MaxRedirects is the number of redirects followed before giving up. If zero,
+	// DefaultURLMaxRedirects is used, and if negative no redirect is followed.
+	MaxRedirects int
+	// MaxBytes is the size of the largest manifest that will be downloaded. If zero,
+	// DefaultURLMaxBytes is used.
+	MaxBytes int64
+	// SHA256 is the hex encoded checksum the downloaded manifest must match before
+	// it is decoded. No checksum is verified if empty.
+	SHA256 string
+	// Transport is used to make requests instead of http.DefaultTransport if set.
+	Transport http.RoundTripper
+}
+
+// URLVisitor downloads the contents of a URL, and if successful, visits the objects
+// it contains as a StreamVisitor would.
 type URLVisitor struct {
 	*Mapper
-	URL    *url.URL
-	Schema validation.Schema
+	URL     *url.URL
+	Schema  validation.Schema
+	Options URLOptions
 }
 
 func (v *URLVisitor) Visit(fn VisitorFunc) error {
-	res, err := http.Get(v.URL.String())
+	data, err := v.download()
 	if err != nil {
-		return fmt.Errorf("unable to access URL %q: %v\n", v.URL, err)
+		return err
 	}
-	defer res.Body.Close()
-	if res.StatusCode != 200 {
-		return fmt.Errorf("unable to read URL %q, server reported %d %s", v.URL, res.StatusCode, res.Status)
+	if len(v.Options.SHA256) > 0 {
+		sum := sha256.Sum256(data)
+		if actual := hex.EncodeToString(sum[:]); !strings.EqualFold(actual, v.Options.SHA256) {
+			return fmt.Errorf("the contents of URL %q do not match the expected checksum: expected sha256 %s, got %s", v.URL, v.Options.SHA256, actual)
+		}
+	}
+	return NewStreamVisitor(bytes.NewReader(data), v.Mapper, v.URL.String(), false, v.Schema).Visit(fn)
+}
+
+func (v *URLVisitor) download() ([]byte, error) {
+	maxRedirects := v.Options.MaxRedirects
+	if maxRedirects == 0 {
+		maxRedirects = DefaultURLMaxRedirects
+	}
+	maxBytes := v.Options.MaxBytes
+	if maxBytes == 0 {
+		maxBytes = DefaultURLMaxBytes
 	}
-	data, err := ioutil.ReadAll(res.Body)
+	client := &http.Client{
+		Transport: v.Options.Transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", len(via)-1)
+			}
+			if req.URL.Scheme != "https" {
+				req.Header.Del("Authorization")
+			}
+			return nil
+		},
+	}
+
+	req, err := http.NewRequest("GET", v.URL.String(), nil)
 	if err != nil {
-		return fmt.Errorf("unable to read URL %q: %v\n", v.URL, err)
+		return nil, fmt.Errorf("unable to access URL %q: %v", v.URL, err)
 	}
-	if err := ValidateSchema(data, v.Schema); err != nil {
-		return fmt.Errorf("error validating %q: %v", v.URL, err)
+	for key, values := range v.Options.Header {
+		if key == "Authorization" && v.URL.Scheme != "https" {
+			return nil, fmt.Errorf("unable to access URL %q: credentials may only be sent to https URLs", v.URL)
+		}
+		req.Header[key] = values
 	}
-	info, err := v.Mapper.InfoForData(data, v.URL.String())
+	res, err := client.Do(req)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("unable to access URL %q: %v", v.URL, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("unable to read URL %q, server reported %d %s", v.URL, res.StatusCode, res.Status)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(res.Body, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read URL %q: %v", v.URL, err)
 	}
-	return fn(info)
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("unable to read URL %q: the manifest is larger than %d bytes", v.URL, maxBytes)
+	}
+	return data, nil
 }
 
 // DecoratedVisitor will invoke the decorators in order prior to invoking the visitor function
@@ -278,11 +430,14 @@ type ContinueOnErrorVisitor struct {
 // error.  If the provided visitor fails on any individual item it
 // will not prevent the remaining items from being visited. An error
 // returned by the visitor directly may still result in some items
-// not being visited.
+// not being visited. It is safe to wrap a ParallelVisitor.
 func (v ContinueOnErrorVisitor) Visit(fn VisitorFunc) error {
+	var lock sync.Mutex
 	errs := []error{}
 	err := v.Visitor.Visit(func(info *Info) error {
 		if err := fn(info); err != nil {
+			lock.Lock()
+			defer lock.Unlock()
 			errs = append(errs, err)
 		}
 		return nil
@@ -290,17 +445,45 @@ func (v ContinueOnErrorVisitor) Visit(fn VisitorFunc) error {
 	if err != nil {
 		errs = append(errs, err)
 	}
-	if len(errs) == 1 {
-		return errs[0]
+	// Sources visited eagerly report their failures as aggregates of their own, so
+	// flatten them into a single list with one entry per failed item.
+	agg := errors.Flatten(errors.NewAggregate(errs))
+	if agg == nil {
+		return nil
+	}
+	if len(agg.Errors()) == 1 {
+		return agg.Errors()[0]
 	}
-	return errors.NewAggregate(errs)
+	return agg
+}
+
+// DocumentError is returned for an object in a file or stream that could not be
+// decoded or failed validation. It records where the object came from so that all
+// of the failures in a run can be reported, and fixed, together.
+type DocumentError struct {
+	// Source is the file, URL, or stream name the object was read from.
+	Source string
+	// Document is the position of the object within Source, starting at 1.
+	Document int
+	Err      error
+}
+
+// Error implements error
+func (e *DocumentError) Error() string {
+	return fmt.Sprintf("error in %s (document %d): %v", e.Source, e.Document, e.Err)
+}
+
+// Cause returns the error the object failed with.
+func (e *DocumentError) Cause() error {
+	return e.Err
 }
 
 // FlattenListVisitor flattens any objects that runtime.ExtractList recognizes as a list
 // - has an "Items" public field that is a slice of runtime.Objects or objects satisfying
-// that interface - into multiple Infos. An error on any sub item (for instance, if a List
-// contains an object that does not have a registered client or resource) will terminate
-// the visit.
+// that interface - into multiple Infos. Items that are lists themselves are flattened in
+// turn, and every item keeps the source and document of the list it was read from. An
+// error on any sub item (for instance, if a List contains an object that does not have
+// a registered client or resource) will terminate the visit.
 // TODO: allow errors to be aggregated?
 type FlattenListVisitor struct {
 	Visitor
@@ -315,33 +498,41 @@ func NewFlattenListVisitor(v Visitor, mapper *Mapper) Visitor {
 
 func (v FlattenListVisitor) Visit(fn VisitorFunc) error {
 	return v.Visitor.Visit(func(info *Info) error {
-		if info.Object == nil {
-			return fn(info)
-		}
-		items, err := runtime.ExtractList(info.Object)
+		return v.flatten(info, fn)
+	})
+}
+
+// flatten visits the items of the object of info if it is a list, and info itself
+// otherwise.
+func (v FlattenListVisitor) flatten(info *Info, fn VisitorFunc) error {
+	if info.Object == nil || info.Mapping == nil {
+		return fn(info)
+	}
+	items, err := runtime.ExtractList(info.Object)
+	if err != nil {
+		return fn(info)
+	}
+	if errs := runtime.DecodeList(items, struct {
+		runtime.ObjectTyper
+		runtime.Decoder
+	}{v.Mapper, info.Mapping.Codec}); len(errs) > 0 {
+		return errors.NewAggregate(errs)
+	}
+	for i := range items {
+		item, err := v.InfoForObject(items[i])
 		if err != nil {
-			return fn(info)
+			return err
 		}
-		if errs := runtime.DecodeList(items, struct {
-			runtime.ObjectTyper
-			runtime.Decoder
-		}{v.Mapper, info.Mapping.Codec}); len(errs) > 0 {
-			return errors.NewAggregate(errs)
+		item.Source = info.Source
+		item.Document = info.Document
+		if len(info.ResourceVersion) != 0 {
+			item.ResourceVersion = info.ResourceVersion
 		}
-		for i := range items {
-			item, err := v.InfoForObject(items[i])
-			if err != nil {
-				return err
-			}
-			if len(info.ResourceVersion) != 0 {
-				item.ResourceVersion = info.ResourceVersion
-			}
-			if err := fn(item); err != nil {
-				return err
-			}
+		if err := v.flatten(item, fn); err != nil {
+			return err
 		}
-		return nil
-	})
+	}
+	return nil
 }
 
 func ignoreFile(path string, extensions []string) bool {
@@ -400,6 +591,88 @@ func ExpandPathsToFileVisitors(mapper *Mapper, paths string, recursive bool, ext
 	return visitors, nil
 }
 
+// hasGlobMeta returns true if path contains any of the special characters of a glob pattern.
+func hasGlobMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// ExpandGlob returns the paths that match pattern, in lexical order. In addition to the
+// syntax of filepath.Match, a path segment of "**" matches any number of directories,
+// so "manifests/**/*.yaml" matches every .yaml file below manifests.
+func ExpandGlob(pattern string) ([]string, error) {
+	if !strings.Contains(pattern, "**") {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		return matches, nil
+	}
+
+	// Walk the longest leading directory of the pattern that has no wildcards.
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	i := 0
+	for i < len(segments) && !hasGlobMeta(segments[i]) {
+		i++
+	}
+	root := filepath.FromSlash(strings.Join(segments[:i], "/"))
+	switch {
+	case len(root) > 0:
+	case filepath.IsAbs(pattern):
+		root = string(filepath.Separator)
+	default:
+		root = "."
+	}
+
+	var matches []string
+	err := filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		ok, err := matchSegments(segments[i:], strings.Split(filepath.ToSlash(rel), "/"))
+		if err != nil {
+			return err
+		}
+		if ok {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// matchSegments returns true if the segments of a path match the segments of a pattern.
+func matchSegments(pattern, path []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(path); i++ {
+				if ok, err := matchSegments(pattern[1:], path[i:]); ok || err != nil {
+					return ok, err
+				}
+			}
+			return false, nil
+		}
+		if len(path) == 0 {
+			return false, nil
+		}
+		if ok, err := filepath.Match(pattern[0], path[0]); !ok || err != nil {
+			return false, err
+		}
+		pattern, path = pattern[1:], path[1:]
+	}
+	return len(path) == 0, nil
+}
+
 // FileVisitor is wrapping around a StreamVisitor, to handle open/close files
 type FileVisitor struct {
 	Path string
@@ -447,33 +720,48 @@ func NewStreamVisitor(r io.Reader, mapper *Mapper, source string, ignoreErrors b
 	}
 }
 
-// Visit implements Visitor over a stream. StreamVisitor is able to distinct multiple resources in one stream.
+// Visit implements Visitor over a stream. StreamVisitor is able to distinct multiple resources in one stream:
+// YAML documents separated by "---" lines, or JSON objects following one another. Each Info records the
+// position of its object in the stream as Document.
+// If IgnoreErrors is set, objects that fail validation or cannot be decoded are skipped and the remaining objects in the
+// stream are still visited; the failures are returned as an aggregate of DocumentErrors once the
+// stream is exhausted.
 func (v *StreamVisitor) Visit(fn VisitorFunc) error {
 	d := yaml.NewYAMLOrJSONDecoder(v.Reader, 4096)
-	for {
+	errs := []error{}
+	for document := 1; ; document++ {
 		ext := runtime.RawExtension{}
 		if err := d.Decode(&ext); err != nil {
 			if err == io.EOF {
-				return nil
+				return errors.NewAggregate(errs)
 			}
-			return err
+			// The rest of the stream cannot be read reliably after a syntax error.
+			errs = append(errs, &DocumentError{v.Source, document, err})
+			return errors.NewAggregate(errs)
 		}
 		ext.RawJSON = bytes.TrimSpace(ext.RawJSON)
 		if len(ext.RawJSON) == 0 || bytes.Equal(ext.RawJSON, []byte("null")) {
 			continue
 		}
 		if err := ValidateSchema(ext.RawJSON, v.Schema); err != nil {
+			err = &DocumentError{v.Source, document, err}
+			if v.IgnoreErrors {
+				errs = append(errs, err)
+				continue
+			}
 			return err
 		}
 		info, err := v.InfoForData(ext.RawJSON, v.Source)
 		if err != nil {
+			err = &DocumentError{v.Source, document, err}
 			if v.IgnoreErrors {
-				fmt.Fprintf(os.Stderr, "error: could not read an encoded object from %s: %v\n", v.Source, err)
 				glog.V(4).Infof("Unreadable: %s", string(ext.RawJSON))
+				errs = append(errs, err)
 				continue
 			}
 			return err
 		}
+		info.Document = document
 		if err := fn(info); err != nil {
 			return err
 		}
@@ -532,6 +820,20 @@ func RequireNamespace(namespace string) VisitorFunc {
 	}
 }
 
+// OverrideNamespace sets the namespace of every namespace scoped Info object, and
+// of info.Object if set, to namespace, replacing the namespace the object specified.
+// It is the alternative to RequireNamespace for callers that want manifests written
+// for another namespace to be applied to this one.
+func OverrideNamespace(namespace string) VisitorFunc {
+	return func(info *Info) error {
+		if !info.Namespaced() || len(namespace) == 0 || info.Namespace == namespace {
+			return nil
+		}
+		info.Namespace = namespace
+		return UpdateObjectNamespace(info)
+	}
+}
+
 // RetrieveLatest updates the Object on each Info by invoking a standard client
 // Get.
 func RetrieveLatest(info *Info) error {
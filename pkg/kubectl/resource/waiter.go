@@ -0,0 +1,141 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/wait"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+)
+
+// DefaultWaitPollInterval is how often a Waiter retrieves an object it is unable to
+// watch.
+const DefaultWaitPollInterval = time.Second
+
+// WaitCondition reports whether obj, the current state of the object being waited
+// for, satisfies the condition. obj is nil if the object does not exist. Returning an
+// error stops the wait.
+type WaitCondition func(obj runtime.Object) (bool, error)
+
+// Exists is satisfied once the object exists.
+func Exists(obj runtime.Object) (bool, error) {
+	return obj != nil, nil
+}
+
+// Deleted is satisfied once the object no longer exists.
+func Deleted(obj runtime.Object) (bool, error) {
+	return obj == nil, nil
+}
+
+// Waiter blocks until an object reaches a condition. Changes to the object are
+// watched for, and if the object cannot be watched it is retrieved periodically
+// instead.
+type Waiter struct {
+	Helper ResourceHelper
+	// How often the object is retrieved while it cannot be watched.
+	PollInterval time.Duration
+}
+
+// NewWaiter creates a Waiter for objects of the resource helper operates on.
+func NewWaiter(helper ResourceHelper) *Waiter {
+	return &Waiter{
+		Helper:       helper,
+		PollInterval: DefaultWaitPollInterval,
+	}
+}
+
+// Wait blocks until the named object satisfies condition or timeout passes, and
+// returns the last state of the object it observed, which is nil if the object does
+// not exist. wait.ErrWaitTimeout is returned if the timeout passes first.
+func (w *Waiter) Wait(namespace, name string, condition WaitCondition, timeout time.Duration) (runtime.Object, error) {
+	deadline := time.After(timeout)
+	for {
+		// The watch is opened before the object is retrieved so that no change
+		// made between the two is missed.
+		watcher, watchErr := w.Helper.WatchSingle(namespace, name, "")
+		obj, done, err := w.check(namespace, name, condition)
+		if done || err != nil {
+			if watchErr == nil {
+				watcher.Stop()
+			}
+			return obj, err
+		}
+
+		if watchErr != nil {
+			glog.V(4).Infof("Unable to watch %q, retrieving it periodically instead: %v", name, watchErr)
+		} else {
+			obj, done, err = w.watch(watcher, obj, condition, deadline)
+			watcher.Stop()
+			if done || err != nil {
+				return obj, err
+			}
+		}
+
+		// The watch could not be opened or was closed, so wait before trying again.
+		select {
+		case <-deadline:
+			return obj, wait.ErrWaitTimeout
+		case <-time.After(w.PollInterval):
+		}
+	}
+}
+
+// check retrieves the object and evaluates condition against it.
+func (w *Waiter) check(namespace, name string, condition WaitCondition) (runtime.Object, bool, error) {
+	obj, err := getLatest(w.Helper, namespace, name)
+	if errors.IsNotFound(err) {
+		obj, err = nil, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	done, err := condition(obj)
+	return obj, done, err
+}
+
+// watch evaluates condition against every change to the object delivered by watcher
+// until it is satisfied, the watch ends, or the deadline passes. An expired
+// deadline is reported as wait.ErrWaitTimeout.
+func (w *Waiter) watch(watcher watch.Interface, obj runtime.Object, condition WaitCondition, deadline <-chan time.Time) (runtime.Object, bool, error) {
+	for {
+		select {
+		case <-deadline:
+			return obj, false, wait.ErrWaitTimeout
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return obj, false, nil
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				obj = event.Object
+			case watch.Deleted:
+				obj = nil
+			default:
+				return obj, false, nil
+			}
+			done, err := condition(obj)
+			if done || err != nil {
+				return obj, done, err
+			}
+		}
+	}
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/testapi"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/wait"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+)
+
+func podInPhase(phase api.PodPhase) *api.Pod {
+	return &api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "foo", Namespace: "test", ResourceVersion: "10"},
+		Spec: api.PodSpec{
+			RestartPolicy: api.RestartPolicyAlways,
+			DNSPolicy:     api.DNSClusterFirst,
+		},
+		Status: api.PodStatus{Phase: phase},
+	}
+}
+
+func isRunning(obj runtime.Object) (bool, error) {
+	pod, ok := obj.(*api.Pod)
+	return ok && pod.Status.Phase == api.PodRunning, nil
+}
+
+func TestWaiter(t *testing.T) {
+	notFound := func() *http.Response {
+		return &http.Response{StatusCode: http.StatusNotFound, Body: objBody(&api.Status{Status: api.StatusFailure, Code: http.StatusNotFound, Reason: api.StatusReasonNotFound})}
+	}
+	tests := map[string]struct {
+		// Gets returns the response to each successive GET of the object; the
+		// last response is repeated.
+		Gets      []func() *http.Response
+		Watch     func() *http.Response
+		Condition WaitCondition
+		Timeout   time.Duration
+
+		Expect runtime.Object
+		Err    error
+	}{
+		"already satisfied": {
+			Gets: []func() *http.Response{func() *http.Response {
+				return &http.Response{StatusCode: http.StatusOK, Body: objBody(podInPhase(api.PodRunning))}
+			}},
+			Condition: isRunning,
+			Expect:    podInPhase(api.PodRunning),
+		},
+		"satisfied by a watch event": {
+			Gets: []func() *http.Response{func() *http.Response {
+				return &http.Response{StatusCode: http.StatusOK, Body: objBody(podInPhase(api.PodPending))}
+			}},
+			Watch: func() *http.Response {
+				return &http.Response{StatusCode: http.StatusOK, Body: stringBody(watchBody(
+					watch.Event{Type: watch.Modified, Object: podInPhase(api.PodPending)},
+					watch.Event{Type: watch.Modified, Object: podInPhase(api.PodRunning)},
+				))}
+			},
+			Condition: isRunning,
+			Expect:    podInPhase(api.PodRunning),
+		},
+		"deleted": {
+			Gets: []func() *http.Response{func() *http.Response {
+				return &http.Response{StatusCode: http.StatusOK, Body: objBody(podInPhase(api.PodRunning))}
+			}},
+			Watch: func() *http.Response {
+				return &http.Response{StatusCode: http.StatusOK, Body: stringBody(watchBody(
+					watch.Event{Type: watch.Deleted, Object: podInPhase(api.PodRunning)},
+				))}
+			},
+			Condition: Deleted,
+		},
+		"polls when the object cannot be watched": {
+			Gets: []func() *http.Response{
+				notFound,
+				notFound,
+				func() *http.Response {
+					return &http.Response{StatusCode: http.StatusOK, Body: objBody(podInPhase(api.PodPending))}
+				},
+			},
+			Watch: func() *http.Response {
+				return &http.Response{StatusCode: http.StatusMethodNotAllowed, Body: objBody(&api.Status{Code: http.StatusMethodNotAllowed})}
+			},
+			Condition: Exists,
+			Expect:    podInPhase(api.PodPending),
+		},
+		"times out": {
+			Gets: []func() *http.Response{func() *http.Response {
+				return &http.Response{StatusCode: http.StatusOK, Body: objBody(podInPhase(api.PodPending))}
+			}},
+			Watch:     func() *http.Response { return &http.Response{StatusCode: http.StatusOK, Body: stringBody("")} },
+			Condition: isRunning,
+			Timeout:   50 * time.Millisecond,
+			Expect:    podInPhase(api.PodPending),
+			Err:       wait.ErrWaitTimeout,
+		},
+	}
+	for k, test := range tests {
+		var lock sync.Mutex
+		gets := 0
+		client := &client.FakeRESTClient{
+			Codec: testapi.Codec(),
+			Client: client.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+				lock.Lock()
+				defer lock.Unlock()
+				switch req.URL.Path {
+				case "/namespaces/test/pods/foo":
+					resp := test.Gets[len(test.Gets)-1]
+					if gets < len(test.Gets) {
+						resp = test.Gets[gets]
+					}
+					gets++
+					return resp(), nil
+				case "/watch/namespaces/test/pods/foo":
+					if test.Watch == nil {
+						return &http.Response{StatusCode: http.StatusOK, Body: stringBody("")}, nil
+					}
+					return test.Watch(), nil
+				}
+				t.Fatalf("%s: unexpected request: %#v", k, req.URL)
+				return nil, nil
+			}),
+		}
+		mapping, err := latest.RESTMapper.RESTMapping("Pod", testapi.Version())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		waiter := NewWaiter(NewHelper(client, mapping))
+		waiter.PollInterval = 5 * time.Millisecond
+		timeout := test.Timeout
+		if timeout == 0 {
+			timeout = 5 * time.Second
+		}
+
+		obj, err := waiter.Wait("test", "foo", test.Condition, timeout)
+		if err != test.Err {
+			t.Errorf("%s: unexpected error: %v", k, err)
+		}
+		if test.Expect == nil {
+			if obj != nil {
+				t.Errorf("%s: expected no object, got %#v", k, obj)
+			}
+			continue
+		}
+		if !api.Semantic.DeepDerivative(test.Expect, obj) {
+			t.Errorf("%s: unexpected object: %#v", k, obj)
+		}
+	}
+}
+
+func TestWaiterWithFakeHelper(t *testing.T) {
+	f, _ := newFakePodHelper(t, podInPhase(api.PodPending))
+	defer f.Shutdown()
+	f.Reactions = []FakeHelperReaction{
+		func(action FakeHelperAction) (bool, runtime.Object, error) {
+			// the pod is deleted once the waiter watches it
+			if action.Verb == "watch" {
+				go f.Delete("test", "foo")
+			}
+			return false, nil, nil
+		},
+	}
+	obj, err := NewWaiter(f).Wait("test", "foo", Deleted, 5*time.Second)
+	if err != nil || obj != nil {
+		t.Errorf("unexpected result: %#v %v", obj, err)
+	}
+}
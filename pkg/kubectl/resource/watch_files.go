@@ -0,0 +1,216 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/errors"
+)
+
+// FileWatchOptions control how Builder.WatchFiles looks for changed manifests.
+type FileWatchOptions struct {
+	// How often the files are checked for changes. Defaults to one second.
+	Interval time.Duration
+	// How long the files must stay unchanged after a change before the manifests are
+	// visited again, so that saving several files at once causes a single run. If it
+	// is zero, the manifests are visited again as soon as a check finds no further
+	// changes.
+	Debounce time.Duration
+}
+
+// FileChanges lists the manifest files that were added, modified, or removed since
+// the manifests were last visited.
+type FileChanges struct {
+	Added    []string
+	Modified []string
+	Removed  []string
+}
+
+// Empty returns true if no file changed.
+func (c FileChanges) Empty() bool {
+	return len(c.Added) == 0 && len(c.Modified) == 0 && len(c.Removed) == 0
+}
+
+// String summarizes the changes.
+func (c FileChanges) String() string {
+	parts := []string{}
+	for _, change := range []struct {
+		verb  string
+		files []string
+	}{{"added", c.Added}, {"modified", c.Modified}, {"removed", c.Removed}} {
+		if len(change.files) > 0 {
+			parts = append(parts, fmt.Sprintf("%s %s", change.verb, strings.Join(change.files, ", ")))
+		}
+	}
+	if len(parts) == 0 {
+		return "no changes"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// FileChangeFunc is called by Builder.WatchFiles with the result of the builder each
+// time the manifests change, and with the changes that caused the run.
+type FileChangeFunc func(r *Result, changes FileChanges) error
+
+// WatchFiles calls fn with the result of the builder, and then again each time the
+// manifests passed to FilenameParam() or Path() change, until stop is closed. The
+// paths are read again for every run, so files added to a watched directory or
+// matching a watched pattern are picked up and removed files are left out. Files are
+// checked for changes by polling their size and modification time. Only manifests
+// read from files can be watched.
+//
+// WatchFiles returns the first error returned by fn, so fn should handle errors it
+// expects to recover from on a later run, such as invalid manifests, by reporting
+// them instead of returning them.
+func (b *Builder) WatchFiles(options FileWatchOptions, stop <-chan struct{}, fn FileChangeFunc) error {
+	if len(b.errs) > 0 {
+		return errors.NewAggregate(b.errs)
+	}
+	if b.stream {
+		return fmt.Errorf("manifests read from a stream cannot be watched")
+	}
+	for _, visitor := range b.paths {
+		if _, ok := visitor.(*FileVisitor); !ok {
+			return fmt.Errorf("only manifests read from files can be watched")
+		}
+	}
+	if len(b.filePaths) == 0 {
+		return fmt.Errorf("you must provide one or more files or directories to watch")
+	}
+	if options.Interval <= 0 {
+		options.Interval = time.Second
+	}
+
+	files := b.statFiles()
+	changes := FileChanges{}
+	for {
+		if err := fn(b.reread().Do(), changes); err != nil {
+			return err
+		}
+		var ok bool
+		if changes, files, ok = b.waitForChanges(files, options, stop); !ok {
+			return nil
+		}
+	}
+}
+
+// reread returns a copy of the builder that reads the paths passed to Path() again.
+func (b *Builder) reread() *Builder {
+	copied := *b
+	copied.paths = nil
+	copied.filePaths = nil
+	copied.dir = false
+	return copied.Path(b.filePaths...)
+}
+
+// fileState is the state of a file that changes when the file is written.
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// statFiles returns the state of every manifest file read from the paths passed to
+// Path(). Paths that cannot be read are left out.
+func (b *Builder) statFiles() map[string]fileState {
+	files := map[string]fileState{}
+	for _, p := range b.filePaths {
+		matches := []string{p}
+		if hasGlobMeta(p) {
+			matches, _ = ExpandGlob(p)
+		}
+		for _, match := range matches {
+			filepath.Walk(match, func(path string, fi os.FileInfo, err error) error {
+				if err != nil {
+					return nil
+				}
+				if fi.IsDir() {
+					if path != match && !b.recursive {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if !ignoreFile(path, manifestExtensions) {
+					files[path] = fileState{fi.Size(), fi.ModTime()}
+				}
+				return nil
+			})
+		}
+	}
+	return files
+}
+
+// waitForChanges checks the files every interval until they differ from last and
+// have then stayed unchanged for the debounce period, and returns the changes and
+// the new state of the files. It returns false if stop is closed first.
+func (b *Builder) waitForChanges(last map[string]fileState, options FileWatchOptions, stop <-chan struct{}) (FileChanges, map[string]fileState, bool) {
+	ticker := time.NewTicker(options.Interval)
+	defer ticker.Stop()
+
+	current := last
+	var changedAt time.Time
+	for {
+		select {
+		case <-stop:
+			return FileChanges{}, last, false
+		case <-ticker.C:
+		}
+		files := b.statFiles()
+		if !diffFiles(current, files).Empty() {
+			current = files
+			changedAt = time.Now()
+			continue
+		}
+		if changedAt.IsZero() || time.Since(changedAt) < options.Debounce {
+			continue
+		}
+		if changes := diffFiles(last, current); !changes.Empty() {
+			return changes, current, true
+		}
+		// the files were changed back
+		changedAt = time.Time{}
+	}
+}
+
+// diffFiles returns the changes from the files in before to those in after, each in
+// lexical order.
+func diffFiles(before, after map[string]fileState) FileChanges {
+	changes := FileChanges{}
+	for path, state := range after {
+		previous, found := before[path]
+		switch {
+		case !found:
+			changes.Added = append(changes.Added, path)
+		case previous.size != state.size || !previous.modTime.Equal(state.modTime):
+			changes.Modified = append(changes.Modified, path)
+		}
+	}
+	for path := range before {
+		if _, found := after[path]; !found {
+			changes.Removed = append(changes.Removed, path)
+		}
+	}
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Modified)
+	sort.Strings(changes.Removed)
+	return changes
+}
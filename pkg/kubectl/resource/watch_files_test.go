@@ -0,0 +1,128 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+func writePodManifest(t *testing.T, path, name string) {
+	pod := &api.Pod{ObjectMeta: api.ObjectMeta{Name: name, Namespace: "test"}}
+	if err := ioutil.WriteFile(path, []byte(runtime.EncodeOrDie(latest.Codec, pod)), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestWatchFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch-files")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	a, b := filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")
+	writePodManifest(t, a, "foo")
+
+	expected := []struct {
+		Names   []string
+		Changes FileChanges
+	}{
+		{[]string{"foo"}, FileChanges{}},
+		{[]string{"foo", "bar"}, FileChanges{Added: []string{b}}},
+		{[]string{"bar"}, FileChanges{Removed: []string{a}}},
+	}
+	stop := make(chan struct{})
+	runs := 0
+	err = NewBuilder(latest.RESTMapper, api.Scheme, fakeClient()).
+		NamespaceParam("test").Path(dir).
+		WatchFiles(FileWatchOptions{Interval: 10 * time.Millisecond}, stop, func(r *Result, changes FileChanges) error {
+			infos, err := r.Infos()
+			if err != nil {
+				t.Fatalf("%d: unexpected error: %v", runs, err)
+			}
+			if names := infoNames(infos); !reflect.DeepEqual(names, expected[runs].Names) {
+				t.Errorf("%d: unexpected infos: %v", runs, names)
+			}
+			if !reflect.DeepEqual(changes, expected[runs].Changes) {
+				t.Errorf("%d: unexpected changes: %#v", runs, changes)
+			}
+			switch runs {
+			case 0:
+				writePodManifest(t, b, "bar")
+			case 1:
+				os.Remove(a)
+			default:
+				close(stop)
+			}
+			runs++
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if runs != len(expected) {
+		t.Errorf("expected %d runs, got %d", len(expected), runs)
+	}
+}
+
+func TestWatchFilesStream(t *testing.T) {
+	err := NewBuilder(latest.RESTMapper, api.Scheme, fakeClient()).
+		Stream(strings.NewReader(""), "STDIN").
+		WatchFiles(FileWatchOptions{}, nil, func(*Result, FileChanges) error {
+			t.Fatalf("unexpected run")
+			return nil
+		})
+	if err == nil || !strings.Contains(err.Error(), "cannot be watched") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDiffFiles(t *testing.T) {
+	now := time.Now()
+	before := map[string]fileState{
+		"a.yaml": {10, now},
+		"b.yaml": {10, now},
+		"c.yaml": {10, now},
+		"d.yaml": {10, now},
+	}
+	after := map[string]fileState{
+		"a.yaml": {10, now},
+		"b.yaml": {12, now},
+		"c.yaml": {10, now.Add(time.Second)},
+		"e.yaml": {10, now},
+	}
+	changes := diffFiles(before, after)
+	expected := FileChanges{Added: []string{"e.yaml"}, Modified: []string{"b.yaml", "c.yaml"}, Removed: []string{"d.yaml"}}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("unexpected changes: %#v", changes)
+	}
+	if s := changes.String(); s != "added e.yaml; modified b.yaml, c.yaml; removed d.yaml" {
+		t.Errorf("unexpected summary: %s", s)
+	}
+	if !diffFiles(after, after).Empty() || diffFiles(after, after).String() != "no changes" {
+		t.Errorf("expected no changes")
+	}
+}
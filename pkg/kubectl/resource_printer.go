@@ -31,9 +31,11 @@ import (
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/conversion"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/kubectl/resource"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/types"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/jsonpath"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/volume"
 	"github.com/ghodss/yaml"
 	"github.com/golang/glog"
@@ -72,6 +74,15 @@ func GetPrinter(format, formatArgument string) (ResourcePrinter, bool, error) {
 		if err != nil {
 			return nil, false, fmt.Errorf("error parsing template %s, %v\n", string(data), err)
 		}
+	case "jsonpath":
+		if len(formatArgument) == 0 {
+			return nil, false, fmt.Errorf("jsonpath format specified but no expression given")
+		}
+		var err error
+		printer, err = NewJSONPathPrinter(formatArgument)
+		if err != nil {
+			return nil, false, fmt.Errorf("error parsing jsonpath %s, %v\n", formatArgument, err)
+		}
 	case "wide":
 		fallthrough
 	case "":
@@ -239,6 +250,12 @@ func (h *HumanReadablePrinter) validatePrintHandlerFunc(printFunc reflect.Value)
 	return nil
 }
 
+// Handles returns true if the printer knows how to print objects of the type of obj.
+func (h *HumanReadablePrinter) Handles(obj runtime.Object) bool {
+	_, ok := h.handlerMap[reflect.TypeOf(obj)]
+	return ok
+}
+
 func (h *HumanReadablePrinter) HandledResources() []string {
 	keys := make([]string, 0)
 
@@ -953,6 +970,31 @@ func (h *HumanReadablePrinter) PrintObj(obj runtime.Object, output io.Writer) er
 	return fmt.Errorf("error: unknown type %#v", obj)
 }
 
+// JSONPathPrinter is an implementation of ResourcePrinter which prints the values a
+// JSONPath expression selects from an object.
+type JSONPathPrinter struct {
+	path *jsonpath.JSONPath
+}
+
+// NewJSONPathPrinter returns a printer for the JSONPath expression expr, or an error if
+// the expression cannot be parsed.
+func NewJSONPathPrinter(expr string) (*JSONPathPrinter, error) {
+	path, err := jsonpath.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONPathPrinter{path}, nil
+}
+
+// PrintObj prints the values selected from obj, separated by spaces.
+func (p *JSONPathPrinter) PrintObj(obj runtime.Object, w io.Writer) error {
+	data, err := resource.ObjectData(obj)
+	if err != nil {
+		return err
+	}
+	return p.path.Print(w, data)
+}
+
 // TemplatePrinter is an implementation of ResourcePrinter which formats data with a Go Template.
 type TemplatePrinter struct {
 	rawTemplate string
@@ -164,6 +164,43 @@ func TestPrintTemplate(t *testing.T) {
 	}
 }
 
+func TestPrintJSONPath(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	printer, found, err := GetPrinter("jsonpath", "{.metadata.labels['app']}")
+	if err != nil || !found {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+	unversionedPod := &api.Pod{ObjectMeta: api.ObjectMeta{Name: "foo", Labels: map[string]string{"app": "web"}}}
+	obj, err := api.Scheme.ConvertToVersion(unversionedPod, testapi.Version())
+	err = printer.PrintObj(obj, buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+	if buf.String() != "web\n" {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+
+	// whole numbers are printed as integers
+	buf.Reset()
+	printer, _, err = GetPrinter("jsonpath", "{.spec.activeDeadlineSeconds}")
+	if err != nil {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+	deadline := int64(1000000)
+	unversionedPod.Spec.ActiveDeadlineSeconds = &deadline
+	obj, err = api.Scheme.ConvertToVersion(unversionedPod, testapi.Version())
+	if err := printer.PrintObj(obj, buf); err != nil {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+	if buf.String() != "1000000\n" {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+
+	if _, _, err := GetPrinter("jsonpath", ".metadata[name"); err == nil {
+		t.Errorf("unexpected non-error")
+	}
+}
+
 func TestPrintEmptyTemplate(t *testing.T) {
 	if _, _, err := GetPrinter("template", ""); err == nil {
 		t.Errorf("unexpected non-error")
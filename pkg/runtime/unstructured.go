@@ -18,8 +18,6 @@ package runtime
 
 import (
 	"encoding/json"
-	"fmt"
-	"reflect"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/conversion"
 )
@@ -28,6 +26,13 @@ import (
 // type, which can be used for generic access to objects without a predefined scheme.
 var UnstructuredJSONScheme ObjectDecoder = unstructuredJSONScheme{}
 
+// UnstructuredJSONCodec decodes objects of any kind into the Unstructured type and
+// encodes them back to JSON unchanged, so that clients can read and write resources
+// they have no compiled-in types for, such as those provided by a plug-in. Objects
+// that are not Unstructured, such as the api.Status returned with a failed request,
+// are decoded and encoded as plain JSON.
+var UnstructuredJSONCodec Codec = unstructuredJSONScheme{}
+
 type unstructuredJSONScheme struct{}
 
 // Recognizes returns true for any version or kind that is specified (internal
@@ -47,7 +52,7 @@ func (s unstructuredJSONScheme) Decode(data []byte) (Object, error) {
 func (unstructuredJSONScheme) DecodeInto(data []byte, obj Object) error {
 	unstruct, ok := obj.(*Unstructured)
 	if !ok {
-		return fmt.Errorf("the unstructured JSON scheme does not recognize %v", reflect.TypeOf(obj))
+		return json.Unmarshal(data, obj)
 	}
 
 	m := make(map[string]interface{})
@@ -74,6 +79,23 @@ func (unstructuredJSONScheme) DecodeInto(data []byte, obj Object) error {
 	return nil
 }
 
+func (unstructuredJSONScheme) Encode(obj Object) ([]byte, error) {
+	unstruct, ok := obj.(*Unstructured)
+	if !ok {
+		return json.Marshal(obj)
+	}
+	if unstruct.Object == nil {
+		unstruct.Object = make(map[string]interface{})
+	}
+	if len(unstruct.APIVersion) > 0 {
+		unstruct.Object["apiVersion"] = unstruct.APIVersion
+	}
+	if len(unstruct.Kind) > 0 {
+		unstruct.Object["kind"] = unstruct.Kind
+	}
+	return json.Marshal(unstruct.Object)
+}
+
 func (unstructuredJSONScheme) DataVersionAndKind(data []byte) (version, kind string, err error) {
 	obj := TypeMeta{}
 	if err := json.Unmarshal(data, &obj); err != nil {
@@ -46,3 +46,33 @@ func TestDecodeUnstructured(t *testing.T) {
 		t.Errorf("object should not have been converted: %#v", pl.Items[2])
 	}
 }
+
+func TestUnstructuredJSONCodec(t *testing.T) {
+	data := []byte(`{"kind":"Widget","apiVersion":"example.com/v1","metadata":{"name":"foo"},"spec":{"size":3}}`)
+	obj, err := runtime.UnstructuredJSONCodec.Decode(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	widget, ok := obj.(*runtime.Unstructured)
+	if !ok || widget.Kind != "Widget" || widget.APIVersion != "example.com/v1" {
+		t.Fatalf("unexpected object: %#v", obj)
+	}
+
+	widget.Kind = "Gadget"
+	out, err := runtime.UnstructuredJSONCodec.Encode(widget)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"apiVersion":"example.com/v1","kind":"Gadget","metadata":{"name":"foo"},"spec":{"size":3}}`
+	if string(out) != expected {
+		t.Errorf("unexpected encoding: %s", string(out))
+	}
+
+	status := &api.Status{}
+	if err := runtime.UnstructuredJSONCodec.DecodeInto([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","code":404}`), status); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status.Status != api.StatusFailure || status.Code != 404 {
+		t.Errorf("unexpected status: %#v", status)
+	}
+}
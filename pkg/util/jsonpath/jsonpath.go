@@ -0,0 +1,179 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package jsonpath evaluates JSONPath expressions against data decoded from JSON.
+// A subset of JSONPath is supported: field names (.metadata.name), quoted field
+// names for keys containing dots (.metadata.labels['app.kubernetes.io/name']), list
+// indices ([0], or [-1] for the last item), and wildcards ([*]) that select every
+// item of a list or every value of a map. The expression may be wrapped in braces
+// and start with $ to denote the root.
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type stepKind int
+
+const (
+	fieldStep stepKind = iota
+	indexStep
+	wildcardStep
+)
+
+type step struct {
+	kind  stepKind
+	field string
+	index int
+}
+
+// JSONPath is a parsed JSONPath expression.
+type JSONPath struct {
+	expr  string
+	steps []step
+}
+
+// Parse parses a JSONPath expression.
+func Parse(expr string) (*JSONPath, error) {
+	path := strings.TrimSpace(expr)
+	if strings.HasPrefix(path, "{") && strings.HasSuffix(path, "}") {
+		path = strings.TrimSpace(path[1 : len(path)-1])
+	}
+	path = strings.TrimPrefix(path, "$")
+
+	steps := []step{}
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			end := strings.IndexAny(path[1:], ".[")
+			if end == -1 {
+				end = len(path) - 1
+			}
+			name := path[1 : end+1]
+			if len(name) == 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q: empty field name", expr)
+			}
+			if name == "*" {
+				steps = append(steps, step{kind: wildcardStep})
+			} else {
+				steps = append(steps, step{kind: fieldStep, field: name})
+			}
+			path = path[end+1:]
+		case '[':
+			end := strings.Index(path, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("invalid JSONPath %q: unterminated [", expr)
+			}
+			inner := strings.TrimSpace(path[1:end])
+			switch {
+			case inner == "*":
+				steps = append(steps, step{kind: wildcardStep})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				steps = append(steps, step{kind: fieldStep, field: inner[1 : len(inner)-1]})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid JSONPath %q: %q is not an index", expr, inner)
+				}
+				steps = append(steps, step{kind: indexStep, index: index})
+			}
+			path = path[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid JSONPath %q: unexpected %q", expr, path[0])
+		}
+	}
+	return &JSONPath{expr: expr, steps: steps}, nil
+}
+
+// String returns the expression the JSONPath was parsed from.
+func (j *JSONPath) String() string {
+	return j.expr
+}
+
+// Execute returns the values the expression selects from data, which must be made of
+// the types encoding/json decodes into. Parts of the expression that do not match
+// data, such as a missing field, select nothing rather than returning an error.
+func (j *JSONPath) Execute(data interface{}) []interface{} {
+	values := []interface{}{data}
+	for _, s := range j.steps {
+		next := []interface{}{}
+		for _, value := range values {
+			switch s.kind {
+			case fieldStep:
+				if m, ok := value.(map[string]interface{}); ok {
+					if v, ok := m[s.field]; ok {
+						next = append(next, v)
+					}
+				}
+			case indexStep:
+				if l, ok := value.([]interface{}); ok {
+					index := s.index
+					if index < 0 {
+						index += len(l)
+					}
+					if index >= 0 && index < len(l) {
+						next = append(next, l[index])
+					}
+				}
+			case wildcardStep:
+				switch t := value.(type) {
+				case []interface{}:
+					next = append(next, t...)
+				case map[string]interface{}:
+					keys := []string{}
+					for key := range t {
+						keys = append(keys, key)
+					}
+					sort.Strings(keys)
+					for _, key := range keys {
+						next = append(next, t[key])
+					}
+				}
+			}
+		}
+		values = next
+	}
+	return values
+}
+
+// Print writes the values the expression selects from data to w, separated by
+// spaces and followed by a newline. Strings are written as is and maps and lists as
+// JSON.
+func (j *JSONPath) Print(w io.Writer, data interface{}) error {
+	values := j.Execute(data)
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		switch t := value.(type) {
+		case string:
+			out = append(out, t)
+		case map[string]interface{}, []interface{}:
+			encoded, err := json.Marshal(t)
+			if err != nil {
+				return err
+			}
+			out = append(out, string(encoded))
+		default:
+			out = append(out, fmt.Sprintf("%v", t))
+		}
+	}
+	_, err := fmt.Fprintln(w, strings.Join(out, " "))
+	return err
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const testJSON = `{
+	"metadata": {"name": "foo", "labels": {"app.kubernetes.io/name": "web", "tier": "frontend"}},
+	"spec": {"replicas": 3, "containers": [{"name": "a", "image": "nginx"}, {"name": "b", "image": "redis"}]}
+}`
+
+func TestExecute(t *testing.T) {
+	data := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(testJSON), &data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := []struct {
+		Expr   string
+		Expect []interface{}
+	}{
+		{"{.metadata.name}", []interface{}{"foo"}},
+		{"$.spec.replicas", []interface{}{float64(3)}},
+		{".metadata.labels['app.kubernetes.io/name']", []interface{}{"web"}},
+		{".spec.containers[*].image", []interface{}{"nginx", "redis"}},
+		{".spec.containers[-1].name", []interface{}{"b"}},
+		{".metadata.labels.*", []interface{}{"web", "frontend"}},
+		{".spec.containers[5].name", []interface{}{}},
+		{".metadata.missing", []interface{}{}},
+	}
+	for _, test := range tests {
+		path, err := Parse(test.Expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.Expr, err)
+			continue
+		}
+		if values := path.Execute(data); !reflect.DeepEqual(test.Expect, values) {
+			t.Errorf("%s: unexpected values: %#v", test.Expr, values)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{".spec[0", ".spec[a]", "spec", ".spec..name"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("%s: expected an error", expr)
+		}
+	}
+}
+
+func TestPrint(t *testing.T) {
+	data := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(testJSON), &data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	path, err := Parse("{.spec.containers[*].name}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	buf := &bytes.Buffer{}
+	if err := path.Print(buf, data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.String() != "a b\n" {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+}
@@ -44,6 +44,104 @@ func StrategicMergePatchData(original, patch []byte, dataStruct interface{}) ([]
 		return nil, err
 	}
 
+	t, err := structType(dataStruct)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := mergeMap(o, p, t)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(result)
+}
+
+// CreateTwoWayMergePatch computes a strategic merge patch that turns original into
+// modified: fields that were added or changed are set, fields that were removed are
+// deleted, and the elements of merging lists are matched by their merge key.
+// dataStruct must be the versioned type of the objects, whose tags describe the
+// patch strategy and merge key of every field.
+func CreateTwoWayMergePatch(original, modified []byte, dataStruct interface{}) ([]byte, error) {
+	t, err := structType(dataStruct)
+	if err != nil {
+		return nil, err
+	}
+
+	var o map[string]interface{}
+	if err := json.Unmarshal(original, &o); err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(modified, &m); err != nil {
+		return nil, err
+	}
+	pruneNulls(o)
+	pruneNulls(m)
+
+	patch, err := diffMaps(o, m, t, false, false)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(patch)
+}
+
+// CreateThreeWayMergePatch computes a strategic merge patch that turns current into
+// modified, without touching the fields of current that appear in neither original
+// nor modified. Fields that were removed between original and modified are deleted.
+// This is how a configuration that was applied before (original) is updated to a new
+// configuration (modified) while preserving the fields set by others on the live
+// object (current). dataStruct must be the versioned type of the objects, whose tags
+// describe the patch strategy and merge key of every field.
+func CreateThreeWayMergePatch(original, modified, current []byte, dataStruct interface{}) ([]byte, error) {
+	t, err := structType(dataStruct)
+	if err != nil {
+		return nil, err
+	}
+
+	o := map[string]interface{}{}
+	if len(original) > 0 {
+		if err := json.Unmarshal(original, &o); err != nil {
+			return nil, err
+		}
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(modified, &m); err != nil {
+		return nil, err
+	}
+	var c map[string]interface{}
+	if err := json.Unmarshal(current, &c); err != nil {
+		return nil, err
+	}
+
+	// A null in a configuration means the field is not set, as it would if the
+	// field was omitted.
+	pruneNulls(o)
+	pruneNulls(m)
+
+	// Additions and changes are computed against the live object, so that changes
+	// others made to the fields in the configuration are overwritten.
+	delta, err := diffMaps(c, m, t, false, true)
+	if err != nil {
+		return nil, err
+	}
+	// Deletions are computed against the previous configuration, so that fields
+	// others added to the live object are left alone.
+	deletions, err := diffMaps(o, m, t, true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	patch, err := mergeMap(deletions, delta, t)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(patch)
+}
+
+// structType returns the struct type of dataStruct, or an error if it is not a struct
+// or a pointer to one.
+func structType(dataStruct interface{}) (reflect.Type, error) {
 	t := reflect.TypeOf(dataStruct)
 	if t.Kind() == reflect.Ptr {
 		t = t.Elem()
@@ -51,13 +149,176 @@ func StrategicMergePatchData(original, patch []byte, dataStruct interface{}) ([]
 	if t.Kind() != reflect.Struct {
 		return nil, fmt.Errorf("strategic merge patch needs a struct, %s received instead", t.Kind().String())
 	}
+	return t, nil
+}
 
-	result, err := mergeMap(o, p, t)
+// Compute the patch that turns the original map into the modified map. Additions and
+// changes are left out of the patch if ignoreChangesAndAdditions is set, and deletions
+// if ignoreDeletions is set.
+func diffMaps(original, modified map[string]interface{}, t reflect.Type, ignoreChangesAndAdditions, ignoreDeletions bool) (map[string]interface{}, error) {
+	// If the data type is a pointer, resolve the element.
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	patch := map[string]interface{}{}
+	for k, modifiedV := range modified {
+		originalV, ok := original[k]
+		if !ok {
+			if !ignoreChangesAndAdditions {
+				patch[k] = modifiedV
+			}
+			continue
+		}
+		if reflect.DeepEqual(originalV, modifiedV) {
+			continue
+		}
+
+		// If they're both maps or lists, recurse into the value unless the field
+		// is replaced as a whole.
+		originalType := reflect.TypeOf(originalV)
+		if originalType != nil && originalType == reflect.TypeOf(modifiedV) &&
+			(originalType.Kind() == reflect.Map || originalType.Kind() == reflect.Slice) {
+			fieldType, fieldPatchStrategy, fieldPatchMergeKey, err := forkedjson.LookupPatchMetadata(t, k)
+			if err != nil {
+				return nil, err
+			}
+			if originalType.Kind() == reflect.Map && fieldPatchStrategy != "replace" {
+				p, err := diffMaps(originalV.(map[string]interface{}), modifiedV.(map[string]interface{}), fieldType, ignoreChangesAndAdditions, ignoreDeletions)
+				if err != nil {
+					return nil, err
+				}
+				if len(p) > 0 {
+					patch[k] = p
+				}
+				continue
+			}
+			if originalType.Kind() == reflect.Slice && fieldPatchStrategy == "merge" {
+				p, err := diffLists(originalV.([]interface{}), modifiedV.([]interface{}), fieldType.Elem(), fieldPatchMergeKey, ignoreChangesAndAdditions, ignoreDeletions)
+				if err != nil {
+					return nil, err
+				}
+				if len(p) > 0 {
+					patch[k] = p
+				}
+				continue
+			}
+		}
+
+		if !ignoreChangesAndAdditions {
+			patch[k] = modifiedV
+		}
+	}
+
+	if !ignoreDeletions {
+		for k := range original {
+			if _, ok := modified[k]; !ok {
+				patch[k] = nil
+			}
+		}
+	}
+	return patch, nil
+}
+
+// Compute the patch that turns the original merging list into the modified one.
+// Elements are matched by their merge key, so the order of the lists is ignored.
+func diffLists(original, modified []interface{}, elemType reflect.Type, mergeKey string, ignoreChangesAndAdditions, ignoreDeletions bool) ([]interface{}, error) {
+	// All the values must be of the same type, but not a list.
+	t, err := sliceElementType(original, modified)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("types of list elements need to be the same, type: %s: %v",
+			elemType.Kind().String(), err)
+	}
+	if t.Kind() == reflect.Slice {
+		return nil, fmt.Errorf("not supporting merging lists of lists yet")
 	}
 
-	return json.Marshal(result)
+	patch := []interface{}{}
+	// Merging lists of scalars are unioned, so there is no way to remove an element.
+	if t.Kind() != reflect.Map {
+		if !ignoreChangesAndAdditions {
+			for _, v := range modified {
+				if !containsScalar(original, v) {
+					patch = append(patch, v)
+				}
+			}
+		}
+		if !ignoreDeletions {
+			for _, v := range original {
+				if !containsScalar(modified, v) {
+					return nil, fmt.Errorf("cannot remove %v from a merging list of scalars", v)
+				}
+			}
+		}
+		return patch, nil
+	}
+
+	if mergeKey == "" {
+		return nil, fmt.Errorf("cannot merge lists without merge key for type %s", elemType.Kind().String())
+	}
+
+	for _, v := range modified {
+		modifiedMap := v.(map[string]interface{})
+		mergeValue, ok := modifiedMap[mergeKey]
+		if !ok {
+			return nil, fmt.Errorf("all list elements need the merge key %s", mergeKey)
+		}
+		originalMap, _, found := findMapInSliceBasedOnKeyValue(original, mergeKey, mergeValue)
+		if !found {
+			if !ignoreChangesAndAdditions {
+				patch = append(patch, modifiedMap)
+			}
+			continue
+		}
+		p, err := diffMaps(originalMap, modifiedMap, elemType, ignoreChangesAndAdditions, ignoreDeletions)
+		if err != nil {
+			return nil, err
+		}
+		if len(p) > 0 {
+			p[mergeKey] = mergeValue
+			patch = append(patch, p)
+		}
+	}
+
+	if !ignoreDeletions {
+		for _, v := range original {
+			mergeValue, ok := v.(map[string]interface{})[mergeKey]
+			if !ok {
+				return nil, fmt.Errorf("all list elements need the merge key %s", mergeKey)
+			}
+			if _, _, found := findMapInSliceBasedOnKeyValue(modified, mergeKey, mergeValue); !found {
+				patch = append(patch, map[string]interface{}{mergeKey: mergeValue, specialKey: "delete"})
+			}
+		}
+	}
+	return patch, nil
+}
+
+// Remove the keys with null values from m and from the maps nested in it.
+func pruneNulls(m map[string]interface{}) {
+	for k, v := range m {
+		switch typedV := v.(type) {
+		case nil:
+			delete(m, k)
+		case map[string]interface{}:
+			pruneNulls(typedV)
+		case []interface{}:
+			for _, e := range typedV {
+				if typedE, ok := e.(map[string]interface{}); ok {
+					pruneNulls(typedE)
+				}
+			}
+		}
+	}
+}
+
+func containsScalar(s []interface{}, v interface{}) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
 }
 
 const specialKey = "$patch"
@@ -478,6 +478,297 @@ func TestSortMergeLists(t *testing.T) {
 	}
 }
 
+type ThreeWayMergePatchCase struct {
+	Description string
+	Original    map[string]interface{}
+	Modified    map[string]interface{}
+	Current     map[string]interface{}
+	Patch       map[string]interface{}
+	Result      map[string]interface{}
+}
+
+var threeWayTestCaseData = []byte(`
+- description: nothing changed
+  original:
+    name: 1
+  modified:
+    name: 1
+  current:
+    name: 1
+    value: 1
+  patch: {}
+  result:
+    name: 1
+    value: 1
+- description: add and remove fields
+  original:
+    name: 1
+    value: 1
+  modified:
+    name: 1
+    simpleMap:
+      a: b
+  current:
+    name: 1
+    value: 1
+  patch:
+    value: null
+    simpleMap:
+      a: b
+  result:
+    name: 1
+    simpleMap:
+      a: b
+- description: keep fields set by others
+  original:
+    name: 1
+  modified:
+    name: 1
+    value: 2
+  current:
+    name: 1
+    value: 1
+    simpleMap:
+      x: y
+  patch:
+    value: 2
+  result:
+    name: 1
+    value: 2
+    simpleMap:
+      x: y
+- description: restore a configured field changed by others
+  original:
+    value: 1
+  modified:
+    value: 1
+  current:
+    value: 3
+  patch:
+    value: 1
+  result:
+    value: 1
+- description: remove a map entry that is no longer configured
+  original:
+    simpleMap:
+      a: b
+      c: d
+  modified:
+    simpleMap:
+      a: b
+  current:
+    simpleMap:
+      a: b
+      c: d
+      e: f
+  patch:
+    simpleMap:
+      c: null
+  result:
+    simpleMap:
+      a: b
+      e: f
+- description: merge list elements by key
+  original:
+    mergingList:
+      - name: 1
+        value: a
+      - name: 2
+  modified:
+    mergingList:
+      - name: 1
+        value: b
+      - name: 3
+  current:
+    mergingList:
+      - name: 1
+        value: a
+      - name: 2
+      - name: 4
+  patch:
+    mergingList:
+      - name: 2
+        $patch: delete
+      - name: 1
+        value: b
+      - name: 3
+  result:
+    mergingList:
+      - name: 1
+        value: b
+      - name: 3
+      - name: 4
+- description: replace non-merging lists
+  original:
+    nonMergingList:
+      - name: 1
+  modified:
+    nonMergingList:
+      - name: 2
+  current:
+    nonMergingList:
+      - name: 1
+  patch:
+    nonMergingList:
+      - name: 2
+  result:
+    nonMergingList:
+      - name: 2
+- description: add to a merging list of scalars
+  original:
+    mergingIntList:
+      - 1
+  modified:
+    mergingIntList:
+      - 1
+      - 2
+  current:
+    mergingIntList:
+      - 1
+      - 3
+  patch:
+    mergingIntList:
+      - 2
+  result:
+    mergingIntList:
+      - 1
+      - 2
+      - 3
+- description: nulls in the configuration are ignored
+  original:
+    name: 1
+    value: 1
+  modified:
+    name: 1
+    value: null
+    simpleMap: null
+  current:
+    name: 1
+    value: 1
+    simpleMap:
+      a: b
+  patch:
+    value: null
+  result:
+    name: 1
+    simpleMap:
+      a: b
+- description: create from an empty configuration
+  modified:
+    name: 1
+  current:
+    name: 1
+    value: 1
+  patch: {}
+  result:
+    name: 1
+    value: 1
+`)
+
+func TestCreateThreeWayMergePatch(t *testing.T) {
+	tc := []ThreeWayMergePatchCase{}
+	err := yaml.Unmarshal(threeWayTestCaseData, &tc)
+	if err != nil {
+		t.Errorf("can't unmarshal test cases: %v", err)
+		return
+	}
+
+	var e MergeItem
+	for _, c := range tc {
+		var original []byte
+		if c.Original != nil {
+			original = toJSON(c.Original)
+		}
+		patch, err := CreateThreeWayMergePatch(original, toJSON(c.Modified), toJSON(c.Current), e)
+		if err != nil {
+			t.Errorf("%s: error creating patch: %v", c.Description, err)
+			continue
+		}
+		if !reflect.DeepEqual(patch, toJSON(c.Patch)) {
+			t.Errorf("%s: unexpected patch:\nexpected:\n%s\ngot:\n%s",
+				c.Description, toYAML(c.Patch), jsonToYAML(patch))
+		}
+
+		result, err := StrategicMergePatchData(toJSON(c.Current), patch, e)
+		if err != nil {
+			t.Errorf("%s: error patching: %v", c.Description, err)
+			continue
+		}
+		result, err = sortMergeListsByName(result, e)
+		if err != nil {
+			t.Errorf("error sorting result object: %v", err)
+		}
+		cResult, err := sortMergeListsByName(toJSON(c.Result), e)
+		if err != nil {
+			t.Errorf("error sorting result object: %v", err)
+		}
+		if !reflect.DeepEqual(result, cResult) {
+			t.Errorf("%s: unexpected result:\nexpected:\n%s\ngot:\n%s",
+				c.Description, jsonToYAML(cResult), jsonToYAML(result))
+		}
+	}
+}
+
+func TestCreateThreeWayMergePatchRemoveScalar(t *testing.T) {
+	var e MergeItem
+	original := toJSON(map[string]interface{}{"mergingIntList": []int{1, 2}})
+	modified := toJSON(map[string]interface{}{"mergingIntList": []int{1}})
+	if _, err := CreateThreeWayMergePatch(original, modified, original, e); err == nil {
+		t.Errorf("expected an error removing an element from a merging list of scalars")
+	}
+}
+
+func TestCreateTwoWayMergePatch(t *testing.T) {
+	tc := []ThreeWayMergePatchCase{}
+	err := yaml.Unmarshal(threeWayTestCaseData, &tc)
+	if err != nil {
+		t.Errorf("can't unmarshal test cases: %v", err)
+		return
+	}
+
+	// Applying the patch between the configurations of each case to the original
+	// configuration must produce the modified one.
+	var e MergeItem
+	for _, c := range tc {
+		if c.Original == nil {
+			continue
+		}
+		patch, err := CreateTwoWayMergePatch(toJSON(c.Original), toJSON(c.Modified), e)
+		if err != nil {
+			t.Errorf("%s: error creating patch: %v", c.Description, err)
+			continue
+		}
+		result, err := StrategicMergePatchData(toJSON(c.Original), patch, e)
+		if err != nil {
+			t.Errorf("%s: error patching: %v", c.Description, err)
+			continue
+		}
+		result, err = sortMergeListsByName(result, e)
+		if err != nil {
+			t.Errorf("error sorting result object: %v", err)
+		}
+		// Nulls in the configuration mean the field is not set.
+		var m map[string]interface{}
+		if err := json.Unmarshal(toJSON(c.Modified), &m); err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.Description, err)
+		}
+		pruneNulls(m)
+		modified, err := sortMergeListsByName(toJSON(m), e)
+		if err != nil {
+			t.Errorf("error sorting modified object: %v", err)
+		}
+		if !reflect.DeepEqual(result, modified) {
+			t.Errorf("%s: unexpected result:\nexpected:\n%s\ngot:\n%s",
+				c.Description, jsonToYAML(modified), jsonToYAML(result))
+		}
+	}
+
+	patch, err := CreateTwoWayMergePatch(toJSON(map[string]interface{}{"name": "a"}), toJSON(map[string]interface{}{"name": "a"}), e)
+	if err != nil || string(patch) != "{}" {
+		t.Errorf("expected an empty patch for identical objects: %s %v", string(patch), err)
+	}
+}
+
 func toYAML(v interface{}) string {
 	y, err := yaml.Marshal(v)
 	if err != nil {
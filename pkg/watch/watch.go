@@ -42,6 +42,9 @@ const (
 	Modified EventType = "MODIFIED"
 	Deleted  EventType = "DELETED"
 	Error    EventType = "ERROR"
+	// Bookmark events are only sent to clients that ask for them, and only mark
+	// the resource version the watch has reached.
+	Bookmark EventType = "BOOKMARK"
 )
 
 // Event represents a single event to a watched resource.
@@ -53,6 +56,8 @@ type Event struct {
 	//  * If Type is Deleted: the state of the object immediately before deletion.
 	//  * If Type is Error: *api.Status is recommended; other types may make sense
 	//    depending on context.
+	//  * If Type is Bookmark: an object of the watched kind in which only the
+	//    resource version is set.
 	Object runtime.Object
 }
 